@@ -0,0 +1,84 @@
+// backend/cmd/server/audit_cmd.go
+package main
+
+import (
+	"backend/internal/audit"
+	"backend/internal/config"
+	"backend/internal/database"
+	"backend/internal/service"
+	"crypto/ed25519"
+	"flag"
+	"fmt"
+	"os"
+)
+
+// runAuditCommand は "server audit <subcommand>" を処理し、終了コードを返します。
+//
+//	server audit verify [--public-key path] [--config path] [その他の設定フラグ]
+//
+// verify は監査ログのハッシュチェーンを先頭からたどり、最初に見つかった切れ目を報告します。
+// チェーンが正常な場合は 0、切れ目が見つかった場合やエラーの場合は 1 を返します。
+func runAuditCommand(args []string) int {
+	if len(args) == 0 || args[0] != "verify" {
+		fmt.Fprintln(os.Stderr, "使い方: server audit verify [--public-key path] [--config path]")
+		return 2
+	}
+
+	fs := flag.NewFlagSet("audit verify", flag.ContinueOnError)
+	publicKeyFile := fs.String("public-key", "", "チェックポイントの署名を検証する Ed25519 公開鍵 (PEM)。省略した場合は AUDIT_CHECKPOINT_KEY から取り出します")
+
+	cfg, err := config.Load(fs, args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "audit verify: %v\n", err)
+		return 1
+	}
+
+	var pub ed25519.PublicKey
+	switch {
+	case *publicKeyFile != "":
+		data, err := os.ReadFile(*publicKeyFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "audit verify: 公開鍵を読み込めません: %v\n", err)
+			return 1
+		}
+		if pub, err = audit.ParsePublicKey(data); err != nil {
+			fmt.Fprintf(os.Stderr, "audit verify: %v\n", err)
+			return 1
+		}
+	case cfg.Audit.CheckpointKey != "":
+		if pub, err = audit.ParsePublicKey([]byte(cfg.Audit.CheckpointKey)); err != nil {
+			fmt.Fprintf(os.Stderr, "audit verify: %v\n", err)
+			return 1
+		}
+	}
+
+	if err := database.InitDB(cfg.DatabaseDSN); err != nil {
+		fmt.Fprintf(os.Stderr, "audit verify: %v\n", err)
+		return 1
+	}
+	defer database.DB.Close()
+
+	report, err := service.VerifyAuditChain(pub)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "audit verify: %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("検証したエントリー: %d (チェーン導入前のエントリー: %d)\n", report.Entries, report.Unchained)
+	if report.SignaturesVerified {
+		fmt.Printf("検証したチェックポイント: %d (署名: %s)\n", report.Checkpoints, audit.KeyID(pub))
+	} else {
+		fmt.Printf("検証したチェックポイント: %d (公開鍵がないため署名は検証していません)\n", report.Checkpoints)
+	}
+
+	if b := report.Broken; b != nil {
+		fmt.Printf("NG: エントリー %d", b.LogID)
+		if b.CheckpointID != 0 {
+			fmt.Printf(" / チェックポイント %d", b.CheckpointID)
+		}
+		fmt.Printf(": %s\n", b.Reason)
+		return 1
+	}
+	fmt.Printf("OK: チェーンの末尾 %d (%s)\n", report.LastLogID, report.LastHash)
+	return 0
+}
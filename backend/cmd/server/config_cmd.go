@@ -0,0 +1,43 @@
+// backend/cmd/server/config_cmd.go
+package main
+
+import (
+	"backend/internal/config"
+	"flag"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// runConfigCommand は "server config <subcommand>" を処理し、終了コードを返します。
+//
+//	server config print [--redacted] [--config path] [その他の設定フラグ]
+func runConfigCommand(args []string) int {
+	if len(args) == 0 || args[0] != "print" {
+		fmt.Fprintln(os.Stderr, "使い方: server config print [--redacted] [--config path]")
+		return 2
+	}
+
+	fs := flag.NewFlagSet("config print", flag.ContinueOnError)
+	redacted := fs.Bool("redacted", false, "秘密情報を伏せ字にして出力します")
+
+	cfg, err := config.Load(fs, args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "config print: %v\n", err)
+		return 1
+	}
+
+	out := *cfg
+	if *redacted {
+		out = cfg.Redacted()
+	}
+
+	enc := yaml.NewEncoder(os.Stdout)
+	enc.SetIndent(2)
+	if err := enc.Encode(out); err != nil {
+		fmt.Fprintf(os.Stderr, "config print: 出力に失敗しました: %v\n", err)
+		return 1
+	}
+	return 0
+}
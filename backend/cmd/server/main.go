@@ -1,26 +1,43 @@
 package main
 
 import (
+	"backend/internal/audit"
+	"backend/internal/auth"
 	"backend/internal/config"
 	"backend/internal/database"
-	"backend/internal/handler"
-	"backend/internal/handler/middleware"
+	"backend/internal/mail"
+	"backend/internal/oauth"
+	"backend/internal/service"
+	"backend/internal/storage"
 	"fmt"
 	"log"
-	"net/http"
-	"time"
+	"os"
+	_ "time/tzdata" // プロフィールのタイムゾーンの確認に使います (OS に tzdata がない環境向け)
 
-	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/joho/godotenv"
 )
 
+// debugRedactedHeaders は DebugHeadersMiddleware でも値を出力しないヘッダーです (正規化したヘッダー名)。
+var debugRedactedHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"Set-Cookie":          true,
+	"X-Csrf-Token":        true,
+}
+
+// DebugHeadersMiddleware は受信したリクエストヘッダーをログに出力します。開発環境でのみ登録します。
+// トークンや Cookie がログに残らないよう、認証に関わるヘッダーの値は伏せ字にします。
 func DebugHeadersMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		log.Println("--- DEBUG: 受信したリクエストヘッダー ---")
 		for key, values := range c.Request.Header {
 			for _, value := range values {
+				if debugRedactedHeaders[key] {
+					value = "[REDACTED]"
+				}
 				log.Printf("%s: %s\n", key, value)
 			}
 		}
@@ -36,7 +53,26 @@ func main() {
 		log.Println("警告: .env ファイルの読み込みに失敗しました。環境変数を直接使用します。")
 	}
 
-	if err := config.LoadConfig(); err != nil {
+	// サブコマンドの振り分け。引数なし、または "serve" の場合はサーバーを起動します。
+	args := os.Args[1:]
+	if len(args) > 0 {
+		switch args[0] {
+		case "config":
+			os.Exit(runConfigCommand(args[1:]))
+		case "audit":
+			os.Exit(runAuditCommand(args[1:]))
+		case "users":
+			os.Exit(runUsersCommand(args[1:]))
+		case "serve":
+			args = args[1:]
+		}
+	}
+	runServer(args)
+}
+
+// runServer は設定をロードして HTTP サーバーを起動します。
+func runServer(args []string) {
+	if err := config.LoadConfig(args); err != nil {
 		log.Fatalf("main: 設定のロードに失敗しました: %v", err)
 	}
 
@@ -53,56 +89,41 @@ func main() {
 		}
 	}()
 
-	router := gin.Default()
-	router.Use(DebugHeadersMiddleware())
-	router.Use(
-		cors.New(cors.Config{
-			AllowOrigins:     []string{"http://localhost:3000"},
-			AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-			AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
-			ExposeHeaders:    []string{"Content-Length"},
-			AllowCredentials: true,
-			MaxAge:           12 * time.Hour,
-		}))
-
-	api := router.Group("/api")
-	{
-		authRoutes := api.Group("/auth")
-		{
-			authRoutes.POST("/login", handler.HandleLogin)
-			authRoutes.POST("/register", handler.HandleCreateUser)
-		}
+	// 外部認証プロバイダーを登録します (設定されたもののみ有効)。
+	oauth.Init(config.AppConfig.OAuth)
 
-		protectedRoutes := api.Group("/")
-		// .Use() を使って、このグループ全体にミドルウェアを適用します。
-		protectedRoutes.Use(middleware.JWTMiddleware())
-		{
-			// テスト用のルート: /api/me (自分のプロフィール情報を取得)
-			protectedRoutes.GET("/me", func(c *gin.Context) {
-				// ミドルウェアによって設定されたユーザーIDを取得します。
-				userID, exists := c.Get("userID")
-				if !exists {
-					c.JSON(http.StatusInternalServerError, gin.H{"error": "ユーザーIDがコンテキストに見つかりません。"})
-					return
-				}
+	// パスワードのハッシュ方式を設定します。
+	auth.InitPasswordHasher(config.AppConfig.PasswordHash)
+
+	// メールの送信方式を設定します。
+	mail.Init(config.AppConfig.Mail)
+
+	// アバター画像などのファイルの保存先を設定します。
+	if err := storage.Init(config.AppConfig.Storage, config.AppConfig.JWTSecretKey); err != nil {
+		log.Fatalf("main: ファイルの保存先の初期化に失敗しました: %v", err)
+	}
 
-				// テストのため、ユーザーIDをそのまま返します。
-				c.JSON(http.StatusOK, gin.H{
-					"message": "あなたは正常に認証されています。",
-					"user_id": userID,
-				})
-			})
-
-			protectedRoutes.PUT("/users/me/password", handler.HandleChangePassword)
-			// userRoutes.GET("/:id", handler.HandleGetUserID)
-			// userRoutes.GET("", handler.HandleGetAllUsers)
-			// userRoutes.PUT("/:id", handler.HandleUpdateUser)
-			// userRoutes.DELETE("/:id", handler.HandleDeleteUser)
+	// 認可サーバーの署名鍵を読み込みます。
+	if config.AppConfig.AuthServer.Enabled {
+		if err := auth.InitSigningKey(config.AppConfig.AuthServer.SigningKey); err != nil {
+			log.Fatalf("main: 認可サーバーの署名鍵の読み込みに失敗しました: %v", err)
 		}
 	}
 
+	// 監査ログのチェックポイントの署名鍵を読み込み、定期的な署名を開始します。
+	if key := config.AppConfig.Audit.CheckpointKey; key != "" {
+		if err := audit.InitCheckpointKey(key); err != nil {
+			log.Fatalf("main: 監査ログのチェックポイントの署名鍵の読み込みに失敗しました: %v", err)
+		}
+		service.StartAuditCheckpoints(config.AppConfig.Audit.CheckpointInterval)
+	} else {
+		log.Println("警告: AUDIT_CHECKPOINT_KEY が設定されていないため、監査ログのチェックポイントを作成しません。")
+	}
+
+	router := newRouter()
+
 	port := config.AppConfig.ServerPort
-	fmt.Println("Starting server on :8080")
+	fmt.Printf("Starting server on %s\n", port)
 	if err := router.Run(port); err != nil {
 		log.Fatalf("main: Error staring Gin server: %v", err)
 	}
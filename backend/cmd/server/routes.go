@@ -0,0 +1,130 @@
+// backend/cmd/server/routes.go
+package main
+
+import (
+	"backend/internal/config"
+	"backend/internal/handler"
+	"backend/internal/handler/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newRouter はミドルウェアとすべてのルートを登録した Gin エンジンを返します。
+func newRouter() *gin.Engine {
+	router := gin.Default()
+	router.Use(middleware.RequestIDMiddleware())
+	if config.AppConfig.IsDevelopment() {
+		router.Use(DebugHeadersMiddleware())
+	}
+	router.Use(middleware.CORSMiddleware(config.AppConfig.CORS, config.AppConfig.IsDevelopment()))
+
+	api := router.Group("/api")
+	{
+		// 保存先が local の場合のアバター画像などの配信です。URL の署名で認可するため認証は不要です。
+		if config.AppConfig.Storage.Driver == config.StorageDriverLocal {
+			api.GET("/blobs/*key", handler.HandleGetBlob)
+		}
+
+		authRoutes := api.Group("/auth")
+		{
+			authRoutes.POST("/login", handler.HandleLogin)
+			authRoutes.GET("/registration", handler.HandleGetRegistration)
+			authRoutes.POST("/register", handler.HandleCreateUser)
+			// Cookie で送られるリフレッシュトークンを使うため CSRF 対策を適用します。
+			authRoutes.POST("/refresh", middleware.CSRFMiddleware(), handler.HandleRefresh)
+			authRoutes.POST("/logout", middleware.CSRFMiddleware(), handler.HandleLogout)
+			authRoutes.POST("/oauth/:provider/authorize", handler.HandleOAuthAuthorize)
+			authRoutes.POST("/oauth/:provider/callback", handler.HandleOAuthCallback)
+			if config.AppConfig.MagicLink.URL != "" {
+				authRoutes.POST("/magic-link", handler.HandleRequestMagicLink)
+				authRoutes.POST("/magic-link/consume", handler.HandleConsumeMagicLink)
+			}
+			if config.AppConfig.EmailChange.ConfirmURL != "" {
+				authRoutes.POST("/email-change/confirm", handler.HandleConfirmEmailChange)
+				authRoutes.POST("/email-change/revert", handler.HandleRevertEmailChange)
+			}
+			if config.AppConfig.WebAuthn.RPID != "" {
+				authRoutes.POST("/passkey/options", handler.HandlePasskeyLoginOptions)
+				authRoutes.POST("/passkey/login", handler.HandlePasskeyLogin)
+			}
+		}
+
+		protectedRoutes := api.Group("/")
+		// .Use() を使って、このグループ全体にミドルウェアを適用します。
+		// Cookie で認証された状態変更リクエストには CSRF トークンを要求します。
+		protectedRoutes.Use(middleware.JWTMiddleware(), middleware.CSRFMiddleware())
+		{
+			protectedRoutes.GET("/me", handler.HandleGetMe)
+			protectedRoutes.GET("/users/me", handler.HandleGetMe)
+			protectedRoutes.PATCH("/users/me", handler.HandleUpdateMe)
+			protectedRoutes.PUT("/users/me/avatar", handler.HandleUpdateAvatar)
+			protectedRoutes.DELETE("/users/me/avatar", handler.HandleDeleteAvatar)
+			protectedRoutes.PATCH("/users/me/username", middleware.SessionOnly(), handler.HandleChangeUsername)
+			protectedRoutes.PUT("/users/me/password", middleware.SessionOnly(), handler.HandleChangePassword)
+			if config.AppConfig.EmailChange.ConfirmURL != "" {
+				protectedRoutes.POST("/users/me/email", middleware.SessionOnly(), handler.HandleRequestEmailChange)
+			}
+			protectedRoutes.GET("/users/me/activity", handler.HandleListMyActivity)
+			protectedRoutes.GET("/users/me/sessions", handler.HandleListSessions)
+			// 他の端末のログアウトは本人のみが行えます (代理ログイン中の管理者が本人のセッションを終了させないようにします)。
+			protectedRoutes.DELETE("/users/me/sessions/:id", middleware.SessionOnly(), handler.HandleRevokeSession)
+			protectedRoutes.GET("/users/me/identities", handler.HandleListIdentities)
+			protectedRoutes.POST("/users/me/identities/:provider", middleware.SessionOnly(), handler.HandleLinkIdentityStart)
+			protectedRoutes.POST("/users/me/identities/:provider/callback", middleware.SessionOnly(), handler.HandleLinkIdentityCallback)
+			protectedRoutes.DELETE("/users/me/identities/:provider", middleware.SessionOnly(), handler.HandleUnlinkIdentity)
+			protectedRoutes.GET("/users/me/tokens", middleware.SessionOnly(), handler.HandleListAPITokens)
+			protectedRoutes.POST("/users/me/tokens", middleware.SessionOnly(), handler.HandleCreateAPIToken)
+			protectedRoutes.DELETE("/users/me/tokens/:id", middleware.SessionOnly(), handler.HandleRevokeAPIToken)
+			if config.AppConfig.WebAuthn.RPID != "" {
+				protectedRoutes.GET("/users/me/passkeys", handler.HandleListPasskeys)
+				protectedRoutes.POST("/users/me/passkeys/options", middleware.SessionOnly(), handler.HandlePasskeyRegistrationOptions)
+				protectedRoutes.POST("/users/me/passkeys", middleware.SessionOnly(), handler.HandleRegisterPasskey)
+				protectedRoutes.PATCH("/users/me/passkeys/:id", handler.HandleRenamePasskey)
+				protectedRoutes.DELETE("/users/me/passkeys/:id", middleware.SessionOnly(), handler.HandleDeletePasskey)
+			}
+			protectedRoutes.GET("/users/by-username/:username", handler.HandleGetUserByUsername)
+			protectedRoutes.POST("/oauth/clients", middleware.SessionOnly(), handler.HandleRegisterClient)
+			protectedRoutes.GET("/oauth/clients", handler.HandleListClients)
+			protectedRoutes.DELETE("/oauth/clients/:client_id", middleware.SessionOnly(), handler.HandleDeleteClient)
+			// userRoutes.GET("/:id", handler.HandleGetUserID)
+			// userRoutes.DELETE("/:id", handler.HandleDeleteUser)
+		}
+
+		// 管理者用のAPIです。
+		adminRoutes := api.Group("/admin")
+		adminRoutes.Use(middleware.JWTMiddleware(), middleware.CSRFMiddleware(), middleware.RequireAdmin())
+		{
+			adminRoutes.GET("/audit-logs", handler.HandleListAuditLogs)
+			adminRoutes.GET("/users", handler.HandleListUsers)
+			adminRoutes.GET("/users/export", handler.HandleExportUsers)
+			adminRoutes.POST("/users/import", middleware.SessionOnly(), handler.HandleImportUsers)
+			adminRoutes.POST("/users/:id/suspend", middleware.SessionOnly(), handler.HandleSuspendUser)
+			adminRoutes.POST("/users/:id/unsuspend", middleware.SessionOnly(), handler.HandleUnsuspendUser)
+			adminRoutes.POST("/users/:id/require-password-change", middleware.SessionOnly(), handler.HandleRequirePasswordChange)
+			adminRoutes.POST("/users/:id/impersonate", middleware.SessionOnly(), handler.HandleImpersonateUser)
+			adminRoutes.GET("/invite-codes", handler.HandleListInviteCodes)
+			adminRoutes.POST("/invite-codes", middleware.SessionOnly(), handler.HandleCreateInviteCode)
+			adminRoutes.DELETE("/invite-codes/:id", middleware.SessionOnly(), handler.HandleRevokeInviteCode)
+		}
+	}
+
+	// YUTAKA を OAuth2 認可サーバー / OIDC プロバイダーとして公開します。
+	if config.AppConfig.AuthServer.Enabled {
+		router.GET("/.well-known/openid-configuration", handler.HandleOpenIDConfiguration)
+		oauthRoutes := router.Group("/oauth")
+		{
+			oauthRoutes.GET("/jwks", handler.HandleJWKS)
+			oauthRoutes.POST("/token", handler.HandleOAuthToken)
+			oauthRoutes.GET("/userinfo", handler.HandleOAuthUserInfo)
+			oauthRoutes.POST("/userinfo", handler.HandleOAuthUserInfo)
+
+			// 同意画面用のAPIはログイン中のユーザーのみ使用できます。
+			consentRoutes := oauthRoutes.Group("/authorize")
+			consentRoutes.Use(middleware.JWTMiddleware(), middleware.CSRFMiddleware())
+			consentRoutes.GET("", handler.HandleAuthorizeInfo)
+			consentRoutes.POST("", middleware.SessionOnly(), handler.HandleAuthorizeDecision)
+		}
+	}
+
+	return router
+}
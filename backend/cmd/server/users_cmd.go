@@ -0,0 +1,251 @@
+// backend/cmd/server/users_cmd.go
+package main
+
+import (
+	"backend/internal/auth"
+	"backend/internal/config"
+	"backend/internal/database"
+	"backend/internal/mail"
+	"backend/internal/repository"
+	"backend/internal/service"
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const usersUsage = `使い方:
+  server users canonicalize [--config path]
+  server users import --file path [--format csv|ndjson] [--dry-run] [--send-invitations] [--config path]
+  server users export [--output path] [--format csv|ndjson] [--fields a,b,...] [--status active|deleted|suspended|all] [--config path]`
+
+// runUsersCommand は "server users <subcommand>" を処理し、終了コードを返します。
+//
+//	server users canonicalize [--config path] [その他の設定フラグ]
+//	server users import --file path [--format csv|ndjson] [--dry-run] [--send-invitations] [その他の設定フラグ]
+//	server users export [--output path] [--format csv|ndjson] [--fields a,b,...] [--status ...] [その他の設定フラグ]
+//
+// canonicalize は正規形が未設定の既存ユーザーに、ユーザー名の正規形とスケルトンを保存します。
+// 何度実行しても問題ありません。他のユーザーと重なるため埋められなかったユーザーがいる場合は 1 を返します。
+//
+// import は CSV または NDJSON のファイルからユーザーを一括登録し、行ごとの結果を NDJSON で標準出力に書き出します。
+// 登録できなかった行がある場合は 1 を返します。
+//
+// export はユーザーを CSV または NDJSON で書き出します。--output を省略した場合は標準出力に書き出します。
+func runUsersCommand(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, usersUsage)
+		return 2
+	}
+	switch args[0] {
+	case "canonicalize":
+		return runUsersCanonicalize(args[1:])
+	case "import":
+		return runUsersImport(args[1:])
+	case "export":
+		return runUsersExport(args[1:])
+	}
+	fmt.Fprintln(os.Stderr, usersUsage)
+	return 2
+}
+
+// runUsersCanonicalize は "server users canonicalize" を処理します。
+func runUsersCanonicalize(args []string) int {
+	fs := flag.NewFlagSet("users canonicalize", flag.ContinueOnError)
+	cfg, err := config.Load(fs, args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "users canonicalize: %v\n", err)
+		return 1
+	}
+
+	if err := database.InitDB(cfg.DatabaseDSN); err != nil {
+		fmt.Fprintf(os.Stderr, "users canonicalize: %v\n", err)
+		return 1
+	}
+	defer database.DB.Close()
+
+	report, err := service.BackfillCanonicalUsernames()
+	if report != nil {
+		fmt.Printf("正規形を保存したユーザー: %d / 変更履歴: %d\n", report.Updated, report.HistoryUpdated)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "users canonicalize: %v\n", err)
+		return 1
+	}
+
+	if len(report.Conflicts) > 0 {
+		fmt.Printf("NG: 他のユーザーと重なるため保存できなかったユーザー: %d\n", len(report.Conflicts))
+		for _, u := range report.Conflicts {
+			fmt.Printf("  %d\t%s\n", u.ID, u.Username)
+		}
+		return 1
+	}
+	fmt.Println("OK")
+	return 0
+}
+
+// runUsersImport は "server users import" を処理します。
+// 行ごとの結果は標準出力に、集計は標準エラー出力に書き出します。
+func runUsersImport(args []string) int {
+	fs := flag.NewFlagSet("users import", flag.ContinueOnError)
+	file := fs.String("file", "", "登録するユーザーのファイル。\"-\" の場合は標準入力から読み込みます")
+	format := fs.String("format", "", "ファイル形式 (csv / ndjson)。省略した場合は拡張子から判断します")
+	dryRun := fs.Bool("dry-run", false, "確認のみ行い、ユーザーを登録しません")
+	sendInvitations := fs.Bool("send-invitations", false, "password のない行のユーザーに招待メールを送信します")
+
+	cfg, err := config.Load(fs, args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "users import: %v\n", err)
+		return 1
+	}
+	if *file == "" {
+		fmt.Fprintln(os.Stderr, usersUsage)
+		return 2
+	}
+	if *format == "" {
+		*format = userFileFormatFromPath(*file)
+	}
+
+	var in io.Reader = os.Stdin
+	if *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "users import: %v\n", err)
+			return 1
+		}
+		defer f.Close()
+		in = f
+	}
+
+	if err := initUsersCommand(cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "users import: %v\n", err)
+		return 1
+	}
+	defer database.DB.Close()
+
+	report, err := service.ImportUsers(in, service.UserImportOptions{
+		Format:             *format,
+		DryRun:             *dryRun,
+		SendInvitations:    *sendInvitations,
+		WaitForInvitations: true,
+	}, 0, service.ClientInfo{UserAgent: "server users import"})
+	if report != nil {
+		out := bufio.NewWriter(os.Stdout)
+		enc := json.NewEncoder(out)
+		for _, row := range report.Rows {
+			enc.Encode(row)
+		}
+		out.Flush()
+		verb := "登録"
+		if report.DryRun {
+			verb = "登録可能"
+		}
+		fmt.Fprintf(os.Stderr, "行数: %d / %s: %d (招待: %d) / エラー: %d\n", report.Total, verb, report.Valid, report.Invited, report.Failed)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "users import: %v\n", err)
+		return 1
+	}
+	if report.Failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// runUsersExport は "server users export" を処理します。
+func runUsersExport(args []string) int {
+	fs := flag.NewFlagSet("users export", flag.ContinueOnError)
+	output := fs.String("output", "-", "書き出すファイル。\"-\" の場合は標準出力に書き出します")
+	format := fs.String("format", "", "ファイル形式 (csv / ndjson)。省略した場合は拡張子から判断し、判断できない場合は csv です")
+	fields := fs.String("fields", "", "書き出す項目 (カンマ区切り)。省略した場合は "+strings.Join(service.DefaultUserExportFields, ","))
+	status := fs.String("status", repository.UserStatusActive, "対象のユーザー (active / deleted / suspended / all)")
+
+	cfg, err := config.Load(fs, args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "users export: %v\n", err)
+		return 1
+	}
+	if *format == "" {
+		if *format = userFileFormatFromPath(*output); *format == "" {
+			*format = service.UserFileFormatCSV
+		}
+	}
+	var fieldList []string
+	if *fields != "" {
+		for _, f := range strings.Split(*fields, ",") {
+			fieldList = append(fieldList, strings.TrimSpace(f))
+		}
+	}
+
+	export, err := service.PrepareUserExport(service.UserExportOptions{
+		Format: *format,
+		Fields: fieldList,
+		Filter: repository.UserFilter{Status: *status},
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "users export: %v\n", err)
+		return 2
+	}
+
+	if err := initUsersCommand(cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "users export: %v\n", err)
+		return 1
+	}
+	defer database.DB.Close()
+
+	var out io.Writer = os.Stdout
+	if *output != "-" {
+		f, err := os.Create(*output)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "users export: %v\n", err)
+			return 1
+		}
+		defer f.Close()
+		out = f
+	}
+
+	w := bufio.NewWriter(out)
+	count, err := export.Stream(w)
+	if flushErr := w.Flush(); err == nil {
+		err = flushErr
+	}
+	service.RecordAudit(service.AuditEntry{Event: service.AuditEventUserExport, Outcome: auditOutcome(err), Client: service.ClientInfo{UserAgent: "server users export"},
+		Details: map[string]any{"format": *format, "fields": fieldList, "status": *status, "count": count}})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "users export: %v\n", err)
+		return 1
+	}
+	fmt.Fprintf(os.Stderr, "書き出したユーザー: %d\n", count)
+	return 0
+}
+
+// initUsersCommand は一括登録・エクスポートに必要な設定を反映し、データベースに接続します。
+// 登録ではパスワードのハッシュ化とメールの送信を、サーバーと同じ設定で行います。
+func initUsersCommand(cfg *config.Config) error {
+	config.AppConfig = *cfg
+	auth.InitPasswordHasher(cfg.PasswordHash)
+	mail.Init(cfg.Mail)
+	return database.InitDB(cfg.DatabaseDSN)
+}
+
+// userFileFormatFromPath はファイルの拡張子からファイル形式を判断します。判断できない場合は空文字列を返します。
+func userFileFormatFromPath(path string) string {
+	switch {
+	case strings.HasSuffix(path, ".csv"):
+		return service.UserFileFormatCSV
+	case strings.HasSuffix(path, ".ndjson"), strings.HasSuffix(path, ".jsonl"):
+		return service.UserFileFormatNDJSON
+	}
+	return ""
+}
+
+// auditOutcome はエラーの有無から監査ログの結果を返します。
+func auditOutcome(err error) string {
+	if err != nil {
+		return service.AuditOutcomeFailure
+	}
+	return service.AuditOutcomeSuccess
+}
@@ -0,0 +1,49 @@
+// backend/internal/audit/chain.go
+package audit
+
+import (
+	"backend/internal/domain"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+// chainVersion はハッシュの入力形式のバージョンです。形式を変える場合は値を変えます。
+const chainVersion = "yutaka-audit-v1"
+
+// Timestamp はハッシュと保存に使う発生日時に丸めます。
+// データベースの DATETIME(6) と同じマイクロ秒精度にしておかないと、読み戻したときにハッシュが一致しません。
+func Timestamp(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Microsecond)
+}
+
+// EntryHash は監査ログのエントリーのハッシュ (SHA-256 の16進数) を計算します。
+// 直前のエントリーのハッシュ (l.PrevHash) を含めるため、途中のエントリーを書き換えたり
+// 削除したりすると、それ以降のチェーンがつながらなくなります。ID は含めません。
+func EntryHash(l *domain.AuditLog) string {
+	var actor, target any
+	if l.ActorUserID.Valid {
+		actor = l.ActorUserID.Int64
+	}
+	if l.TargetUserID.Valid {
+		target = l.TargetUserID.Int64
+	}
+	// 配列にすることでフィールドの順序を固定します。
+	fields := []any{
+		chainVersion,
+		l.PrevHash,
+		Timestamp(l.OccurredAt).Format(time.RFC3339Nano),
+		l.Event,
+		l.Outcome,
+		actor,
+		target,
+		l.IPAddress,
+		l.UserAgent,
+		l.RequestID,
+		l.Details,
+	}
+	data, _ := json.Marshal(fields) // 文字列と数値のみのため失敗しません
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
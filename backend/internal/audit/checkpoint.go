@@ -0,0 +1,113 @@
+// backend/internal/audit/checkpoint.go
+package audit
+
+import (
+	"backend/internal/domain"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// checkpointKey はチェックポイントに署名する Ed25519 鍵です。InitCheckpointKey で設定されます。
+var checkpointKey ed25519.PrivateKey
+
+// InitCheckpointKey は PEM 形式 (PKCS#8) の Ed25519 秘密鍵を読み込みます。
+// 例: openssl genpkey -algorithm ed25519
+func InitCheckpointKey(pemData string) error {
+	block, _ := pem.Decode([]byte(pemData))
+	if block == nil || block.Type != "PRIVATE KEY" {
+		return errors.New("チェックポイントの署名鍵を PEM (PRIVATE KEY) として解釈できません")
+	}
+	k, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("チェックポイントの署名鍵の解析に失敗しました: %w", err)
+	}
+	key, ok := k.(ed25519.PrivateKey)
+	if !ok {
+		return errors.New("チェックポイントの署名鍵は Ed25519 鍵である必要があります")
+	}
+	checkpointKey = key
+	return nil
+}
+
+// CheckpointEnabled はチェックポイントの署名鍵が設定されているかどうかを返します。
+func CheckpointEnabled() bool {
+	return checkpointKey != nil
+}
+
+// ParsePublicKey は検証に使う公開鍵を読み込みます。
+// 公開鍵 (PUBLIC KEY) のほか、秘密鍵 (PRIVATE KEY) からも公開鍵を取り出せます。
+func ParsePublicKey(pemData []byte) (ed25519.PublicKey, error) {
+	block, _ := pem.Decode(pemData)
+	if block == nil {
+		return nil, errors.New("鍵を PEM として解釈できません")
+	}
+	var k any
+	var err error
+	switch block.Type {
+	case "PUBLIC KEY":
+		k, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "PRIVATE KEY":
+		k, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("未対応の PEM の種類です: %s", block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("鍵の解析に失敗しました: %w", err)
+	}
+	switch key := k.(type) {
+	case ed25519.PublicKey:
+		return key, nil
+	case ed25519.PrivateKey:
+		return key.Public().(ed25519.PublicKey), nil
+	}
+	return nil, errors.New("鍵は Ed25519 鍵である必要があります")
+}
+
+// KeyID は公開鍵の識別子 (SHA-256 の先頭16バイトの base64url) を返します。
+func KeyID(pub ed25519.PublicKey) string {
+	sum := sha256.Sum256(pub)
+	return base64.RawURLEncoding.EncodeToString(sum[:16])
+}
+
+// SignCheckpoint はチェーンの末尾 (lastLogID, lastHash) に署名したチェックポイントを返します。
+func SignCheckpoint(lastLogID int64, lastHash string, signedAt time.Time) (*domain.AuditCheckpoint, error) {
+	if checkpointKey == nil {
+		return nil, errors.New("チェックポイントの署名鍵が設定されていません")
+	}
+	cp := &domain.AuditCheckpoint{
+		LastLogID: lastLogID,
+		LastHash:  lastHash,
+		SignedAt:  Timestamp(signedAt),
+		KeyID:     KeyID(checkpointKey.Public().(ed25519.PublicKey)),
+	}
+	cp.Signature = base64.RawURLEncoding.EncodeToString(ed25519.Sign(checkpointKey, checkpointMessage(cp)))
+	return cp, nil
+}
+
+// VerifyCheckpoint はチェックポイントの署名を公開鍵で検証します。
+func VerifyCheckpoint(pub ed25519.PublicKey, cp *domain.AuditCheckpoint) bool {
+	if cp.KeyID != KeyID(pub) {
+		return false
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(cp.Signature)
+	if err != nil {
+		return false
+	}
+	return ed25519.Verify(pub, checkpointMessage(cp), sig)
+}
+
+// checkpointMessage は署名の対象となるバイト列です。
+func checkpointMessage(cp *domain.AuditCheckpoint) []byte {
+	return []byte(chainVersion + "/checkpoint\n" +
+		strconv.FormatInt(cp.LastLogID, 10) + "\n" +
+		cp.LastHash + "\n" +
+		Timestamp(cp.SignedAt).Format(time.RFC3339Nano) + "\n" +
+		cp.KeyID)
+}
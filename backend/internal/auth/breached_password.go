@@ -0,0 +1,111 @@
+// backend/internal/auth/breached_password.go
+package auth
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// breachedRangePrefixLength は k-anonymity で照会に使うハッシュの先頭の文字数です。
+// Have I Been Pwned の range API と同じく、パスワードの SHA-1 の先頭5文字の範囲だけを読み込み、
+// 残りの部分はその範囲の中で照合します。
+const breachedRangePrefixLength = 5
+
+// IsBreachedPassword は漏洩済みパスワードの一覧にパスワードが含まれているかどうかを返します。
+// 一覧は "<SHA-1 の16進数>:<出現回数>" の行をハッシュ順に並べたファイルで、二分探索で範囲を探します。
+func IsBreachedPassword(listFile string, password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	suffixes, err := breachedRange(listFile, hash[:breachedRangePrefixLength])
+	if err != nil {
+		return false, err
+	}
+	for _, s := range suffixes {
+		if s == hash[breachedRangePrefixLength:] {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// breachedRange は指定した先頭5文字で始まるハッシュの残りの部分の一覧を返します。
+func breachedRange(listFile string, prefix string) ([]string, error) {
+	f, err := os.Open(listFile)
+	if err != nil {
+		return nil, fmt.Errorf("漏洩済みパスワードの一覧を開けません: %w", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("漏洩済みパスワードの一覧を開けません: %w", err)
+	}
+
+	// prefix 以上のハッシュで始まる最初の行の位置を二分探索します。
+	lo, hi := int64(0), info.Size()
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		key, _, err := hashAfter(f, mid)
+		if err != nil {
+			return nil, err
+		}
+		if key == "" || key >= prefix {
+			hi = mid
+		} else {
+			lo = mid + 1
+		}
+	}
+
+	_, start, err := hashAfter(f, lo)
+	if err != nil {
+		return nil, err
+	}
+	var suffixes []string
+	scanner := bufio.NewScanner(io.NewSectionReader(f, start, info.Size()-start))
+	for scanner.Scan() {
+		key := lineHash(scanner.Text())
+		if !strings.HasPrefix(key, prefix) {
+			break
+		}
+		suffixes = append(suffixes, key[len(prefix):])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("漏洩済みパスワードの一覧を読み込めません: %w", err)
+	}
+	return suffixes, nil
+}
+
+// hashAfter は offset 以降で最初に始まる行のハッシュとその行の開始位置を返します。
+// 該当する行がない場合は空文字列を返します。
+func hashAfter(f *os.File, offset int64) (string, int64, error) {
+	r := bufio.NewReader(io.NewSectionReader(f, max(offset-1, 0), 1<<20))
+	start := offset
+	if offset > 0 {
+		// offset の直前の文字から改行までを読み飛ばし、次の行の先頭に合わせます。
+		skipped, err := r.ReadString('\n')
+		if err == io.EOF {
+			return "", offset, nil
+		}
+		if err != nil {
+			return "", 0, fmt.Errorf("漏洩済みパスワードの一覧を読み込めません: %w", err)
+		}
+		start = offset - 1 + int64(len(skipped))
+	}
+	line, err := r.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", 0, fmt.Errorf("漏洩済みパスワードの一覧を読み込めません: %w", err)
+	}
+	return lineHash(line), start, nil
+}
+
+// lineHash は行からハッシュの部分を大文字で取り出します。
+func lineHash(line string) string {
+	hash, _, _ := strings.Cut(strings.TrimSpace(line), ":")
+	return strings.ToUpper(hash)
+}
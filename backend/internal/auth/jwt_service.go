@@ -5,6 +5,7 @@ import (
 	"backend/internal/config" // 設定情報を取得するため
 	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -12,28 +13,49 @@ import (
 
 // Claims はJWTに含まれるカスタムクレームを定義します。
 type Claims struct {
-	UserID   int64  `json:"user_id"`
-	Username string `json:"username"`
+	UserID    int64  `json:"user_id"`
+	Username  string `json:"username"`
+	SessionID int64  `json:"sid,omitempty"` // ログインごとのセッションID (失効確認に使用)
+	// Actor は管理者による代理ログインの場合に、実際に操作している管理者を表します (RFC 8693 の act クレーム)。
+	// 本人のログインで発行されたトークンには含まれません。
+	Actor *ActorClaim `json:"act,omitempty"`
 	jwt.RegisteredClaims
 }
 
-// GenerateToken はユーザーIDとユーザー名を受け取り、JWTトークン文字列を生成します。
-func GenerateToken(userID int64, username string) (string, error) {
-	// トークンの有効期限を設定します。例として24時間とします。
-	expirationTime := time.Now().Add(24 * time.Hour)
+// ActorClaim は代理ログインした管理者を表す act クレームです。
+type ActorClaim struct {
+	Subject string `json:"sub"` // 管理者のユーザーID (文字列)
+	UserID  int64  `json:"user_id"`
+}
+
+// GenerateToken はユーザーID・ユーザー名・セッションIDを受け取り、JWTトークン文字列を生成します。
+func GenerateToken(userID int64, username string, sessionID int64) (string, error) {
+	// トークンの有効期限を設定します。既定値は24時間です。
+	expirationTime := time.Now().Add(config.AppConfig.Session.AccessTokenTTL)
+	return signToken(&Claims{UserID: userID, Username: username, SessionID: sessionID}, expirationTime)
+}
 
-	// クレームを作成します。
+// GenerateImpersonationToken は管理者 actorUserID がユーザーとして操作するための、act クレーム付きの JWT を生成します。
+// 有効期限は代理ログインのセッションの期限 expiresAt です。
+func GenerateImpersonationToken(userID int64, username string, sessionID int64, actorUserID int64, expiresAt time.Time) (string, error) {
 	claims := &Claims{
-		UserID:   userID,
-		Username: username,
-		RegisteredClaims: jwt.RegisteredClaims{
-			// 有効期限 (ExpiresAt) はUnixタイムスタンプで指定します。
-			ExpiresAt: jwt.NewNumericDate(expirationTime),
-			// 発行日時 (IssuedAt)
-			IssuedAt: jwt.NewNumericDate(time.Now()),
-			// 発行者 (Issuer) - オプション
-			Issuer: "YUTAKA",
-		},
+		UserID:    userID,
+		Username:  username,
+		SessionID: sessionID,
+		Actor:     &ActorClaim{Subject: strconv.FormatInt(actorUserID, 10), UserID: actorUserID},
+	}
+	return signToken(claims, expiresAt)
+}
+
+// signToken は有効期限などの登録済みクレームを設定し、トークンに署名します。
+func signToken(claims *Claims, expirationTime time.Time) (string, error) {
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		// 有効期限 (ExpiresAt) はUnixタイムスタンプで指定します。
+		ExpiresAt: jwt.NewNumericDate(expirationTime),
+		// 発行日時 (IssuedAt)
+		IssuedAt: jwt.NewNumericDate(time.Now()),
+		// 発行者 (Issuer) - オプション
+		Issuer: "YUTAKA",
 	}
 
 	// ヘッダーとペイロード（クレーム）を含むトークンオブジェクトを作成します。
@@ -76,6 +98,6 @@ func ValidateToken(tokenString string) (*Claims, error) {
 	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
 		return claims, nil
 	}
-	
+
 	return nil, errors.New("無効なトークンです")
-}
\ No newline at end of file
+}
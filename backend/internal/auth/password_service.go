@@ -2,21 +2,106 @@
 package auth
 
 import (
+	"backend/internal/config"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
-	"golang.org/x/crypto/bcrypt"
+	"strings"
+	"sync"
 )
 
-// HashPassword は平文のパスワードを受け取り、bcryptハッシュを生成します。
+// Hasher はパスワードのハッシュ方式です。
+// ハッシュは方式とパラメーターを含む PHC 形式 ("$<方式>$...") の文字列で保存されます。
+type Hasher interface {
+	// Hash はパスワードをハッシュ化します。
+	Hash(password string) (string, error)
+	// Verify はパスワードがハッシュと一致するかどうかを返します。
+	Verify(password, encoded string) (bool, error)
+	// NeedsRehash はハッシュがこの方式・パラメーターで作成されていない場合に true を返します。
+	NeedsRehash(encoded string) bool
+}
+
+// passwordHasher は新しく保存するパスワードに使うハッシュ方式です。InitPasswordHasher で設定されます。
+var passwordHasher Hasher = &bcryptHasher{cost: 10}
+
+// dummyHash は存在しないユーザーのログインなどで比較に使う、現在の方式・パラメーターのハッシュです。
+// 最初の比較だけ作成の分だけ遅くならないよう、InitPasswordHasher で作成しておきます。
+var (
+	dummyHash     string
+	dummyHashOnce sync.Once
+)
+
+// InitPasswordHasher は設定からパスワードのハッシュ方式を選択します。
+func InitPasswordHasher(cfg config.PasswordHashConfig) {
+	switch cfg.Algorithm {
+	case config.PasswordHashArgon2id:
+		passwordHasher = &argon2idHasher{
+			memory:      uint32(cfg.Argon2Memory),
+			iterations:  uint32(cfg.Argon2Iterations),
+			parallelism: uint8(cfg.Argon2Parallelism),
+		}
+	default:
+		passwordHasher = &bcryptHasher{cost: cfg.BcryptCost}
+	}
+	dummyHash, dummyHashOnce = "", sync.Once{}
+	dummyHashOnce.Do(initDummyHash)
+}
+
+// HashPassword は平文のパスワードを受け取り、設定された方式でハッシュを生成します。
 func HashPassword(password string) (string, error) {
-	hashedBytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	hashed, err := passwordHasher.Hash(password)
 	if err != nil {
 		return "", fmt.Errorf("パスワードのハッシュ化に失敗しました: %w", err)
 	}
-	return string(hashedBytes), nil
+	return hashed, nil
 }
 
 // CheckPasswordHash は平文のパスワードとハッシュ化されたパスワードを比較します。
+// ハッシュの方式は設定に関わらず、ハッシュの接頭辞から判断します。
 func CheckPasswordHash(password, hash string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
-	return err == nil // エラーがなければパスワードは一致 (如果没有错误则密码一致)
-}
\ No newline at end of file
+	hasher := hasherFor(hash)
+	if hasher == nil {
+		return false
+	}
+	ok, err := hasher.Verify(password, hash)
+	return err == nil && ok // エラーがなければパスワードは一致 (如果没有错误则密码一致)
+}
+
+// CheckDummyPasswordHash は比較するハッシュがない場合 (ユーザーが存在しない、パスワードが未設定など) に、
+// 通常のログインと同じ時間がかかるよう現在の方式のダミーのハッシュと比較します。結果は常に不一致です。
+// 応答時間からユーザーの有無を推測されないようにするために使用します。
+func CheckDummyPasswordHash(password string) {
+	dummyHashOnce.Do(initDummyHash)
+	if dummyHash != "" {
+		CheckPasswordHash(password, dummyHash)
+	}
+}
+
+// initDummyHash は推測できないランダムな値から dummyHash を作成します。
+func initDummyHash() {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return
+	}
+	if h, err := passwordHasher.Hash(hex.EncodeToString(secret)); err == nil {
+		dummyHash = h
+	}
+}
+
+// NeedsRehash は保存済みのハッシュが現在の設定と異なる方式・パラメーターで作成されたかどうかを返します。
+// true の場合は、ログインに成功したときに平文のパスワードから再ハッシュします。
+func NeedsRehash(hash string) bool {
+	return passwordHasher.NeedsRehash(hash)
+}
+
+// hasherFor はハッシュの接頭辞から検証に使う方式を返します。
+// 検証ではパラメーターをハッシュから読み取るため、方式ごとの既定のインスタンスを使います。
+func hasherFor(hash string) Hasher {
+	switch {
+	case strings.HasPrefix(hash, argon2idPrefix):
+		return &argon2idHasher{}
+	case strings.HasPrefix(hash, "$2a$"), strings.HasPrefix(hash, "$2b$"), strings.HasPrefix(hash, "$2y$"):
+		return &bcryptHasher{}
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+// backend/internal/auth/signing_key.go
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// accessTokenType は OAuth2 アクセストークンの JWT ヘッダー typ です (RFC 9068)。
+// ID トークンがアクセストークンとして使われることを防ぎます。
+const accessTokenType = "at+jwt"
+
+// signingKey は認可サーバーが ID トークンとアクセストークンに署名する RSA 鍵です。
+// 利用者のセッション用 JWT (HS256) とは別の鍵で、公開鍵は JWKS として公開されます。
+var (
+	signingKey   *rsa.PrivateKey
+	signingKeyID string
+)
+
+// IDTokenClaims は OIDC の ID トークンのクレームです。
+// ログイン用の Claims に OIDC の標準クレームを加えたものです。
+type IDTokenClaims struct {
+	Claims
+	Nonce             string           `json:"nonce,omitempty"`
+	AuthTime          *jwt.NumericDate `json:"auth_time,omitempty"`
+	PreferredUsername string           `json:"preferred_username,omitempty"`
+	Email             string           `json:"email,omitempty"`
+}
+
+// AccessTokenClaims は認可サーバーが発行するアクセストークンのクレームです。
+// client_credentials の場合は UserID が 0 で、Subject がクライアントIDになります。
+type AccessTokenClaims struct {
+	Claims
+	ClientID string `json:"client_id"`
+	Scope    string `json:"scope,omitempty"`
+}
+
+// InitSigningKey は PEM 形式の RSA 秘密鍵を読み込みます。
+// pemData が空の場合は一時的な鍵を生成します (再起動するとそれまでのトークンは検証できなくなります)。
+func InitSigningKey(pemData string) error {
+	if pemData == "" {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			return fmt.Errorf("署名鍵の生成に失敗しました: %w", err)
+		}
+		log.Println("警告: 認可サーバーの署名鍵が設定されていないため、一時的な鍵を生成しました。")
+		setSigningKey(key)
+		return nil
+	}
+
+	block, _ := pem.Decode([]byte(pemData))
+	if block == nil {
+		return errors.New("署名鍵を PEM として解釈できません")
+	}
+
+	var key *rsa.PrivateKey
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		k, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return fmt.Errorf("署名鍵の解析に失敗しました: %w", err)
+		}
+		key = k
+	case "PRIVATE KEY":
+		k, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return fmt.Errorf("署名鍵の解析に失敗しました: %w", err)
+		}
+		rsaKey, ok := k.(*rsa.PrivateKey)
+		if !ok {
+			return errors.New("署名鍵は RSA 鍵である必要があります")
+		}
+		key = rsaKey
+	default:
+		return fmt.Errorf("対応していない PEM の種類です: %s", block.Type)
+	}
+	if key.N.BitLen() < 2048 {
+		return errors.New("署名鍵は 2048 ビット以上である必要があります")
+	}
+
+	setSigningKey(key)
+	return nil
+}
+
+// setSigningKey は鍵と、公開鍵から求めた鍵ID (kid) を設定します。
+func setSigningKey(key *rsa.PrivateKey) {
+	der, _ := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	sum := sha256.Sum256(der)
+	signingKey = key
+	signingKeyID = base64.RawURLEncoding.EncodeToString(sum[:16])
+}
+
+// SigningKeyJWKS は署名鍵の公開鍵を JWKS 形式で返します。
+func SigningKeyJWKS() map[string]any {
+	if signingKey == nil {
+		return map[string]any{"keys": []any{}}
+	}
+	pub := signingKey.PublicKey
+	return map[string]any{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"use": "sig",
+			"alg": "RS256",
+			"kid": signingKeyID,
+			"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}},
+	}
+}
+
+// GenerateIDToken は ID トークンに RS256 で署名します。
+func GenerateIDToken(claims *IDTokenClaims) (string, error) {
+	return signRS256(claims, "JWT")
+}
+
+// GenerateAccessToken は認可サーバーのアクセストークンに RS256 で署名します。
+func GenerateAccessToken(claims *AccessTokenClaims) (string, error) {
+	return signRS256(claims, accessTokenType)
+}
+
+// ValidateAccessToken は認可サーバーが発行したアクセストークンを検証します。
+func ValidateAccessToken(tokenString string, issuer string) (*AccessTokenClaims, error) {
+	if signingKey == nil {
+		return nil, errors.New("署名鍵が初期化されていません")
+	}
+
+	claims := &AccessTokenClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if typ, _ := token.Header["typ"].(string); typ != accessTokenType {
+			return nil, errors.New("アクセストークンではありません")
+		}
+		return &signingKey.PublicKey, nil
+	}, jwt.WithValidMethods([]string{"RS256"}), jwt.WithIssuer(issuer), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, fmt.Errorf("無効なトークンです: %w", err)
+	}
+	if !token.Valid {
+		return nil, errors.New("無効なトークンです")
+	}
+	return claims, nil
+}
+
+// signRS256 はクレームに署名鍵で署名します。
+func signRS256(claims jwt.Claims, typ string) (string, error) {
+	if signingKey == nil {
+		return "", errors.New("署名鍵が初期化されていません")
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = signingKeyID
+	token.Header["typ"] = typ
+	return token.SignedString(signingKey)
+}
+
+// NewRegisteredClaims は発行者・対象者・主体と有効期間から標準クレームを作成します。
+func NewRegisteredClaims(issuer, subject, audience string, ttl time.Duration) jwt.RegisteredClaims {
+	now := time.Now()
+	return jwt.RegisteredClaims{
+		Issuer:    issuer,
+		Subject:   subject,
+		Audience:  jwt.ClaimStrings{audience},
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+	}
+}
@@ -0,0 +1,27 @@
+// backend/internal/auth/token_service.go
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+)
+
+// GenerateOpaqueToken は推測不可能なランダムトークンを生成します。
+// リフレッシュトークンや CSRF トークンなど、JWT ではないトークンに使用します。
+func GenerateOpaqueToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("トークンの生成に失敗しました: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashToken はトークンを保存用に SHA-256 でハッシュ化します。
+// データベースにはトークンそのものではなく、このハッシュのみを保存します。
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -2,33 +2,423 @@
 package config
 
 import (
-	"errors"
+	"flag"
+	"fmt"
 	"log"
-	"os"
+	"time"
+)
+
+// 実行環境の名前です。
+const (
+	EnvDevelopment = "development"
+	EnvStaging     = "staging"
+	EnvProduction  = "production"
 )
 
 // Config はアプリケーションの設定を保持します。
+//
+// 各フィールドのタグは設定の読み込み元を表します。
+//   - yaml: 設定ファイルのキー (TOML でも同じキーを使用します)
+//   - env: 環境変数名 (<NAME>_FILE でファイルから読み込むこともできます)。
+//     入れ子の構造体に付けた場合は、その中のフィールドの環境変数名の接頭辞になります
+//   - flag: コマンドラインフラグ名
+//   - secret: "true" の場合は値全体、"dsn" の場合は DSN のパスワードが redacted 出力で伏せ字になります
 type Config struct {
-	ServerPort   string // ":8080"
-	DatabaseDSN  string // データベース接続文字列
-	JWTSecretKey string // JWT署名用の秘密鍵
+	Environment  string `yaml:"environment" env:"APP_ENV" flag:"env"`                                    // development / staging / production
+	ServerPort   string `yaml:"server_port" env:"SERVER_PORT" flag:"port"`                               // ":8080"
+	DatabaseDSN  string `yaml:"database_dsn" env:"DATABASE_DSN" flag:"database-dsn" secret:"dsn"`        // データベース接続文字列
+	JWTSecretKey string `yaml:"jwt_secret_key" env:"JWT_SECRET_KEY" flag:"jwt-secret-key" secret:"true"` // JWT署名用の秘密鍵
+
+	CORS    CORSConfig    `yaml:"cors"`
+	Session SessionConfig `yaml:"session"`
+	OAuth   OAuthConfig   `yaml:"oauth"`
+
+	AuthServer AuthServerConfig `yaml:"auth_server" env:"AUTH_SERVER_"`
+	Mail       MailConfig       `yaml:"mail" env:"MAIL_"`
+	MagicLink  MagicLinkConfig  `yaml:"magic_link" env:"MAGIC_LINK_"`
+	WebAuthn   WebAuthnConfig   `yaml:"webauthn" env:"WEBAUTHN_"`
+
+	EmailChange EmailChangeConfig `yaml:"email_change" env:"EMAIL_CHANGE_"`
+
+	PasswordPolicy PasswordPolicyConfig `yaml:"password_policy" env:"PASSWORD_"`
+	PasswordHash   PasswordHashConfig   `yaml:"password_hash" env:"PASSWORD_HASH_"`
+
+	Audit AuditConfig `yaml:"audit" env:"AUDIT_"`
+
+	Storage StorageConfig `yaml:"storage" env:"STORAGE_"`
+	Avatar  AvatarConfig  `yaml:"avatar" env:"AVATAR_"`
+
+	Username     UsernameConfig     `yaml:"username" env:"USERNAME_"`
+	Registration RegistrationConfig `yaml:"registration" env:"REGISTRATION_"`
+}
+
+// セッションの受け渡し方式です。
+const (
+	SessionModeBearer = "bearer" // トークンをレスポンスボディで返し、Authorization ヘッダーで受け取ります
+	SessionModeCookie = "cookie" // トークンを HttpOnly Cookie に保存します
+)
+
+// SessionConfig はログイン後のトークンの発行方法に関する設定です。
+type SessionConfig struct {
+	Mode            string        `yaml:"mode" env:"SESSION_MODE" flag:"session-mode"`
+	AccessTokenTTL  time.Duration `yaml:"access_token_ttl" env:"ACCESS_TOKEN_TTL"`
+	RefreshTokenTTL time.Duration `yaml:"refresh_token_ttl" env:"REFRESH_TOKEN_TTL"`
+	// LastSeenInterval はセッションの最終アクセス日時を更新する最小間隔です (書き込み負荷の抑制)。
+	LastSeenInterval time.Duration `yaml:"last_seen_interval" env:"SESSION_LAST_SEEN_INTERVAL"`
+	// ImpersonationTTL は管理者による代理ログインのセッションの有効期間です。延長やリフレッシュはできません。
+	ImpersonationTTL time.Duration `yaml:"impersonation_ttl" env:"SESSION_IMPERSONATION_TTL"`
+	CookieDomain     string        `yaml:"cookie_domain" env:"SESSION_COOKIE_DOMAIN"`
+	CookieSecure     bool          `yaml:"cookie_secure" env:"SESSION_COOKIE_SECURE"`
+	CookieSameSite   string        `yaml:"cookie_same_site" env:"SESSION_COOKIE_SAMESITE"` // lax / strict / none
+}
+
+// CORSConfig はクロスオリジンリクエストの許可設定です。
+//
+// AllowedOrigins には "https://app.example.com" のような完全なオリジンのほか、
+// "https://*.example.com" のようなサブドメインのワイルドカードを指定できます。
+// Environment が development の場合は、これに加えて任意の localhost オリジンを許可します。
+type CORSConfig struct {
+	AllowedOrigins   []string      `yaml:"allowed_origins" env:"CORS_ALLOWED_ORIGINS" flag:"cors-allowed-origins"`
+	AllowedMethods   []string      `yaml:"allowed_methods" env:"CORS_ALLOWED_METHODS"`
+	AllowedHeaders   []string      `yaml:"allowed_headers" env:"CORS_ALLOWED_HEADERS"`
+	ExposedHeaders   []string      `yaml:"exposed_headers" env:"CORS_EXPOSED_HEADERS"`
+	AllowCredentials bool          `yaml:"allow_credentials" env:"CORS_ALLOW_CREDENTIALS"`
+	MaxAge           time.Duration `yaml:"max_age" env:"CORS_MAX_AGE"`
+}
+
+// OAuthConfig は外部の OAuth2 / OpenID Connect プロバイダーによるログインの設定です。
+// ClientID が設定されたプロバイダーのみ有効になります。
+type OAuthConfig struct {
+	// RedirectBaseURL はフロントエンドのコールバック URL の基底です。
+	// 実際のリダイレクト URI は "<RedirectBaseURL>/<provider>" になります。
+	RedirectBaseURL string              `yaml:"redirect_base_url" env:"OAUTH_REDIRECT_BASE_URL"`
+	Google          OAuthProviderConfig `yaml:"google" env:"OAUTH_GOOGLE_"`
+	GitHub          OAuthProviderConfig `yaml:"github" env:"OAUTH_GITHUB_"`
+	OIDC            OIDCProviderConfig  `yaml:"oidc"`
+}
+
+// OAuthProviderConfig は Google / GitHub など、エンドポイントが既知のプロバイダーの設定です。
+type OAuthProviderConfig struct {
+	ClientID     string `yaml:"client_id" env:"CLIENT_ID"`
+	ClientSecret string `yaml:"client_secret" env:"CLIENT_SECRET" secret:"true"`
+}
+
+// OIDCProviderConfig は任意の OpenID Connect プロバイダーの設定です。
+// IssuerURL のディスカバリードキュメントからエンドポイントを取得します。
+type OIDCProviderConfig struct {
+	Name         string   `yaml:"name" env:"OAUTH_OIDC_NAME"` // URL に使うプロバイダー名 (既定値 "oidc")
+	IssuerURL    string   `yaml:"issuer_url" env:"OAUTH_OIDC_ISSUER_URL"`
+	ClientID     string   `yaml:"client_id" env:"OAUTH_OIDC_CLIENT_ID"`
+	ClientSecret string   `yaml:"client_secret" env:"OAUTH_OIDC_CLIENT_SECRET" secret:"true"`
+	Scopes       []string `yaml:"scopes" env:"OAUTH_OIDC_SCOPES"`
+}
+
+// AuthServerConfig は YUTAKA を OAuth2 認可サーバー / OIDC プロバイダーとして動かすための設定です。
+type AuthServerConfig struct {
+	Enabled bool   `yaml:"enabled" env:"ENABLED"`
+	Issuer  string `yaml:"issuer" env:"ISSUER"` // 例: "https://auth.example.com"
+	// AuthorizationEndpoint はディスカバリードキュメントで公開する認可エンドポイントです。
+	// 同意画面をフロントエンドで表示する場合はそのページの URL を指定します (既定値 "<Issuer>/oauth/authorize")。
+	AuthorizationEndpoint string `yaml:"authorization_endpoint" env:"AUTHORIZATION_ENDPOINT"`
+	// SigningKey は ID トークンとアクセストークンに署名する RSA 秘密鍵 (PEM) です。
+	// 未設定の場合は起動ごとに一時的な鍵を生成します (開発環境のみ)。
+	SigningKey      string        `yaml:"signing_key" env:"SIGNING_KEY" secret:"true"`
+	AuthCodeTTL     time.Duration `yaml:"auth_code_ttl" env:"AUTH_CODE_TTL"`
+	AccessTokenTTL  time.Duration `yaml:"access_token_ttl" env:"ACCESS_TOKEN_TTL"`
+	RefreshTokenTTL time.Duration `yaml:"refresh_token_ttl" env:"REFRESH_TOKEN_TTL"`
+}
+
+// メールの送信方式です。
+const (
+	MailDriverLog  = "log"  // 送信せずにログへ出力します (開発用)
+	MailDriverSMTP = "smtp" // SMTP サーバー経由で送信します
+)
+
+// MailConfig はメール送信の設定です。
+type MailConfig struct {
+	Driver       string `yaml:"driver" env:"DRIVER"` // log / smtp
+	From         string `yaml:"from" env:"FROM"`     // 例: "YUTAKA <no-reply@example.com>"
+	SMTPHost     string `yaml:"smtp_host" env:"SMTP_HOST"`
+	SMTPPort     int    `yaml:"smtp_port" env:"SMTP_PORT"`
+	SMTPUsername string `yaml:"smtp_username" env:"SMTP_USERNAME"`
+	SMTPPassword string `yaml:"smtp_password" env:"SMTP_PASSWORD" secret:"true"`
+}
+
+// MagicLinkConfig はメールのリンクによるパスワードなしログインの設定です。
+// URL が設定されている場合のみ有効になります。
+type MagicLinkConfig struct {
+	// URL はリンク先となるフロントエンドのページです。メールのリンクは "<URL>?token=..." になります。
+	// リンクを開いただけではログインせず、そのページから POST したときにトークンを消費します。
+	URL string        `yaml:"url" env:"URL"`
+	TTL time.Duration `yaml:"ttl" env:"TTL"`
+	// InvitationTTL は一括登録で送信する招待メールのリンクの有効期間です。招待メールも同じページを指します。
+	InvitationTTL time.Duration `yaml:"invitation_ttl" env:"INVITATION_TTL"`
+}
+
+// EmailChangeConfig はメールアドレス変更の確認の設定です。ConfirmURL が設定されている場合のみ有効になります。
+type EmailChangeConfig struct {
+	// ConfirmURL は新しいアドレスに送る確認リンクのページ、RevertURL は旧アドレスに送る取り消しリンクのページです。
+	// メールのリンクは "<URL>?token=..." になり、マジックリンクと同様にそのページから POST したときにトークンを消費します。
+	ConfirmURL string        `yaml:"confirm_url" env:"CONFIRM_URL"`
+	RevertURL  string        `yaml:"revert_url" env:"REVERT_URL"`
+	TTL        time.Duration `yaml:"ttl" env:"TTL"`               // 確認リンクの有効期間
+	RevertTTL  time.Duration `yaml:"revert_ttl" env:"REVERT_TTL"` // 取り消しリンクの有効期間 (申請時から)
+}
+
+// WebAuthnConfig はパスキー (WebAuthn) の設定です。RPID が設定されている場合のみ有効になります。
+type WebAuthnConfig struct {
+	RPID   string `yaml:"rp_id" env:"RP_ID"`     // 例: "example.com"
+	RPName string `yaml:"rp_name" env:"RP_NAME"` // 認証器に表示されるサービス名
+	// Origins はパスキーの操作を許可するフロントエンドのオリジンです。ホストは RPID またはそのサブドメインである必要があります。
+	Origins          []string      `yaml:"origins" env:"ORIGINS"`
+	UserVerification string        `yaml:"user_verification" env:"USER_VERIFICATION"` // required / preferred / discouraged
+	Timeout          time.Duration `yaml:"timeout" env:"TIMEOUT"`                     // 登録・ログインのチャレンジの有効期間
+}
+
+// PasswordPolicyConfig はユーザー登録・パスワード変更時に適用するパスワードの条件です。
+type PasswordPolicyConfig struct {
+	MinLength int `yaml:"min_length" env:"MIN_LENGTH"` // 文字数
+	// MaxBytes はバイト数の上限です。bcrypt は 72 バイトを超えるパスワードを扱えないため、bcrypt の場合は 72 以下にしてください。
+	MaxBytes         int  `yaml:"max_bytes" env:"MAX_BYTES"`
+	RequireUppercase bool `yaml:"require_uppercase" env:"REQUIRE_UPPERCASE"`
+	RequireLowercase bool `yaml:"require_lowercase" env:"REQUIRE_LOWERCASE"`
+	RequireDigit     bool `yaml:"require_digit" env:"REQUIRE_DIGIT"`
+	RequireSymbol    bool `yaml:"require_symbol" env:"REQUIRE_SYMBOL"`
+	// DisallowPersonalInfo はユーザー名やメールアドレスを含むパスワードを拒否します。
+	DisallowPersonalInfo bool `yaml:"disallow_personal_info" env:"DISALLOW_PERSONAL_INFO"`
+	// HistorySize は再利用を禁止する直近のパスワードの数です (0 の場合は確認しません)。
+	HistorySize int `yaml:"history_size" env:"HISTORY_SIZE"`
+	// BreachedListFile は漏洩済みパスワードの SHA-1 ハッシュの一覧です (Have I Been Pwned の
+	// "ordered by hash" 形式: 1行に "<SHA-1 の16進数>:<出現回数>" をハッシュ順に並べたもの)。
+	// ハッシュの先頭5文字の範囲だけを読み込んで照合します。未設定の場合は確認しません。
+	BreachedListFile string `yaml:"breached_list_file" env:"BREACHED_LIST_FILE"`
+}
+
+// パスワードのハッシュ方式です。
+const (
+	PasswordHashBcrypt   = "bcrypt"
+	PasswordHashArgon2id = "argon2id"
+)
+
+// PasswordHashConfig は新しく保存するパスワードのハッシュ方式とパラメーターです。
+// 保存済みのハッシュが異なる方式やパラメーターの場合は、次回ログイン時に再ハッシュされます。
+type PasswordHashConfig struct {
+	Algorithm  string `yaml:"algorithm" env:"ALGORITHM"` // bcrypt / argon2id
+	BcryptCost int    `yaml:"bcrypt_cost" env:"BCRYPT_COST"`
+	// Argon2id のパラメーター (RFC 9106)。メモリーは KiB 単位です。
+	Argon2Memory      int `yaml:"argon2_memory" env:"ARGON2_MEMORY"`
+	Argon2Iterations  int `yaml:"argon2_iterations" env:"ARGON2_ITERATIONS"`
+	Argon2Parallelism int `yaml:"argon2_parallelism" env:"ARGON2_PARALLELISM"`
+}
+
+// AuditConfig は監査ログのハッシュチェーンの署名付きチェックポイントの設定です。
+// CheckpointKey を設定した場合のみ、CheckpointInterval ごとにチェーンの末尾へ署名します。
+type AuditConfig struct {
+	// CheckpointKey は PEM 形式 (PKCS#8) の Ed25519 秘密鍵です (例: openssl genpkey -algorithm ed25519)。
+	CheckpointKey      string        `yaml:"checkpoint_key" env:"CHECKPOINT_KEY" secret:"true"`
+	CheckpointInterval time.Duration `yaml:"checkpoint_interval" env:"CHECKPOINT_INTERVAL"`
+}
+
+// ファイルの保存先です。
+const (
+	StorageDriverLocal = "local" // サーバーのディレクトリに保存し、このサーバーから配信します
+	StorageDriverS3    = "s3"    // S3 互換のオブジェクトストレージに保存します
+)
+
+// StorageConfig はアバター画像などのファイルの保存先の設定です。
+// ファイルは署名付き URL で配信され、URLTTL の間だけ有効です。
+type StorageConfig struct {
+	Driver string `yaml:"driver" env:"DRIVER"` // local / s3
+
+	LocalDir string `yaml:"local_dir" env:"LOCAL_DIR"`
+	// PublicURL は local の場合に署名付き URL の先頭に付けるこのサーバーの URL です (例: "https://api.example.com")。
+	// 未設定の場合は "/api/blobs/..." の相対 URL になります。
+	PublicURL string `yaml:"public_url" env:"PUBLIC_URL"`
+	// URLSigningKey は local の署名付き URL の HMAC 鍵です。未設定の場合は JWT_SECRET_KEY から導出します。
+	URLSigningKey string `yaml:"url_signing_key" env:"URL_SIGNING_KEY" secret:"true"`
+
+	S3Endpoint        string `yaml:"s3_endpoint" env:"S3_ENDPOINT"` // 例: "https://s3.ap-northeast-1.amazonaws.com", "http://localhost:9000"
+	S3Region          string `yaml:"s3_region" env:"S3_REGION"`
+	S3Bucket          string `yaml:"s3_bucket" env:"S3_BUCKET"`
+	S3AccessKeyID     string `yaml:"s3_access_key_id" env:"S3_ACCESS_KEY_ID"`
+	S3SecretAccessKey string `yaml:"s3_secret_access_key" env:"S3_SECRET_ACCESS_KEY" secret:"true"`
+	// S3PathStyle は "<endpoint>/<bucket>/<key>" 形式でアクセスします (MinIO など)。
+	S3PathStyle bool `yaml:"s3_path_style" env:"S3_PATH_STYLE"`
+
+	URLTTL time.Duration `yaml:"url_ttl" env:"URL_TTL"`
+}
+
+// AvatarConfig はアバター画像のアップロードの制限です。
+type AvatarConfig struct {
+	MaxBytes int `yaml:"max_bytes" env:"MAX_BYTES"`
+	// MaxDimension は受け付ける画像の幅・高さの上限 (ピクセル) です。展開後のメモリー使用量を制限します。
+	MaxDimension int `yaml:"max_dimension" env:"MAX_DIMENSION"`
+}
+
+// UsernameConfig はユーザー名の予約と変更の制限です。
+type UsernameConfig struct {
+	// Reserved は登録・変更に使用できないユーザー名です。大文字・小文字を区別せずに比較します。
+	Reserved []string `yaml:"reserved" env:"RESERVED"`
+	// ChangeInterval はユーザー名を変更してから次に変更できるまでの期間です。
+	ChangeInterval time.Duration `yaml:"change_interval" env:"CHANGE_INTERVAL"`
+	// HoldPeriod は変更前のユーザー名を元の所有者のために保持し、他のユーザーに使わせない期間です。
+	HoldPeriod time.Duration `yaml:"hold_period" env:"HOLD_PERIOD"`
+}
+
+// 新規登録の受け付け方です。
+const (
+	RegistrationModeOpen       = "open"        // 誰でも登録できます
+	RegistrationModeInviteOnly = "invite_only" // 管理者が発行した招待コードを持つ人のみ登録できます
+	RegistrationModeClosed     = "closed"      // 登録を受け付けません (管理者による一括登録のみ)
+)
+
+// RegistrationConfig は新規登録の受け付けに関する設定です。
+// 外部プロバイダーでの初回ログインによるユーザーの作成は open の場合のみ行います。
+type RegistrationConfig struct {
+	Mode string `yaml:"mode" env:"MODE"`
+	// InviteTTL は有効期限を指定せずに発行した招待コードの有効期間です。
+	InviteTTL time.Duration `yaml:"invite_ttl" env:"INVITE_TTL"`
 }
 
 // AppConfig はロードされた設定を保持するグローバル変数です。
 var AppConfig Config
 
-// LoadConfig は環境変数または.envファイルから設定をロードします。
-func LoadConfig() error {
-	AppConfig.ServerPort = os.Getenv("SERVER_PORT")
-	AppConfig.DatabaseDSN = os.Getenv("DATABASE_DSN")
-	AppConfig.JWTSecretKey = os.Getenv("JWT_SECRET_KEY")
+// defaultConfig は何も指定されなかった場合の既定値を返します。
+func defaultConfig() Config {
+	return Config{
+		Environment: EnvProduction,
+		ServerPort:  ":8080",
+		CORS: CORSConfig{
+			AllowedOrigins:   []string{"http://localhost:3000"},
+			AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+			AllowedHeaders:   []string{"Origin", "Content-Type", "Accept", "Authorization", "X-CSRF-Token"},
+			ExposedHeaders:   []string{"Content-Length", "X-Request-ID"},
+			AllowCredentials: true,
+			MaxAge:           12 * time.Hour,
+		},
+		Session: SessionConfig{
+			Mode:             SessionModeBearer,
+			AccessTokenTTL:   24 * time.Hour,
+			RefreshTokenTTL:  30 * 24 * time.Hour,
+			LastSeenInterval: 5 * time.Minute,
+			ImpersonationTTL: 30 * time.Minute,
+			CookieSecure:     true,
+			CookieSameSite:   "lax",
+		},
+		Mail: MailConfig{
+			Driver:   MailDriverLog,
+			SMTPPort: 587,
+		},
+		MagicLink: MagicLinkConfig{
+			TTL:           15 * time.Minute,
+			InvitationTTL: 72 * time.Hour,
+		},
+		EmailChange: EmailChangeConfig{
+			TTL:       24 * time.Hour,
+			RevertTTL: 7 * 24 * time.Hour,
+		},
+		WebAuthn: WebAuthnConfig{
+			RPName:           "YUTAKA",
+			UserVerification: "preferred",
+			Timeout:          5 * time.Minute,
+		},
+		PasswordPolicy: PasswordPolicyConfig{
+			MinLength:            8,
+			MaxBytes:             72,
+			DisallowPersonalInfo: true,
+			HistorySize:          5,
+		},
+		PasswordHash: PasswordHashConfig{
+			Algorithm:         PasswordHashBcrypt,
+			BcryptCost:        10,
+			Argon2Memory:      64 * 1024,
+			Argon2Iterations:  3,
+			Argon2Parallelism: 2,
+		},
+		Audit: AuditConfig{
+			CheckpointInterval: time.Hour,
+		},
+		Storage: StorageConfig{
+			Driver:   StorageDriverLocal,
+			LocalDir: "./data/blobs",
+			S3Region: "us-east-1",
+			URLTTL:   time.Hour,
+		},
+		Avatar: AvatarConfig{
+			MaxBytes:     5 << 20,
+			MaxDimension: 4096,
+		},
+		Username: UsernameConfig{
+			Reserved: []string{
+				"admin", "administrator", "root", "system", "support", "help", "security",
+				"api", "www", "me", "settings", "login", "logout", "register", "null", "undefined",
+			},
+			ChangeInterval: 30 * 24 * time.Hour,
+			HoldPeriod:     90 * 24 * time.Hour,
+		},
+		Registration: RegistrationConfig{
+			Mode:      RegistrationModeOpen,
+			InviteTTL: 7 * 24 * time.Hour,
+		},
+	}
+}
 
-	if AppConfig.DatabaseDSN == "" { return errors.New("DATABASE_DSN is not set") }
-	if AppConfig.JWTSecretKey == "" { return errors.New("JWT_SECRET_KEY is not set") }
+// IsDevelopment は開発環境で動作しているかどうかを返します。
+func (c *Config) IsDevelopment() bool {
+	return c.Environment == EnvDevelopment
+}
 
-	if AppConfig.ServerPort == "" { AppConfig.ServerPort = ":8080" }
+// LoadConfig は設定ファイル・環境変数・コマンドラインフラグから設定をロードし、
+// 検証に成功した場合に AppConfig へ反映します。
+// 優先順位は 既定値 < 設定ファイル < 環境変数 < フラグ です。
+func LoadConfig(args []string) error {
+	cfg, err := Load(flag.NewFlagSet("server", flag.ContinueOnError), args)
+	if err != nil {
+		return err
+	}
 
+	AppConfig = *cfg
 	log.Println("設定が正常にロードされました。")
 	return nil
 }
 
+// Load は fs に設定用のフラグを登録して args を解析し、各レイヤーを重ねた設定を返します。
+// 呼び出し側は Load の前に fs へ独自のフラグを追加できます。
+func Load(fs *flag.FlagSet, args []string) (*Config, error) {
+	cfg := defaultConfig()
+
+	configPath := fs.String("config", "", "設定ファイルのパス (.yaml, .yml, .toml)")
+	overrides := registerFlags(fs, &cfg)
+	if err := fs.Parse(args); err != nil {
+		return nil, fmt.Errorf("config.Load: フラグの解析に失敗しました: %w", err)
+	}
+
+	path := *configPath
+	if path == "" {
+		v, err := lookupEnv("CONFIG_FILE")
+		if err != nil {
+			return nil, fmt.Errorf("config.Load: %w", err)
+		}
+		path = v
+	}
+	if path != "" {
+		if err := loadFile(path, &cfg); err != nil {
+			return nil, fmt.Errorf("config.Load: %w", err)
+		}
+	}
+
+	if err := applyEnv(&cfg); err != nil {
+		return nil, fmt.Errorf("config.Load: %w", err)
+	}
+
+	for _, apply := range *overrides {
+		if err := apply(); err != nil {
+			return nil, fmt.Errorf("config.Load: %w", err)
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
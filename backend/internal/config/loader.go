@@ -0,0 +1,202 @@
+// backend/internal/config/loader.go
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// field は Config 内の設定可能な1つのフィールドを表します。
+type field struct {
+	value reflect.Value
+	tag   reflect.StructTag
+	env   string // 接頭辞を含めた環境変数名 (なければ空)
+}
+
+// fields は Config の設定可能なフィールドを入れ子の構造体も含めて列挙します。
+func fields(cfg *Config) []field {
+	var out []field
+	var walk func(v reflect.Value, envPrefix string)
+	walk = func(v reflect.Value, envPrefix string) {
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			fv := v.Field(i)
+			if fv.Kind() == reflect.Struct && fv.Type() != durationType {
+				walk(fv, envPrefix+sf.Tag.Get("env"))
+				continue
+			}
+			f := field{value: fv, tag: sf.Tag}
+			if name := sf.Tag.Get("env"); name != "" {
+				f.env = envPrefix + name
+			}
+			out = append(out, f)
+		}
+	}
+	walk(reflect.ValueOf(cfg).Elem(), "")
+	return out
+}
+
+// loadFile は拡張子に応じて YAML または TOML の設定ファイルを読み込みます。
+func loadFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("設定ファイル %s を読み込めません: %w", path, err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = decodeYAML(data, cfg)
+	case ".toml":
+		// TOML は一度汎用のマップに読み込み、YAML のデコーダーで構造体に反映します。
+		// これにより "12h" のような期間の文字列も YAML と同じく time.Duration として扱えます。
+		// 未知のキーも YAML と同じく decodeYAML で拒否されます。
+		var m map[string]any
+		if err = toml.Unmarshal(data, &m); err == nil {
+			var b []byte
+			if b, err = yaml.Marshal(m); err == nil {
+				err = decodeYAML(b, cfg)
+			}
+		}
+	default:
+		return fmt.Errorf("設定ファイル %s の形式に対応していません (.yaml, .yml, .toml のみ)", path)
+	}
+	if err != nil {
+		return fmt.Errorf("設定ファイル %s の解析に失敗しました: %w", path, err)
+	}
+	return nil
+}
+
+// decodeYAML は YAML を cfg に反映します。
+// キーの綴りを間違えた設定が黙って既定値のまま使われないよう、Config にないキーはエラーにします。
+func decodeYAML(data []byte, cfg *Config) error {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	return nil
+}
+
+// lookupEnv は環境変数 name の値を返します。
+// name が未設定で name_FILE が設定されている場合は、そのファイルの内容を値とします。
+// (Docker / Kubernetes のシークレットをファイルとしてマウントする運用向けです)
+func lookupEnv(name string) (string, error) {
+	value, hasValue := os.LookupEnv(name)
+	path, hasFile := os.LookupEnv(name + "_FILE")
+
+	if hasValue && hasFile {
+		return "", fmt.Errorf("%s と %s_FILE が両方設定されています", name, name)
+	}
+	if !hasFile {
+		return value, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("%s_FILE のファイルを読み込めません: %w", name, err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// applyEnv は env タグを持つフィールドに環境変数の値を反映します。
+func applyEnv(cfg *Config) error {
+	for _, f := range fields(cfg) {
+		name := f.env
+		if name == "" {
+			continue
+		}
+		value, err := lookupEnv(name)
+		if err != nil {
+			return err
+		}
+		if value == "" {
+			continue
+		}
+		if err := setField(f.value, value); err != nil {
+			return fmt.Errorf("環境変数 %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// registerFlags は flag タグを持つフィールドを fs に登録します。
+// フラグの値は環境変数より優先させるため、解析後に返された関数で反映します。
+func registerFlags(fs *flag.FlagSet, cfg *Config) *[]func() error {
+	overrides := &[]func() error{}
+	for _, f := range fields(cfg) {
+		name := f.tag.Get("flag")
+		if name == "" {
+			continue
+		}
+		target := f.value
+		usage := "環境変数 " + f.env + " を上書きします"
+		fs.Func(name, usage, func(value string) error {
+			*overrides = append(*overrides, func() error {
+				if err := setField(target, value); err != nil {
+					return fmt.Errorf("フラグ --%s: %w", name, err)
+				}
+				return nil
+			})
+			return nil
+		})
+	}
+	return overrides
+}
+
+// setField は文字列の値をフィールドの型に変換して設定します。
+func setField(v reflect.Value, raw string) error {
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("期間として解釈できません: %q", raw)
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("真偽値として解釈できません: %q", raw)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return fmt.Errorf("整数として解釈できません: %q", raw)
+		}
+		v.SetInt(n)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("対応していない型です: %s", v.Type())
+		}
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("対応していない型です: %s", v.Type())
+	}
+	return nil
+}
@@ -0,0 +1,519 @@
+// backend/internal/config/validate.go
+package config
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/mail"
+	"net/url"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+const (
+	// minSecretLength は JWT 秘密鍵に要求する最小の文字数です。
+	minSecretLength = 32
+	// minSecretEntropyBits は JWT 秘密鍵に要求する推定エントロピー (ビット) です。
+	// 推定は文字の偏りから行うため実際の値より低めになり、32 文字の16進数 (openssl rand -hex 16) は満たしません。
+	// 43 文字以上の Base64 (openssl rand -base64 32) か 64 文字の16進数 (openssl rand -hex 32) を想定しています。
+	minSecretEntropyBits = 128.0
+	// secretHint は秘密鍵が要件を満たさない場合に示す生成方法です。
+	secretHint = "ランダムに生成した 43 文字以上の Base64 (例: openssl rand -base64 32) か 64 文字の16進数 (例: openssl rand -hex 32) を使用してください"
+)
+
+// Validate は設定値を検証し、問題があればすべてまとめたエラーを返します。
+func (c *Config) Validate() error {
+	var errs []error
+
+	switch c.Environment {
+	case EnvDevelopment, EnvStaging, EnvProduction:
+	default:
+		errs = append(errs, fmt.Errorf("APP_ENV %q は development / staging / production のいずれかである必要があります", c.Environment))
+	}
+
+	if err := validatePort(c.ServerPort); err != nil {
+		errs = append(errs, err)
+	}
+
+	if c.DatabaseDSN == "" {
+		errs = append(errs, errors.New("DATABASE_DSN is not set"))
+	} else if _, err := mysql.ParseDSN(c.DatabaseDSN); err != nil {
+		errs = append(errs, fmt.Errorf("DATABASE_DSN を解析できません: %w", err))
+	}
+
+	if c.JWTSecretKey == "" {
+		errs = append(errs, errors.New("JWT_SECRET_KEY is not set"))
+	} else if err := validateSecret("JWT_SECRET_KEY", c.JWTSecretKey); err != nil {
+		errs = append(errs, err)
+	}
+
+	errs = append(errs, c.CORS.validate()...)
+	errs = append(errs, c.Session.validate()...)
+	errs = append(errs, c.OAuth.validate()...)
+	errs = append(errs, c.AuthServer.validate(c.Environment)...)
+	errs = append(errs, c.Mail.validate()...)
+	errs = append(errs, c.MagicLink.validate()...)
+	errs = append(errs, c.EmailChange.validate()...)
+	errs = append(errs, c.WebAuthn.validate()...)
+	errs = append(errs, c.PasswordPolicy.validate(c.PasswordHash.Algorithm)...)
+	errs = append(errs, c.PasswordHash.validate()...)
+	errs = append(errs, c.Audit.validate()...)
+	errs = append(errs, c.Storage.validate()...)
+	errs = append(errs, c.Avatar.validate()...)
+	errs = append(errs, c.Username.validate()...)
+	errs = append(errs, c.Registration.validate()...)
+	// log ドライバーではログインリンクがログに残るため、本番環境では使用できません。
+	if c.MagicLink.URL != "" && c.Environment == EnvProduction && c.Mail.Driver == MailDriverLog {
+		errs = append(errs, errors.New("本番環境で MAGIC_LINK_URL を設定する場合は MAIL_DRIVER を smtp にしてください"))
+	}
+	if c.EmailChange.ConfirmURL != "" && c.Environment == EnvProduction && c.Mail.Driver == MailDriverLog {
+		errs = append(errs, errors.New("本番環境で EMAIL_CHANGE_CONFIRM_URL を設定する場合は MAIL_DRIVER を smtp にしてください"))
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("設定が不正です: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// validatePort は ":8080" や "127.0.0.1:8080" 形式の待ち受けアドレスを検証します。
+func validatePort(addr string) error {
+	_, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("SERVER_PORT %q は \"host:port\" 形式である必要があります", addr)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil || port < 1 || port > 65535 {
+		return fmt.Errorf("SERVER_PORT %q のポート番号が不正です", addr)
+	}
+	return nil
+}
+
+// validateSecret は秘密鍵の長さと推定エントロピーを検証します。
+func validateSecret(name, secret string) error {
+	if n := len([]rune(secret)); n < minSecretLength {
+		return fmt.Errorf("%s は %d 文字以上である必要があります (現在 %d 文字)。%s", name, minSecretLength, n, secretHint)
+	}
+	if bits := estimateEntropyBits(secret); bits < minSecretEntropyBits {
+		return fmt.Errorf("%s のエントロピーが不足しています (文字の出現頻度から推定 %.0f ビット、%.0f ビット以上が必要)。%s",
+			name, bits, minSecretEntropyBits, secretHint)
+	}
+	return nil
+}
+
+// estimateEntropyBits は文字の出現頻度から求めたシャノンエントロピーに
+// 文字数を掛けて、文字列全体のエントロピーを概算します。
+func estimateEntropyBits(s string) float64 {
+	runes := []rune(s)
+	counts := make(map[rune]int)
+	for _, r := range runes {
+		counts[r]++
+	}
+
+	n := float64(len(runes))
+	var perChar float64
+	for _, c := range counts {
+		p := float64(c) / n
+		perChar -= p * math.Log2(p)
+	}
+	return perChar * n
+}
+
+// validate は CORS 設定のオリジン指定を検証します。
+func (c *CORSConfig) validate() []error {
+	var errs []error
+	for _, origin := range c.AllowedOrigins {
+		if origin == "*" {
+			if c.AllowCredentials {
+				errs = append(errs, errors.New("CORS_ALLOWED_ORIGINS に \"*\" を指定する場合は CORS_ALLOW_CREDENTIALS を false にしてください"))
+			}
+			continue
+		}
+		u, err := url.Parse(origin)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || (u.Path != "" && u.Path != "/") {
+			errs = append(errs, fmt.Errorf("CORS_ALLOWED_ORIGINS %q は \"scheme://host[:port]\" 形式である必要があります", origin))
+			continue
+		}
+		if host := u.Hostname(); strings.Contains(strings.TrimPrefix(host, "*."), "*") {
+			errs = append(errs, fmt.Errorf("CORS_ALLOWED_ORIGINS %q: ワイルドカードは先頭の \"*.\" のみ使用できます", origin))
+		}
+	}
+	if c.MaxAge < 0 {
+		errs = append(errs, errors.New("CORS_MAX_AGE は 0 以上である必要があります"))
+	}
+	return errs
+}
+
+// validate はセッション設定を検証します。
+func (s *SessionConfig) validate() []error {
+	var errs []error
+	if s.Mode != SessionModeBearer && s.Mode != SessionModeCookie {
+		errs = append(errs, fmt.Errorf("SESSION_MODE %q は bearer / cookie のいずれかである必要があります", s.Mode))
+	}
+	if s.AccessTokenTTL <= 0 {
+		errs = append(errs, errors.New("ACCESS_TOKEN_TTL は正の期間である必要があります"))
+	}
+	if s.RefreshTokenTTL <= 0 {
+		errs = append(errs, errors.New("REFRESH_TOKEN_TTL は正の期間である必要があります"))
+	}
+	if s.ImpersonationTTL <= 0 {
+		errs = append(errs, errors.New("SESSION_IMPERSONATION_TTL は正の期間である必要があります"))
+	}
+	switch strings.ToLower(s.CookieSameSite) {
+	case "lax", "strict":
+	case "none":
+		if !s.CookieSecure {
+			errs = append(errs, errors.New("SESSION_COOKIE_SAMESITE=none の場合は SESSION_COOKIE_SECURE を true にしてください"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("SESSION_COOKIE_SAMESITE %q は lax / strict / none のいずれかである必要があります", s.CookieSameSite))
+	}
+	return errs
+}
+
+// validate は OAuth プロバイダー設定を検証します。
+func (o *OAuthConfig) validate() []error {
+	var errs []error
+	enabled := o.Google.ClientID != "" || o.GitHub.ClientID != "" || o.OIDC.ClientID != ""
+	if !enabled {
+		return nil
+	}
+
+	if u, err := url.Parse(o.RedirectBaseURL); err != nil || u.Scheme == "" || u.Host == "" {
+		errs = append(errs, errors.New("OAuth プロバイダーを有効にする場合は OAUTH_REDIRECT_BASE_URL に絶対 URL を設定してください"))
+	}
+	if o.Google.ClientID != "" && o.Google.ClientSecret == "" {
+		errs = append(errs, errors.New("OAUTH_GOOGLE_CLIENT_SECRET is not set"))
+	}
+	if o.GitHub.ClientID != "" && o.GitHub.ClientSecret == "" {
+		errs = append(errs, errors.New("OAUTH_GITHUB_CLIENT_SECRET is not set"))
+	}
+	if o.OIDC.ClientID != "" {
+		if u, err := url.Parse(o.OIDC.IssuerURL); err != nil || u.Scheme == "" || u.Host == "" {
+			errs = append(errs, errors.New("OAUTH_OIDC_ISSUER_URL に絶対 URL を設定してください"))
+		}
+		switch o.OIDC.Name {
+		case "google", "github":
+			errs = append(errs, fmt.Errorf("OAUTH_OIDC_NAME %q は組み込みのプロバイダー名と重複しています", o.OIDC.Name))
+		}
+	}
+	return errs
+}
+
+// validate は認可サーバーの設定を検証します。
+func (a *AuthServerConfig) validate(env string) []error {
+	if !a.Enabled {
+		return nil
+	}
+
+	var errs []error
+	if u, err := url.Parse(a.Issuer); err != nil || u.Scheme == "" || u.Host == "" || u.RawQuery != "" || u.Fragment != "" {
+		errs = append(errs, errors.New("AUTH_SERVER_ISSUER にクエリやフラグメントを含まない絶対 URL を設定してください"))
+	} else if env == EnvProduction && u.Scheme != "https" {
+		errs = append(errs, errors.New("本番環境では AUTH_SERVER_ISSUER に https の URL を設定してください"))
+	}
+	if a.SigningKey == "" && env != EnvDevelopment {
+		errs = append(errs, errors.New("AUTH_SERVER_SIGNING_KEY is not set"))
+	}
+	if a.AuthCodeTTL <= 0 || a.AccessTokenTTL <= 0 || a.RefreshTokenTTL <= 0 {
+		errs = append(errs, errors.New("AUTH_SERVER_*_TTL は正の期間である必要があります"))
+	}
+	return errs
+}
+
+// validate はメール送信の設定を検証します。
+func (m *MailConfig) validate() []error {
+	var errs []error
+	switch m.Driver {
+	case MailDriverLog:
+	case MailDriverSMTP:
+		if m.SMTPHost == "" {
+			errs = append(errs, errors.New("MAIL_SMTP_HOST is not set"))
+		}
+		if m.SMTPPort < 1 || m.SMTPPort > 65535 {
+			errs = append(errs, fmt.Errorf("MAIL_SMTP_PORT %d は 1〜65535 の範囲である必要があります", m.SMTPPort))
+		}
+		if m.From == "" {
+			errs = append(errs, errors.New("MAIL_FROM is not set"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("MAIL_DRIVER %q は log / smtp のいずれかである必要があります", m.Driver))
+	}
+	if m.From != "" {
+		if _, err := mail.ParseAddress(m.From); err != nil {
+			errs = append(errs, fmt.Errorf("MAIL_FROM %q を解析できません: %w", m.From, err))
+		}
+	}
+	return errs
+}
+
+// validate はマジックリンクの設定を検証します。
+func (m *MagicLinkConfig) validate() []error {
+	if m.URL == "" {
+		return nil
+	}
+
+	var errs []error
+	if u, err := url.Parse(m.URL); err != nil || u.Scheme == "" || u.Host == "" || u.RawQuery != "" || u.Fragment != "" {
+		errs = append(errs, errors.New("MAGIC_LINK_URL にクエリやフラグメントを含まない絶対 URL を設定してください"))
+	}
+	if m.TTL <= 0 || m.TTL > time.Hour {
+		errs = append(errs, errors.New("MAGIC_LINK_TTL は 1 時間以下の正の期間である必要があります"))
+	}
+	if m.InvitationTTL <= 0 || m.InvitationTTL > 30*24*time.Hour {
+		errs = append(errs, errors.New("MAGIC_LINK_INVITATION_TTL は 30 日以下の正の期間である必要があります"))
+	}
+	return errs
+}
+
+// validate はメールアドレス変更の設定を検証します。
+func (e *EmailChangeConfig) validate() []error {
+	if e.ConfirmURL == "" {
+		return nil
+	}
+
+	var errs []error
+	for _, v := range []struct{ name, url string }{
+		{"EMAIL_CHANGE_CONFIRM_URL", e.ConfirmURL},
+		{"EMAIL_CHANGE_REVERT_URL", e.RevertURL},
+	} {
+		if u, err := url.Parse(v.url); err != nil || u.Scheme == "" || u.Host == "" || u.RawQuery != "" || u.Fragment != "" {
+			errs = append(errs, fmt.Errorf("%s にクエリやフラグメントを含まない絶対 URL を設定してください", v.name))
+		}
+	}
+	if e.TTL <= 0 || e.TTL > 72*time.Hour {
+		errs = append(errs, errors.New("EMAIL_CHANGE_TTL は 72 時間以下の正の期間である必要があります"))
+	}
+	if e.RevertTTL < e.TTL || e.RevertTTL > 30*24*time.Hour {
+		errs = append(errs, errors.New("EMAIL_CHANGE_REVERT_TTL は EMAIL_CHANGE_TTL 以上、30 日以下である必要があります"))
+	}
+	return errs
+}
+
+// validate はパスキーの設定を検証します。
+func (w *WebAuthnConfig) validate() []error {
+	if w.RPID == "" {
+		return nil
+	}
+
+	var errs []error
+	if strings.ContainsAny(w.RPID, ":/") {
+		errs = append(errs, fmt.Errorf("WEBAUTHN_RP_ID %q にはスキームやポートを含めずドメイン名のみを指定してください", w.RPID))
+	}
+	if len(w.Origins) == 0 {
+		errs = append(errs, errors.New("WEBAUTHN_ORIGINS is not set"))
+	}
+	for _, origin := range w.Origins {
+		u, err := url.Parse(origin)
+		if err != nil || u.Host == "" || (u.Path != "" && u.Path != "/") {
+			errs = append(errs, fmt.Errorf("WEBAUTHN_ORIGINS %q は \"scheme://host[:port]\" 形式である必要があります", origin))
+			continue
+		}
+		host := u.Hostname()
+		if host != w.RPID && !strings.HasSuffix(host, "."+w.RPID) {
+			errs = append(errs, fmt.Errorf("WEBAUTHN_ORIGINS %q のホストは WEBAUTHN_RP_ID %q またはそのサブドメインである必要があります", origin, w.RPID))
+		}
+		// ブラウザは localhost を除き https でのみ WebAuthn を使用できます。
+		if u.Scheme != "https" && !(u.Scheme == "http" && host == "localhost") {
+			errs = append(errs, fmt.Errorf("WEBAUTHN_ORIGINS %q には https のオリジンを指定してください", origin))
+		}
+	}
+	switch w.UserVerification {
+	case "required", "preferred", "discouraged":
+	default:
+		errs = append(errs, fmt.Errorf("WEBAUTHN_USER_VERIFICATION %q は required / preferred / discouraged のいずれかである必要があります", w.UserVerification))
+	}
+	if w.Timeout <= 0 || w.Timeout > 10*time.Minute {
+		errs = append(errs, errors.New("WEBAUTHN_TIMEOUT は 10 分以下の正の期間である必要があります"))
+	}
+	return errs
+}
+
+// validate はパスワードポリシーを検証します。
+// bcrypt は 72 バイトを超えるパスワードを扱えないため、ハッシュ方式によって上限が変わります。
+func (p *PasswordPolicyConfig) validate(hashAlgorithm string) []error {
+	var errs []error
+	if p.MinLength < 1 {
+		errs = append(errs, errors.New("PASSWORD_MIN_LENGTH は 1 以上である必要があります"))
+	}
+	maxBytes := 1024
+	if hashAlgorithm == PasswordHashBcrypt {
+		maxBytes = 72
+	}
+	if p.MaxBytes < p.MinLength || p.MaxBytes > maxBytes {
+		errs = append(errs, fmt.Errorf("PASSWORD_MAX_BYTES %d は PASSWORD_MIN_LENGTH 以上 %d 以下である必要があります", p.MaxBytes, maxBytes))
+	}
+	if p.HistorySize < 0 || p.HistorySize > 24 {
+		errs = append(errs, fmt.Errorf("PASSWORD_HISTORY_SIZE %d は 0〜24 の範囲である必要があります", p.HistorySize))
+	}
+	if p.BreachedListFile != "" {
+		if info, err := os.Stat(p.BreachedListFile); err != nil {
+			errs = append(errs, fmt.Errorf("PASSWORD_BREACHED_LIST_FILE を開けません: %w", err))
+		} else if info.IsDir() {
+			errs = append(errs, fmt.Errorf("PASSWORD_BREACHED_LIST_FILE %q はファイルである必要があります", p.BreachedListFile))
+		}
+	}
+	return errs
+}
+
+// validate はパスワードのハッシュ方式とパラメーターを検証します。
+func (h *PasswordHashConfig) validate() []error {
+	var errs []error
+	switch h.Algorithm {
+	case PasswordHashBcrypt:
+		if h.BcryptCost < 10 || h.BcryptCost > 31 {
+			errs = append(errs, fmt.Errorf("PASSWORD_HASH_BCRYPT_COST %d は 10〜31 の範囲である必要があります", h.BcryptCost))
+		}
+	case PasswordHashArgon2id:
+		if h.Argon2Parallelism < 1 || h.Argon2Parallelism > 255 {
+			errs = append(errs, fmt.Errorf("PASSWORD_HASH_ARGON2_PARALLELISM %d は 1〜255 の範囲である必要があります", h.Argon2Parallelism))
+		}
+		// OWASP の推奨する最小構成 (19 MiB, 2 回) を下回らないようにします。
+		if h.Argon2Memory < 19*1024 || h.Argon2Memory > 4*1024*1024 {
+			errs = append(errs, fmt.Errorf("PASSWORD_HASH_ARGON2_MEMORY %d は 19456〜4194304 (KiB) の範囲である必要があります", h.Argon2Memory))
+		}
+		if h.Argon2Iterations < 2 || h.Argon2Iterations > 100 {
+			errs = append(errs, fmt.Errorf("PASSWORD_HASH_ARGON2_ITERATIONS %d は 2〜100 の範囲である必要があります", h.Argon2Iterations))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("PASSWORD_HASH_ALGORITHM %q は bcrypt / argon2id のいずれかである必要があります", h.Algorithm))
+	}
+	return errs
+}
+
+// validate は監査ログのチェックポイントの設定を検証します。鍵の中身は起動時に読み込むときに確認します。
+func (a *AuditConfig) validate() []error {
+	if a.CheckpointKey == "" {
+		return nil
+	}
+	if a.CheckpointInterval < time.Minute || a.CheckpointInterval > 24*time.Hour {
+		return []error{errors.New("AUDIT_CHECKPOINT_INTERVAL は 1 分以上 24 時間以下である必要があります")}
+	}
+	return nil
+}
+
+// validate はファイルの保存先の設定を検証します。
+func (s *StorageConfig) validate() []error {
+	var errs []error
+	switch s.Driver {
+	case StorageDriverLocal:
+		if s.LocalDir == "" {
+			errs = append(errs, errors.New("STORAGE_LOCAL_DIR is not set"))
+		}
+		if s.PublicURL != "" {
+			if u, err := url.Parse(s.PublicURL); err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+				errs = append(errs, fmt.Errorf("STORAGE_PUBLIC_URL %q は http(s) の URL である必要があります", s.PublicURL))
+			}
+		}
+	case StorageDriverS3:
+		if u, err := url.Parse(s.S3Endpoint); err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+			errs = append(errs, fmt.Errorf("STORAGE_S3_ENDPOINT %q は http(s) の URL である必要があります", s.S3Endpoint))
+		}
+		for name, v := range map[string]string{
+			"STORAGE_S3_REGION":            s.S3Region,
+			"STORAGE_S3_BUCKET":            s.S3Bucket,
+			"STORAGE_S3_ACCESS_KEY_ID":     s.S3AccessKeyID,
+			"STORAGE_S3_SECRET_ACCESS_KEY": s.S3SecretAccessKey,
+		} {
+			if v == "" {
+				errs = append(errs, fmt.Errorf("%s is not set", name))
+			}
+		}
+	default:
+		errs = append(errs, fmt.Errorf("STORAGE_DRIVER %q は local / s3 のいずれかである必要があります", s.Driver))
+	}
+	// S3 の署名付き URL は最長 7 日間です。
+	if s.URLTTL < time.Minute || s.URLTTL > 7*24*time.Hour {
+		errs = append(errs, errors.New("STORAGE_URL_TTL は 1 分以上 7 日以下である必要があります"))
+	}
+	return errs
+}
+
+// validate はアバター画像の制限を検証します。
+func (a *AvatarConfig) validate() []error {
+	var errs []error
+	if a.MaxBytes < 1<<10 || a.MaxBytes > 50<<20 {
+		errs = append(errs, fmt.Errorf("AVATAR_MAX_BYTES %d は 1KiB〜50MiB の範囲である必要があります", a.MaxBytes))
+	}
+	if a.MaxDimension < 256 || a.MaxDimension > 16384 {
+		errs = append(errs, fmt.Errorf("AVATAR_MAX_DIMENSION %d は 256〜16384 の範囲である必要があります", a.MaxDimension))
+	}
+	return errs
+}
+
+// validate はユーザー名の予約と変更の制限を検証します。
+func (u *UsernameConfig) validate() []error {
+	var errs []error
+	for _, name := range u.Reserved {
+		if strings.TrimSpace(name) == "" {
+			errs = append(errs, errors.New("USERNAME_RESERVED に空のユーザー名を含めることはできません"))
+			break
+		}
+	}
+	if u.ChangeInterval < 0 || u.ChangeInterval > 365*24*time.Hour {
+		errs = append(errs, errors.New("USERNAME_CHANGE_INTERVAL は 0〜365 日の範囲である必要があります"))
+	}
+	if u.HoldPeriod < 0 || u.HoldPeriod > 3*365*24*time.Hour {
+		errs = append(errs, errors.New("USERNAME_HOLD_PERIOD は 0〜3 年の範囲である必要があります"))
+	}
+	return errs
+}
+
+// validate は新規登録の設定を検証します。
+func (r *RegistrationConfig) validate() []error {
+	var errs []error
+	switch r.Mode {
+	case RegistrationModeOpen, RegistrationModeInviteOnly, RegistrationModeClosed:
+	default:
+		errs = append(errs, fmt.Errorf("REGISTRATION_MODE %q は open / invite_only / closed のいずれかである必要があります", r.Mode))
+	}
+	if r.InviteTTL <= 0 || r.InviteTTL > 365*24*time.Hour {
+		errs = append(errs, errors.New("REGISTRATION_INVITE_TTL は 365 日以下の正の期間である必要があります"))
+	}
+	return errs
+}
+
+// Redacted は秘密情報を伏せ字にした設定のコピーを返します。
+func (c Config) Redacted() Config {
+	for _, f := range fields(&c) {
+		switch f.tag.Get("secret") {
+		case "true":
+			redact(f.value)
+		case "dsn":
+			if f.value.String() == "" {
+				continue
+			}
+			dsn, err := mysql.ParseDSN(f.value.String())
+			if err != nil {
+				f.value.SetString("[REDACTED]")
+				continue
+			}
+			if dsn.Passwd != "" {
+				dsn.Passwd = "REDACTED"
+			}
+			f.value.SetString(dsn.FormatDSN())
+		}
+	}
+	return c
+}
+
+// redact は空でないフィールドの値を伏せ字に置き換えます。
+func redact(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.String:
+		if v.String() != "" {
+			v.SetString("[REDACTED]")
+		}
+	case reflect.Slice:
+		if v.Len() > 0 {
+			v.Set(reflect.ValueOf([]string{"[REDACTED]"}))
+		}
+	}
+}
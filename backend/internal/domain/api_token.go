@@ -0,0 +1,20 @@
+package domain
+
+import (
+	"database/sql"
+	"time"
+)
+
+// APIToken 结构体对应数据库中的 api_tokens 表 (个人访问令牌)
+type APIToken struct {
+	ID         int64
+	UserID     int64
+	Name       string
+	Prefix     string // 表示用の接頭辞 (例: "yut_ab12cd34")
+	TokenHash  string
+	Scopes     []string
+	ExpiresAt  sql.NullTime
+	LastUsedAt sql.NullTime
+	CreatedAt  time.Time
+	RevokedAt  sql.NullTime
+}
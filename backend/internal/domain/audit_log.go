@@ -0,0 +1,32 @@
+package domain
+
+import (
+	"database/sql"
+	"time"
+)
+
+// AuditLog 结构体对应数据库中的 audit_logs 表 (审计日志, 只追加)
+type AuditLog struct {
+	ID           int64
+	OccurredAt   time.Time
+	Event        string
+	Outcome      string
+	ActorUserID  sql.NullInt64
+	TargetUserID sql.NullInt64
+	IPAddress    string
+	UserAgent    string
+	RequestID    string
+	Details      string // JSON
+	PrevHash     string // 直前のエントリーの EntryHash (哈希链)
+	EntryHash    string
+}
+
+// AuditCheckpoint 结构体对应数据库中的 audit_checkpoints 表 (审计日志哈希链的签名检查点)
+type AuditCheckpoint struct {
+	ID        int64
+	LastLogID int64
+	LastHash  string
+	SignedAt  time.Time
+	KeyID     string
+	Signature string
+}
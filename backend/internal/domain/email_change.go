@@ -0,0 +1,23 @@
+package domain
+
+import (
+	"database/sql"
+	"time"
+)
+
+// EmailChange 结构体对应数据库中的 email_changes 表 (邮箱变更申请)
+type EmailChange struct {
+	ID               int64
+	UserID           int64
+	OldEmail         string
+	NewEmail         string
+	ConfirmTokenHash string
+	RevertTokenHash  string
+	IPAddress        string
+	ExpiresAt        time.Time
+	RevertExpiresAt  time.Time
+	CreatedAt        time.Time
+	ConfirmedAt      sql.NullTime
+	RevertedAt       sql.NullTime
+	CancelledAt      sql.NullTime
+}
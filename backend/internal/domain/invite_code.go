@@ -0,0 +1,27 @@
+package domain
+
+import (
+	"database/sql"
+	"time"
+)
+
+// InviteCode 结构体对应数据库中的 invite_codes 表 (注册邀请码)
+type InviteCode struct {
+	ID        int64
+	Prefix    string // 表示用の接頭辞 (例: "yui_ab12cd34")
+	CodeHash  string
+	CreatedBy int64  // 发行邀请码的管理员
+	Note      string // 管理员的备注
+	Role      string // 注册后的用户角色
+	Email     string // 为空时任何邮箱都可以注册
+	MaxUses   int
+	UseCount  int
+	ExpiresAt time.Time
+	CreatedAt time.Time
+	RevokedAt sql.NullTime
+}
+
+// Usable は失効・使い切り・期限切れのいずれでもなく、now の時点で登録に使用できるかどうかを返します。
+func (c *InviteCode) Usable(now time.Time) bool {
+	return !c.RevokedAt.Valid && c.UseCount < c.MaxUses && now.Before(c.ExpiresAt)
+}
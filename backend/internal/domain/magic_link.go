@@ -0,0 +1,17 @@
+package domain
+
+import (
+	"database/sql"
+	"time"
+)
+
+// MagicLink 结构体对应数据库中的 magic_links 表 (无密码登录链接)
+type MagicLink struct {
+	ID         int64
+	UserID     int64
+	TokenHash  string
+	IPAddress  string
+	ExpiresAt  time.Time
+	CreatedAt  time.Time
+	ConsumedAt sql.NullTime
+}
@@ -0,0 +1,48 @@
+package domain
+
+import (
+	"database/sql"
+	"time"
+)
+
+// OAuthClient 结构体对应数据库中的 oauth_clients 表 (使用 YUTAKA 登录的客户端应用)
+type OAuthClient struct {
+	ID               int64
+	ClientID         string
+	ClientSecretHash string // 空の場合は公開クライアント
+	Name             string
+	RedirectURIs     []string
+	GrantTypes       []string
+	Scopes           []string
+	OwnerUserID      int64
+	CreatedAt        time.Time
+}
+
+// IsPublic はクライアントシークレットを持たない公開クライアント (SPA・ネイティブアプリ) かどうかを返します。
+func (c *OAuthClient) IsPublic() bool {
+	return c.ClientSecretHash == ""
+}
+
+// OAuthAuthorizationCode 结构体对应数据库中的 oauth_authorization_codes 表
+type OAuthAuthorizationCode struct {
+	ID            int64
+	ClientID      string
+	UserID        int64
+	RedirectURI   string
+	Scope         string
+	Nonce         string
+	CodeChallenge string
+	AuthTime      sql.NullTime // 同意时所用会话的登录时间 (ID 令牌的 auth_time)
+	ExpiresAt     time.Time
+	CreatedAt     time.Time
+}
+
+// OAuthRefreshToken 结构体对应数据库中的 oauth_refresh_tokens 表
+type OAuthRefreshToken struct {
+	ID        int64
+	ClientID  string
+	UserID    int64
+	Scope     string
+	ExpiresAt time.Time
+	RevokedAt sql.NullTime
+}
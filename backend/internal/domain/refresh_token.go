@@ -0,0 +1,17 @@
+package domain
+
+import (
+	"database/sql"
+	"time"
+)
+
+// RefreshToken 结构体对应数据库中的 refresh_tokens 表
+type RefreshToken struct {
+	ID        int64
+	UserID    int64
+	SessionID int64
+	TokenHash string // トークンの SHA-256 ハッシュ (平文は保存しない)
+	ExpiresAt time.Time
+	CreatedAt time.Time
+	RevokedAt sql.NullTime
+}
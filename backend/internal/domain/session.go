@@ -0,0 +1,21 @@
+package domain
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Session 结构体对应数据库中的 sessions 表 (一次登录 = 一个会话)
+type Session struct {
+	ID         int64
+	UserID     int64
+	UserAgent  string
+	IPAddress  string
+	CreatedAt  time.Time
+	LastSeenAt time.Time
+	RevokedAt  sql.NullTime
+
+	// 管理员代理登录 (冒充) 的会话
+	ImpersonatorUserID sql.NullInt64 // 代理登录的管理员 (普通登录则为 NULL)
+	ExpiresAt          sql.NullTime  // 会话的有效期 (普通登录则为 NULL)
+}
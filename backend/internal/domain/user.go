@@ -2,16 +2,43 @@ package domain
 
 import (
 	"database/sql"
+	"errors"
 	"time"
 )
 
 // User 结构体对应数据库中的 users 表
 type User struct {
-	ID        int64 // 用 int64 对应数据库的 INT 或 BIGINT 主键
-	Username  string
-	Password  string
-	Email     string
-	CreatedAt time.Time // DATETIME 也能被 parseTime=True 解析为 time.Time
-	UpdatedAt time.Time
-	DeletedAt sql.NullTime
-}
\ No newline at end of file
+	ID              int64 // 用 int64 对应数据库的 INT 或 BIGINT 主键
+	Username        string
+	Password        string
+	Email           string
+	Role            string    // "user" 或 "admin"
+	CreatedAt       time.Time // DATETIME 也能被 parseTime=True 解析为 time.Time
+	UpdatedAt       time.Time
+	DeletedAt       sql.NullTime
+	EmailVerifiedAt sql.NullTime // 邮箱验证时间 (未验证则为 NULL)
+
+	// 个人资料 (未设置则为空字符串)
+	DisplayName string
+	Bio         string
+	Locale      string // BCP 47 语言标签, 例: "ja-JP"
+	Timezone    string // IANA 时区名, 例: "Asia/Tokyo"
+	AvatarKey   string // 头像在存储中的键 (未设置则为空字符串)
+
+	// 管理
+	SuspendedAt            sql.NullTime // 被管理员停用的时间 (未停用则为 NULL)
+	SuspensionReason       string       // 停用原因 (仅管理员可见)
+	PasswordChangeRequired bool         // 下次登录时必须修改密码
+}
+
+// MarshalJSON は常にエラーを返します。
+// User はパスワードのハッシュなどを含むため、レスポンスにはビューごとの構造体に写してから返してください。
+func (User) MarshalJSON() ([]byte, error) {
+	return nil, errors.New("domain.User must not be serialized directly")
+}
+
+// 用户角色
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
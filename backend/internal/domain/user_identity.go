@@ -0,0 +1,26 @@
+package domain
+
+import (
+	"database/sql"
+	"time"
+)
+
+// UserIdentity 结构体对应数据库中的 user_identities 表 (外部认证提供方的账号关联)
+type UserIdentity struct {
+	ID        int64
+	UserID    int64
+	Provider  string // "google" / "github" / 設定した OIDC プロバイダー名
+	Subject   string // プロバイダー内の不変のユーザーID
+	Email     string
+	CreatedAt time.Time
+}
+
+// OAuthState 结构体对应数据库中的 oauth_states 表
+type OAuthState struct {
+	ID           int64
+	Provider     string
+	CodeVerifier string
+	Nonce        string
+	LinkUserID   sql.NullInt64 // アカウント連携の場合は連携先のユーザーID
+	ExpiresAt    time.Time
+}
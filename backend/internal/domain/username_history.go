@@ -0,0 +1,12 @@
+package domain
+
+import "time"
+
+// UsernameHistory 结构体对应数据库中的 username_history 表 (用户名变更历史)
+type UsernameHistory struct {
+	ID        int64
+	UserID    int64
+	Username  string // 变更前的用户名
+	ChangedAt time.Time
+	HeldUntil time.Time // 在此之前其他用户不能使用该用户名
+}
@@ -0,0 +1,33 @@
+package domain
+
+import (
+	"database/sql"
+	"time"
+)
+
+// WebAuthnCredential 结构体对应数据库中的 webauthn_credentials 表 (通行密钥)
+type WebAuthnCredential struct {
+	ID             int64
+	UserID         int64
+	CredentialID   []byte
+	PublicKey      []byte // COSE_Key 形式
+	Algorithm      int64
+	SignCount      uint32
+	AAGUID         []byte
+	Transports     []string
+	BackupEligible bool
+	BackedUp       bool
+	Name           string
+	CreatedAt      time.Time
+	LastUsedAt     sql.NullTime
+}
+
+// WebAuthnChallenge 结构体对应数据库中的 webauthn_challenges 表
+type WebAuthnChallenge struct {
+	ID            int64
+	ChallengeHash string
+	UserID        sql.NullInt64
+	Ceremony      string // registration / login
+	ExpiresAt     time.Time
+	CreatedAt     time.Time
+}
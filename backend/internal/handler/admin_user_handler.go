@@ -0,0 +1,116 @@
+// backend/internal/handler/admin_user_handler.go
+package handler
+
+import (
+	"backend/internal/service"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SuspendUserRequest はユーザー停止APIのリクエストボディです。
+type SuspendUserRequest struct {
+	Reason string `json:"reason" binding:"max=255"` // 管理者のみが参照する停止の理由
+}
+
+// HandleSuspendUser はユーザーを停止し、すべての端末からログアウトさせます (管理者用)。
+func HandleSuspendUser(c *gin.Context) {
+	adminID, userID, ok := adminTarget(c)
+	if !ok {
+		return
+	}
+
+	var req SuspendUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "入力内容が正しくありません。", "details": err.Error()})
+		return
+	}
+
+	if err := service.SuspendUser(adminID, userID, req.Reason, clientInfo(c)); err != nil {
+		respondAdminUserError(c, err, "ユーザーの停止に失敗しました。")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "ユーザーを停止しました。"})
+}
+
+// HandleUnsuspendUser はユーザーの停止を解除します (管理者用)。
+func HandleUnsuspendUser(c *gin.Context) {
+	adminID, userID, ok := adminTarget(c)
+	if !ok {
+		return
+	}
+
+	if err := service.UnsuspendUser(adminID, userID, clientInfo(c)); err != nil {
+		respondAdminUserError(c, err, "ユーザーの停止の解除に失敗しました。")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "ユーザーの停止を解除しました。"})
+}
+
+// HandleRequirePasswordChange はユーザーに次回ログイン時のパスワード変更を求めます (管理者用)。
+func HandleRequirePasswordChange(c *gin.Context) {
+	adminID, userID, ok := adminTarget(c)
+	if !ok {
+		return
+	}
+
+	if err := service.RequirePasswordChange(adminID, userID, clientInfo(c)); err != nil {
+		respondAdminUserError(c, err, "パスワードの変更の要求に失敗しました。")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "次回ログイン時にパスワードの変更を求めます。"})
+}
+
+// HandleImpersonateUser は管理者がユーザーとして操作するための代理ログインのトークンを発行します (管理者用)。
+// 管理者自身のセッションを置き換えないよう、Cookie セッションモードでもトークンはレスポンスボディで返します。
+func HandleImpersonateUser(c *gin.Context) {
+	adminID, userID, ok := adminTarget(c)
+	if !ok {
+		return
+	}
+
+	imp, err := service.StartImpersonation(adminID, userID, clientInfo(c))
+	if err != nil {
+		respondAdminUserError(c, err, "代理ログインに失敗しました。")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "代理ログインを開始しました。",
+		"token":      imp.AccessToken,
+		"session_id": imp.SessionID,
+		"expires_at": imp.ExpiresAt,
+	})
+}
+
+// adminTarget は操作している管理者のユーザーIDと、パスの :id の対象ユーザーIDを取得します。
+// 取得できなかった場合はレスポンスを返して ok に false を返します。
+func adminTarget(c *gin.Context) (adminID int64, userID int64, ok bool) {
+	adminID, ok = currentUserID(c)
+	if !ok {
+		return 0, 0, false
+	}
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ユーザーIDの形式が正しくありません。"})
+		return 0, 0, false
+	}
+	return adminID, userID, true
+}
+
+// respondAdminUserError は管理者用のユーザー操作のエラーを適切なステータスコードに変換して返します。
+func respondAdminUserError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrCannotTargetSelf), errors.Is(err, service.ErrNoPassword):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrCannotImpersonate):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrAlreadySuspended), errors.Is(err, service.ErrNotSuspended), errors.Is(err, service.ErrAccountSuspended):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
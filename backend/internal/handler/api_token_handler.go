@@ -0,0 +1,122 @@
+// backend/internal/handler/api_token_handler.go
+package handler
+
+import (
+	"backend/internal/domain"
+	"backend/internal/service"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateAPITokenRequest はアクセストークン発行APIのリクエストボディです。
+type CreateAPITokenRequest struct {
+	Name      string     `json:"name" binding:"required,max=100"`
+	Scopes    []string   `json:"scopes"`     // 省略時は read のみ
+	ExpiresAt *time.Time `json:"expires_at"` // 省略時は無期限
+}
+
+// APITokenResponse はアクセストークン1件分のレスポンスです。
+type APITokenResponse struct {
+	ID         int64      `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Token      string     `json:"token,omitempty"` // 発行直後のみ返されます
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// HandleCreateAPIToken は認証済みユーザーの個人用アクセストークンを発行します。
+func HandleCreateAPIToken(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req CreateAPITokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "入力内容が正しくありません。", "details": err.Error()})
+		return
+	}
+
+	t, token, err := service.CreateAPIToken(userID, req.Name, req.Scopes, req.ExpiresAt)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidAPITokenScope) || errors.Is(err, service.ErrInvalidAPITokenExpiry) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "アクセストークンの発行に失敗しました。"})
+		return
+	}
+
+	res := apiTokenResponse(t)
+	res.Token = token
+	c.JSON(http.StatusCreated, res)
+}
+
+// HandleListAPITokens は認証済みユーザーの有効なアクセストークンの一覧を返します。
+// トークン本体は返さず、識別用の接頭辞のみを返します。
+func HandleListAPITokens(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	tokens, err := service.ListAPITokens(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "アクセストークンの取得に失敗しました。"})
+		return
+	}
+
+	res := make([]APITokenResponse, 0, len(tokens))
+	for i := range tokens {
+		res = append(res, apiTokenResponse(&tokens[i]))
+	}
+	c.JSON(http.StatusOK, gin.H{"tokens": res})
+}
+
+// HandleRevokeAPIToken は指定したアクセストークンを失効させます。
+func HandleRevokeAPIToken(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	tokenID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid token ID format"})
+		return
+	}
+
+	if err := service.RevokeAPIToken(userID, tokenID); err != nil {
+		if errors.Is(err, service.ErrAPITokenNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "アクセストークンの失効に失敗しました。"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "アクセストークンを失効させました。"})
+}
+
+func apiTokenResponse(t *domain.APIToken) APITokenResponse {
+	res := APITokenResponse{
+		ID:        t.ID,
+		Name:      t.Name,
+		Prefix:    t.Prefix,
+		Scopes:    t.Scopes,
+		CreatedAt: t.CreatedAt,
+	}
+	if t.ExpiresAt.Valid {
+		res.ExpiresAt = &t.ExpiresAt.Time
+	}
+	if t.LastUsedAt.Valid {
+		res.LastUsedAt = &t.LastUsedAt.Time
+	}
+	return res
+}
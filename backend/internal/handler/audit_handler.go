@@ -0,0 +1,141 @@
+// backend/internal/handler/audit_handler.go
+package handler
+
+import (
+	"backend/internal/domain"
+	"backend/internal/repository"
+	"backend/internal/service"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AuditLogQuery は監査ログ検索APIのクエリパラメーターです。
+type AuditLogQuery struct {
+	Event        string    `form:"event"`
+	Outcome      string    `form:"outcome" binding:"omitempty,oneof=success failure"`
+	ActorUserID  int64     `form:"actor_id"`
+	TargetUserID int64     `form:"target_id"`
+	UserID       int64     `form:"user_id"` // actor_id または target_id のどちらかが一致するもの
+	IPAddress    string    `form:"ip"`
+	From         time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To           time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Cursor       string    `form:"cursor"`
+	Limit        int       `form:"limit" binding:"omitempty,min=1,max=200"`
+}
+
+// ActivityQuery は自分の操作履歴APIのクエリパラメーターです。
+type ActivityQuery struct {
+	Cursor string `form:"cursor"`
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=200"`
+}
+
+// AuditLogResponse は監査ログ1件分のレスポンスです。
+type AuditLogResponse struct {
+	ID           int64           `json:"id"`
+	OccurredAt   time.Time       `json:"occurred_at"`
+	Event        string          `json:"event"`
+	Outcome      string          `json:"outcome"`
+	ActorUserID  *int64          `json:"actor_user_id"`
+	TargetUserID *int64          `json:"target_user_id"`
+	IPAddress    string          `json:"ip_address"`
+	UserAgent    string          `json:"user_agent"`
+	RequestID    string          `json:"request_id"`
+	Details      json.RawMessage `json:"details,omitempty"`
+}
+
+// HandleListAuditLogs は条件に一致する監査ログを新しい順に返します (管理者用)。
+func HandleListAuditLogs(c *gin.Context) {
+	var q AuditLogQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "検索条件が正しくありません。", "details": err.Error()})
+		return
+	}
+
+	filter := repository.AuditLogFilter{
+		Event:         q.Event,
+		Outcome:       q.Outcome,
+		ActorUserID:   q.ActorUserID,
+		TargetUserID:  q.TargetUserID,
+		SubjectUserID: q.UserID,
+		IPAddress:     q.IPAddress,
+		From:          q.From,
+		To:            q.To,
+	}
+	logs, next, err := service.ListAuditLogs(filter, q.Cursor, q.Limit)
+	respondAuditLogs(c, logs, next, err)
+}
+
+// HandleListMyActivity は認証済みユーザーが操作した、または対象となった監査ログを新しい順に返します。
+func HandleListMyActivity(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var q ActivityQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "検索条件が正しくありません。", "details": err.Error()})
+		return
+	}
+
+	logs, next, err := service.ListUserActivity(userID, q.Cursor, q.Limit)
+	respondAuditLogs(c, logs, next, err)
+}
+
+// respondAuditLogs は監査ログの1ページ分と次のページのカーソルを返します。
+func respondAuditLogs(c *gin.Context, logs []domain.AuditLog, next string, err error) {
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCursor) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "監査ログの取得に失敗しました。"})
+		return
+	}
+
+	res := make([]AuditLogResponse, 0, len(logs))
+	for _, l := range logs {
+		entry := AuditLogResponse{
+			ID:         l.ID,
+			OccurredAt: l.OccurredAt,
+			Event:      l.Event,
+			Outcome:    l.Outcome,
+			IPAddress:  l.IPAddress,
+			UserAgent:  l.UserAgent,
+			RequestID:  l.RequestID,
+		}
+		if l.ActorUserID.Valid {
+			entry.ActorUserID = &l.ActorUserID.Int64
+		}
+		if l.TargetUserID.Valid {
+			entry.TargetUserID = &l.TargetUserID.Int64
+		}
+		if l.Details != "" {
+			entry.Details = json.RawMessage(l.Details)
+		}
+		res = append(res, entry)
+	}
+
+	body := gin.H{"entries": res, "next_cursor": nil}
+	if next != "" {
+		body["next_cursor"] = next
+	}
+	c.JSON(http.StatusOK, body)
+}
+
+// auditActor は監査ログに記録する操作者のユーザーIDを返します。未ログインの場合は 0 です。
+func auditActor(c *gin.Context) int64 {
+	return c.GetInt64("userID")
+}
+
+// auditOutcome はエラーの有無から監査ログの結果を返します。
+func auditOutcome(err error) string {
+	if err != nil {
+		return service.AuditOutcomeFailure
+	}
+	return service.AuditOutcomeSuccess
+}
@@ -2,15 +2,20 @@
 package handler
 
 import (
+	"backend/internal/config"
+	"backend/internal/handler/middleware"
 	"backend/internal/service"
+	"errors"
 	"github.com/gin-gonic/gin"
 	"net/http"
 )
 
 // LoginRequest はログインAPIのリクエストボディを定義します。
+// Identifier にはユーザー名またはメールアドレスを指定します。Username は以前のクライアントとの互換性のために残しています。
 type LoginRequest struct {
-	Username string `json:"username" binding:"required"`
-	Password string `json:"password" binding:"required"`
+	Identifier string `json:"identifier" binding:"required_without=Username"`
+	Username   string `json:"username"`
+	Password   string `json:"password" binding:"required"`
 }
 
 // HandleLogin はログインリクエストを処理します。
@@ -20,31 +25,120 @@ func HandleLogin(c *gin.Context) {
 	// リクエストボディを構造体にバインドし、バリデーションします。
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "ユーザー名とパスワードを入力してください。",
+			"error":   "ユーザー名またはメールアドレスとパスワードを入力してください。",
 			"details": err.Error(),
 		})
 		return
 	}
 
 	// 認証サービスを呼び出します。
-	token, err := service.Login(req.Username, req.Password)
+	loginID := req.Identifier
+	if loginID == "" {
+		loginID = req.Username
+	}
+	pair, err := service.Login(loginID, req.Password, clientInfo(c))
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": err.Error(), 
-		})
+		if errors.Is(err, service.ErrInvalidCredentials) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, service.ErrAccountSuspended) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		// 内部エラーの詳細は返しません。
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ログインに失敗しました。"})
 		return
 	}
 
-	// 認証成功。JWTトークンを返します。
-	c.JSON(http.StatusOK, gin.H{
-		"message": "ログインに成功しました。",
-		"token":   token,
-	})
+	// 認証成功。設定に応じてトークンを返すか Cookie に保存します。
+	respondWithTokens(c, pair, "ログインに成功しました。")
+}
+
+// RefreshRequest はトークン更新APIのリクエストボディを定義します。
+// Cookie セッションモードでは本文を省略し、refresh_token Cookie を使用します。
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// HandleRefresh はリフレッシュトークンを使って新しいトークンを発行します。
+func HandleRefresh(c *gin.Context) {
+	refreshToken := refreshTokenFromRequest(c)
+	if refreshToken == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "リフレッシュトークンが必要です。"})
+		return
+	}
+
+	pair, err := service.RefreshTokens(refreshToken)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidRefreshToken) {
+			clearSessionCookies(c)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "トークンの更新に失敗しました。"})
+		return
+	}
+
+	respondWithTokens(c, pair, "トークンを更新しました。")
+}
+
+// HandleLogout はリフレッシュトークンを失効させ、セッション Cookie を削除します。
+func HandleLogout(c *gin.Context) {
+	if refreshToken := refreshTokenFromRequest(c); refreshToken != "" {
+		if err := service.Logout(refreshToken); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "ログアウトに失敗しました。"})
+			return
+		}
+	}
+
+	clearSessionCookies(c)
+	c.JSON(http.StatusOK, gin.H{"message": "ログアウトしました。"})
+}
+
+// respondWithTokens は Cookie セッションモードでは Cookie を設定して CSRF トークンを、
+// Bearer モードではトークンをレスポンスボディで返します。
+// 管理者にパスワードの変更を求められている場合は password_change_required も返します。
+func respondWithTokens(c *gin.Context, pair *service.TokenPair, message string) {
+	var body gin.H
+	if config.AppConfig.Session.Mode == config.SessionModeCookie {
+		csrfToken, err := setSessionCookies(c, pair)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "セッションの作成に失敗しました。"})
+			return
+		}
+		body = gin.H{
+			"message":    message,
+			"csrf_token": csrfToken,
+		}
+	} else {
+		body = gin.H{
+			"message":       message,
+			"token":         pair.AccessToken,
+			"refresh_token": pair.RefreshToken,
+		}
+	}
+	if pair.PasswordChangeRequired {
+		body["password_change_required"] = true
+	}
+	c.JSON(http.StatusOK, body)
+}
+
+// refreshTokenFromRequest はリクエストボディ、なければ Cookie からリフレッシュトークンを取り出します。
+func refreshTokenFromRequest(c *gin.Context) string {
+	var req RefreshRequest
+	if err := c.ShouldBindJSON(&req); err == nil && req.RefreshToken != "" {
+		return req.RefreshToken
+	}
+	if cookie, err := c.Cookie(middleware.RefreshTokenCookie); err == nil {
+		return cookie
+	}
+	return ""
 }
 
 type ChangePasswordRequest struct {
 	CurrentPassword string `json:"currentPassword" binding:"required"`
-	NewPassword string `json:"newPassword" binding:"required,min=8"`
+	NewPassword string `json:"newPassword" binding:"required"` // 長さなどの条件はパスワードポリシーで確認します
 }
 
 // HandleChangePassword は認証済みユーザーのパスワード変更リクエストを処理します。
@@ -77,7 +171,10 @@ func HandleChangePassword(c *gin.Context) {
 	}
 
 	// 認証サービスレイヤーの ChangePassword 関数を呼び出します。
-	err := service.ChangePassword(userID, req.CurrentPassword, req.NewPassword)
+	err := service.ChangePassword(userID, req.CurrentPassword, req.NewPassword, clientInfo(c))
+	if respondPasswordPolicyError(c, err) {
+		return
+	}
 	if err != nil {
 		// サービス層から返されたエラー
 		// クライアントに起因するエラーなので、400 Bad Request を返すのが適切です。
@@ -92,3 +189,17 @@ func HandleChangePassword(c *gin.Context) {
 		"message": "パスワードが正常に更新されました。",
 	})
 }
+
+// respondPasswordPolicyError はエラーがパスワードポリシー違反の場合に、満たしていない条件の一覧を
+// 400 で返して true を返します。
+func respondPasswordPolicyError(c *gin.Context, err error) bool {
+	var policyErr *service.PasswordPolicyError
+	if !errors.As(err, &policyErr) {
+		return false
+	}
+	c.JSON(http.StatusBadRequest, gin.H{
+		"error":      "パスワードが条件を満たしていません。",
+		"violations": policyErr.Violations,
+	})
+	return true
+}
@@ -0,0 +1,124 @@
+// backend/internal/handler/avatar_handler.go
+package handler
+
+import (
+	"backend/internal/config"
+	"backend/internal/imaging"
+	"backend/internal/service"
+	"backend/internal/storage"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// multipartOverhead はアップロードのリクエストボディのうち、ファイル以外の部分 (境界やヘッダー) の上限です。
+const multipartOverhead = 64 << 10
+
+// HandleUpdateAvatar は multipart/form-data の "avatar" で送られた画像を認証済みユーザーのアバターにします。
+// 画像の形式はファイルの中身から判定し、サイズごとに縮小した画像を保存します。
+func HandleUpdateAvatar(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	maxBytes := int64(config.AppConfig.Avatar.MaxBytes)
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes+multipartOverhead)
+	file, err := c.FormFile("avatar")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": service.ErrAvatarTooLarge.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "multipart/form-data の avatar に画像を指定してください。"})
+		return
+	}
+	if file.Size > maxBytes {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": service.ErrAvatarTooLarge.Error()})
+		return
+	}
+	f, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "画像を読み込めません。"})
+		return
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, maxBytes+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "画像を読み込めません。"})
+		return
+	}
+
+	user, err := service.UpdateAvatar(userID, data, clientInfo(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrAvatarTooLarge):
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+		case errors.Is(err, imaging.ErrUnsupportedFormat):
+			c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+		case errors.Is(err, imaging.ErrTooLarge), errors.Is(err, imaging.ErrInvalidImage):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrUserNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "アバター画像の保存に失敗しました。"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, newSelfUserResponse(user))
+}
+
+// HandleDeleteAvatar は認証済みユーザーのアバター画像を削除します。
+func HandleDeleteAvatar(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	user, err := service.DeleteAvatar(userID, clientInfo(c))
+	if err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "アバター画像の削除に失敗しました。"})
+		return
+	}
+	c.JSON(http.StatusOK, newSelfUserResponse(user))
+}
+
+// HandleGetBlob は保存先が local の場合に、署名付き URL のファイルを配信します。
+//
+//	GET /api/blobs/<key>?expires=<UNIX 時刻>&sig=<署名>
+func HandleGetBlob(c *gin.Context) {
+	local, ok := storage.Local()
+	if !ok {
+		c.Status(http.StatusNotFound)
+		return
+	}
+
+	expires := c.Query("expires")
+	path, err := local.Open(strings.TrimPrefix(c.Param("key"), "/"), expires, c.Query("sig"))
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	if _, err := os.Stat(path); err != nil {
+		c.Status(http.StatusNotFound)
+		return
+	}
+
+	// URL の有効期限まではブラウザーにキャッシュさせます。
+	exp, _ := strconv.ParseInt(expires, 10, 64)
+	maxAge := max(int64(time.Until(time.Unix(exp, 0))/time.Second), 0)
+	c.Header("Cache-Control", "private, max-age="+strconv.FormatInt(maxAge, 10))
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.File(path)
+}
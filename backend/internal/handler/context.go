@@ -0,0 +1,35 @@
+// backend/internal/handler/context.go
+package handler
+
+import (
+	"backend/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// currentUserID は JWTMiddleware がコンテキストに設定したユーザーIDを取得します。
+// 取得できない場合は 500 エラーを返し、ok に false を返します。
+func currentUserID(c *gin.Context) (int64, bool) {
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "サーバー内部でユーザー情報が見つかりませんでした。"})
+		return 0, false
+	}
+	userID, ok := userIDAny.(int64)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "サーバー内部でユーザーIDの形式が不正です。"})
+		return 0, false
+	}
+	return userID, true
+}
+
+// clientInfo はリクエスト元の端末情報を取得します。
+func clientInfo(c *gin.Context) service.ClientInfo {
+	return service.ClientInfo{
+		UserAgent:          c.Request.UserAgent(),
+		IPAddress:          c.ClientIP(),
+		RequestID:          c.GetString("requestID"),
+		ImpersonatorUserID: c.GetInt64("impersonatorID"),
+	}
+}
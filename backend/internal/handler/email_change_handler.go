@@ -0,0 +1,103 @@
+// backend/internal/handler/email_change_handler.go
+package handler
+
+import (
+	"backend/internal/identifier"
+	"backend/internal/service"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EmailChangeRequest はメールアドレス変更の申請APIのリクエストボディです。
+type EmailChangeRequest struct {
+	NewEmail        string `json:"new_email" binding:"required,email,max=255"`
+	CurrentPassword string `json:"current_password" binding:"required"`
+}
+
+// EmailChangeTokenRequest はメールアドレス変更の確認・取り消しAPIのリクエストボディです。
+type EmailChangeTokenRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// HandleRequestEmailChange は現在のパスワードを確認し、新しいアドレスに確認リンクを、現在のアドレスに通知を送信します。
+// メールアドレスは確認リンクが使われるまで変更されません。
+func HandleRequestEmailChange(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req EmailChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "入力内容が正しくありません。", "details": err.Error()})
+		return
+	}
+
+	err := service.RequestEmailChange(userID, req.NewEmail, req.CurrentPassword, clientInfo(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidCurrentPassword), errors.Is(err, service.ErrSameEmail), errors.Is(err, identifier.ErrInvalidEmail):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrEmailAlreadyRegistered):
+			c.JSON(http.StatusConflict, gin.H{"error": "このメールアドレスは既に登録されています。"})
+		case errors.Is(err, service.ErrUserNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "メールアドレスの変更の申請に失敗しました。"})
+		}
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"message": "新しいメールアドレスに確認用のリンクを送信しました。リンクを開くと変更が反映されます。"})
+}
+
+// HandleConfirmEmailChange は新しいアドレスに送られた確認リンクのトークンを検証し、メールアドレスを変更します。
+// リンクの先読みでトークンが消費されないよう、リンク先のページから POST で呼び出します。
+func HandleConfirmEmailChange(c *gin.Context) {
+	var req EmailChangeTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "トークンが必要です。", "details": err.Error()})
+		return
+	}
+
+	if err := service.ConfirmEmailChange(req.Token, clientInfo(c)); err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidEmailChange):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrEmailAlreadyRegistered):
+			c.JSON(http.StatusConflict, gin.H{"error": "このメールアドレスは既に登録されています。"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "メールアドレスの変更に失敗しました。"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "メールアドレスを変更しました。"})
+}
+
+// HandleRevertEmailChange は旧アドレスに送られた取り消しリンクのトークンを検証し、変更を取り消します。
+// 変更が反映済みの場合は元のアドレスに戻し、いずれの場合もすべての端末からログアウトさせます。
+func HandleRevertEmailChange(c *gin.Context) {
+	var req EmailChangeTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "トークンが必要です。", "details": err.Error()})
+		return
+	}
+
+	result, err := service.RevertEmailChange(req.Token, clientInfo(c))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidEmailChange) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "メールアドレスの変更の取り消しに失敗しました。"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message":              "メールアドレスの変更を取り消し、すべての端末からログアウトしました。パスワードを変更し、連携している外部アカウントとパスキーを確認してください。",
+		"restored":             result.Restored,
+		"revoked_sessions":     result.RevokedSessions,
+		"revoked_api_tokens":   result.RevokedAPITokens,
+		"revoked_oauth_tokens": result.RevokedOAuthTokens,
+	})
+}
@@ -0,0 +1,147 @@
+// backend/internal/handler/invite_code_handler.go
+package handler
+
+import (
+	"backend/internal/domain"
+	"backend/internal/identifier"
+	"backend/internal/service"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateInviteCodeRequest は招待コード発行APIのリクエストボディです。
+type CreateInviteCodeRequest struct {
+	MaxUses   int        `json:"max_uses" binding:"omitempty,min=1"` // 省略時は 1
+	ExpiresAt *time.Time `json:"expires_at"`                         // 省略時は REGISTRATION_INVITE_TTL 後
+	Role      string     `json:"role" binding:"omitempty,oneof=user admin"`
+	Email     string     `json:"email" binding:"omitempty,email"` // 指定した場合はこのメールアドレスでのみ登録できます
+	Note      string     `json:"note" binding:"max=255"`
+}
+
+// InviteCodeResponse は招待コード1件分のレスポンスです。
+type InviteCodeResponse struct {
+	ID        int64      `json:"id"`
+	Prefix    string     `json:"prefix"`
+	Code      string     `json:"code,omitempty"` // 発行直後のみ返されます
+	CreatedBy int64      `json:"created_by"`
+	Note      string     `json:"note"`
+	Role      string     `json:"role"`
+	Email     string     `json:"email"`
+	MaxUses   int        `json:"max_uses"`
+	UseCount  int        `json:"use_count"`
+	Active    bool       `json:"active"` // 登録に使用できる場合に true
+	ExpiresAt time.Time  `json:"expires_at"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+}
+
+// HandleGetRegistration は新規登録の受け付け方 (open / invite_only / closed) を返します。
+// フロントエンドが登録画面の表示や招待コードの入力欄を切り替えるために使用します。
+func HandleGetRegistration(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"mode": service.RegistrationMode()})
+}
+
+// HandleCreateInviteCode は新規登録用の招待コードを発行します (管理者用)。
+func HandleCreateInviteCode(c *gin.Context) {
+	adminID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req CreateInviteCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "入力内容が正しくありません。", "details": err.Error()})
+		return
+	}
+
+	invite, code, err := service.CreateInviteCode(adminID, service.InviteCodeOptions{
+		MaxUses:   req.MaxUses,
+		ExpiresAt: req.ExpiresAt,
+		Role:      req.Role,
+		Email:     req.Email,
+		Note:      req.Note,
+	}, clientInfo(c))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidInviteCodeOptions) || errors.Is(err, identifier.ErrInvalidEmail) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "招待コードの発行に失敗しました。"})
+		return
+	}
+
+	res := inviteCodeResponse(invite, time.Now())
+	res.Code = code
+	c.JSON(http.StatusCreated, res)
+}
+
+// HandleListInviteCodes は招待コードの一覧を返します (管理者用)。
+// status=active の場合は、まだ登録に使用できる招待コードのみを返します。
+func HandleListInviteCodes(c *gin.Context) {
+	status := c.DefaultQuery("status", "all")
+	if status != "all" && status != "active" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status には active または all を指定してください。"})
+		return
+	}
+
+	codes, err := service.ListInviteCodes(status == "active")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "招待コードの取得に失敗しました。"})
+		return
+	}
+
+	now := time.Now()
+	res := make([]InviteCodeResponse, 0, len(codes))
+	for i := range codes {
+		res = append(res, inviteCodeResponse(&codes[i], now))
+	}
+	c.JSON(http.StatusOK, gin.H{"invite_codes": res})
+}
+
+// HandleRevokeInviteCode は招待コードを失効させます (管理者用)。
+func HandleRevokeInviteCode(c *gin.Context) {
+	adminID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	inviteCodeID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "招待コードIDの形式が正しくありません。"})
+		return
+	}
+
+	if err := service.RevokeInviteCode(adminID, inviteCodeID, clientInfo(c)); err != nil {
+		if errors.Is(err, service.ErrInviteCodeNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "招待コードの失効に失敗しました。"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "招待コードを失効させました。"})
+}
+
+func inviteCodeResponse(c *domain.InviteCode, now time.Time) InviteCodeResponse {
+	res := InviteCodeResponse{
+		ID:        c.ID,
+		Prefix:    c.Prefix,
+		CreatedBy: c.CreatedBy,
+		Note:      c.Note,
+		Role:      c.Role,
+		Email:     c.Email,
+		MaxUses:   c.MaxUses,
+		UseCount:  c.UseCount,
+		Active:    c.Usable(now),
+		ExpiresAt: c.ExpiresAt,
+		CreatedAt: c.CreatedAt,
+	}
+	if c.RevokedAt.Valid {
+		res.RevokedAt = &c.RevokedAt.Time
+	}
+	return res
+}
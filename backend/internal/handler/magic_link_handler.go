@@ -0,0 +1,63 @@
+// backend/internal/handler/magic_link_handler.go
+package handler
+
+import (
+	"backend/internal/service"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MagicLinkRequest はログインリンク送信APIのリクエストボディです。
+// メールアドレスの形式はサービス層で正規化してから確認します (前後の空白や全角文字を含む入力もログインと同様に受け付けます)。
+type MagicLinkRequest struct {
+	Email string `json:"email" binding:"required,max=320"`
+}
+
+// MagicLinkConsumeRequest はログインリンクでのログインAPIのリクエストボディです。
+type MagicLinkConsumeRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// HandleRequestMagicLink はメールアドレス宛てにログインリンクを送信します。
+// 登録の有無に関わらず同じレスポンスを返します。
+func HandleRequestMagicLink(c *gin.Context) {
+	var req MagicLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "メールアドレスを入力してください。", "details": err.Error()})
+		return
+	}
+
+	if err := service.RequestMagicLink(req.Email, clientInfo(c)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ログインリンクの送信に失敗しました。"})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"message": "登録済みのメールアドレスの場合、ログイン用のリンクを送信しました。"})
+}
+
+// HandleConsumeMagicLink はログインリンクのトークンを検証し、通常のログインと同じトークンを発行します。
+// リンクの先読みでトークンが消費されないよう、リンク先のページから POST で呼び出します。
+func HandleConsumeMagicLink(c *gin.Context) {
+	var req MagicLinkConsumeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "トークンが必要です。", "details": err.Error()})
+		return
+	}
+
+	pair, err := service.ConsumeMagicLink(req.Token, clientInfo(c))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidMagicLink) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, service.ErrAccountSuspended) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ログインに失敗しました。"})
+		return
+	}
+
+	respondWithTokens(c, pair, "ログインに成功しました。")
+}
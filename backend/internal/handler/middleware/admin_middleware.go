@@ -0,0 +1,37 @@
+// backend/internal/handler/middleware/admin_middleware.go
+package middleware
+
+import (
+	"backend/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireAdmin は管理者のみがアクセスできるようにするミドルウェアです。JWTMiddleware の後に使用します。
+// 権限の変更がすぐに反映されるよう、トークンのクレームではなくデータベースのロールを確認します。
+// 個人用アクセストークンは読み取り専用でも全ユーザーのメールアドレスや監査ログを取得できてしまうため、管理者用のAPIには使用できません。
+func RequireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.GetInt64("userID")
+		if userID == 0 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "認証が必要です。"})
+			return
+		}
+		if _, viaAPIToken := c.Get("apiTokenID"); viaAPIToken {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "管理者用のAPIはアクセストークンでは使用できません。"})
+			return
+		}
+
+		isAdmin, err := service.IsAdmin(userID)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "権限の確認に失敗しました。"})
+			return
+		}
+		if !isAdmin {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "管理者権限が必要です。"})
+			return
+		}
+		c.Next()
+	}
+}
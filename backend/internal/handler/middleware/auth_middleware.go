@@ -3,31 +3,35 @@ package middleware
 
 import (
 	"backend/internal/auth"
+	"backend/internal/service"
+	"errors"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
-// JWTMiddleware はリクエストヘッダーからJWTを検証するミドルウェアです。
+// Cookie の名前です。Cookie セッションモードでログインした場合に使用されます。
+const (
+	AccessTokenCookie  = "access_token"
+	RefreshTokenCookie = "refresh_token"
+	CSRFTokenCookie    = "csrf_token"
+)
+
+// JWTMiddleware はリクエストヘッダーまたは Cookie からJWTを検証するミドルウェアです。
+// Authorization ヘッダーがある場合はそちらを優先します。
+// Bearer トークンが個人用アクセストークンの場合は authenticateAPIToken で認証します。
 func JWTMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// リクエストヘッダーから `Authorization` を取得します。
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			// ヘッダーが存在しない場合はエラー
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "認証ヘッダーが必要です。"})
+		tokenString, viaCookie, ok := extractToken(c)
+		if !ok {
 			return
 		}
 
-		// ヘッダーの形式が "Bearer <token>" であることを確認します。
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "認証ヘッダーの形式が正しくありません。"})
+		if !viaCookie && service.IsAPIToken(tokenString) {
+			authenticateAPIToken(c, tokenString)
 			return
 		}
-		
-		tokenString := parts[1]
 
 		// トークンを検証します。
 		claims, err := auth.ValidateToken(tokenString)
@@ -37,12 +41,137 @@ func JWTMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		// セッションが失効していないこと、ユーザーが停止されていないことを確認します (端末のログアウトに対応するため)。
+		var impersonatorID int64
+		if claims.Actor != nil {
+			impersonatorID = claims.Actor.UserID
+		}
+		user, err := service.ValidateSession(claims.UserID, claims.SessionID, impersonatorID, c.ClientIP())
+		if err != nil {
+			switch {
+			case errors.Is(err, service.ErrSessionRevoked):
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			case errors.Is(err, service.ErrAccountSuspended):
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			default:
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "セッションの確認に失敗しました。"})
+			}
+			return
+		}
+
+		// 管理者にパスワードの変更を求められている場合は、変更が済むまで他の操作を受け付けません。
+		// 代理ログイン中の管理者はパスワードを変更できないため対象外とします。
+		if user.PasswordChangeRequired && impersonatorID == 0 && !allowedBeforePasswordChange(c) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error":                    "パスワードの変更が必要です。",
+				"password_change_required": true,
+			})
+			return
+		}
+
 		// 検証成功。クレームからの情報を Gin のコンテキストに保存します。
 		// これにより、後続のハンドラでユーザー情報にアクセスできます。
 		c.Set("userID", claims.UserID)
 		c.Set("username", claims.Username)
+		c.Set("sessionID", claims.SessionID)
+		c.Set("authViaCookie", viaCookie)
+		if impersonatorID != 0 {
+			c.Set("impersonatorID", impersonatorID)
+		}
 
 		// 次のミドルウェアまたはハンドラに処理を渡します。
 		c.Next()
 	}
-}
\ No newline at end of file
+}
+
+// passwordChangeAllowedRoutes はパスワードの変更が求められている間も使用できるルートです ("<メソッド> <パス>")。
+var passwordChangeAllowedRoutes = map[string]bool{
+	"GET /api/me":                true,
+	"GET /api/users/me":          true,
+	"PUT /api/users/me/password": true,
+}
+
+// allowedBeforePasswordChange はパスワードの変更が求められている間も使用できるルートかどうかを返します。
+func allowedBeforePasswordChange(c *gin.Context) bool {
+	return passwordChangeAllowedRoutes[c.Request.Method+" "+c.FullPath()]
+}
+
+// extractToken は Authorization ヘッダー、なければ access_token Cookie からトークンを取り出します。
+// 取り出せなかった場合はレスポンスを返して処理を中断し、ok に false を返します。
+func extractToken(c *gin.Context) (token string, viaCookie bool, ok bool) {
+	// リクエストヘッダーから `Authorization` を取得します。
+	authHeader := c.GetHeader("Authorization")
+	if authHeader == "" {
+		if cookie, err := c.Cookie(AccessTokenCookie); err == nil && cookie != "" {
+			return cookie, true, true
+		}
+		// ヘッダーも Cookie も存在しない場合はエラー
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "認証ヘッダーが必要です。"})
+		return "", false, false
+	}
+
+	// ヘッダーの形式が "Bearer <token>" であることを確認します。
+	parts := strings.Split(authHeader, " ")
+	if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "認証ヘッダーの形式が正しくありません。"})
+		return "", false, false
+	}
+	return parts[1], false, true
+}
+
+// authenticateAPIToken は個人用アクセストークンを検証し、スコープで許可されたメソッドであれば後続の処理に進みます。
+func authenticateAPIToken(c *gin.Context, tokenString string) {
+	token, user, err := service.AuthenticateAPIToken(tokenString)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidAPIToken) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, service.ErrAccountSuspended) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "アクセストークンの確認に失敗しました。"})
+		return
+	}
+
+	// パスワードの変更を求められている間は、発行済みのアクセストークンもログインセッションと同様に制限します。
+	if user.PasswordChangeRequired && !allowedBeforePasswordChange(c) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"error":                    "パスワードの変更が必要です。",
+			"password_change_required": true,
+		})
+		return
+	}
+
+	if !service.APITokenAllows(token, c.Request.Method) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "アクセストークンのスコープが不足しています。"})
+		return
+	}
+
+	c.Set("userID", user.ID)
+	c.Set("username", user.Username)
+	c.Set("sessionID", int64(0))
+	c.Set("authViaCookie", false)
+	c.Set("apiTokenID", token.ID)
+
+	c.Next()
+}
+
+// SessionOnly は個人用アクセストークンと、管理者による代理ログインでの利用を禁止するミドルウェアです。
+// トークンの発行やパスワード変更など、漏洩したトークンで権限を広げられる操作や、本人しか行うべきでない操作に使用します。
+// 外部アカウントの連携や OAuth クライアントへの同意など、パスワードなしのログイン手段や長期間有効なトークンを
+// 増やす操作もこれにあたります。代理ログインや漏洩したトークンを、期限のない別のログイン手段に引き継がせないためです。
+func SessionOnly() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, viaAPIToken := c.Get("apiTokenID"); viaAPIToken {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "この操作はアクセストークンでは実行できません。"})
+			return
+		}
+		if _, impersonating := c.Get("impersonatorID"); impersonating {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "この操作は代理ログイン中には実行できません。"})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,105 @@
+// backend/internal/handler/middleware/cors_middleware.go
+package middleware
+
+import (
+	"backend/internal/config"
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+// originPattern は許可するオリジンの1つのパターンです。
+type originPattern struct {
+	scheme string
+	host   string // ワイルドカードの場合は "*." を除いたサフィックス
+	port   string
+	suffix bool // true の場合 "*.host" のサブドメインに一致します
+}
+
+// CORSMiddleware は設定に基づいて CORS ヘッダーを付与するミドルウェアです。
+// devMode が true の場合、任意の localhost オリジンも許可します。
+func CORSMiddleware(cfg config.CORSConfig, devMode bool) gin.HandlerFunc {
+	allowAll := false
+	var patterns []originPattern
+	for _, origin := range cfg.AllowedOrigins {
+		if origin == "*" {
+			allowAll = true
+			continue
+		}
+		if p, ok := parseOriginPattern(origin); ok {
+			patterns = append(patterns, p)
+		}
+	}
+
+	corsConfig := cors.Config{
+		AllowMethods:     cfg.AllowedMethods,
+		AllowHeaders:     cfg.AllowedHeaders,
+		ExposeHeaders:    cfg.ExposedHeaders,
+		AllowCredentials: cfg.AllowCredentials,
+		MaxAge:           cfg.MaxAge,
+	}
+	if allowAll {
+		corsConfig.AllowAllOrigins = true
+	} else {
+		corsConfig.AllowOriginFunc = func(origin string) bool {
+			return originAllowed(origin, patterns, devMode)
+		}
+	}
+	return cors.New(corsConfig)
+}
+
+// parseOriginPattern は "https://app.example.com" や "https://*.example.com:8443" を解析します。
+func parseOriginPattern(origin string) (originPattern, bool) {
+	u, err := url.Parse(strings.ToLower(origin))
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return originPattern{}, false
+	}
+	p := originPattern{scheme: u.Scheme, host: u.Hostname(), port: u.Port()}
+	if strings.HasPrefix(p.host, "*.") {
+		p.host = strings.TrimPrefix(p.host, "*")
+		p.suffix = true
+	}
+	return p, true
+}
+
+// originAllowed はリクエストの Origin がいずれかのパターンに一致するかを判定します。
+func originAllowed(origin string, patterns []originPattern, devMode bool) bool {
+	u, err := url.Parse(strings.ToLower(origin))
+	if err != nil || u.Host == "" {
+		return false
+	}
+	host, port := u.Hostname(), u.Port()
+
+	if devMode && isLocalhost(host) && (u.Scheme == "http" || u.Scheme == "https") {
+		return true
+	}
+
+	for _, p := range patterns {
+		if p.scheme != u.Scheme || p.port != port {
+			continue
+		}
+		if p.suffix {
+			// "*.example.com" は "a.example.com" に一致し、"example.com" 自体には一致しません。
+			if strings.HasSuffix(host, p.host) && len(host) > len(p.host) {
+				return true
+			}
+			continue
+		}
+		if p.host == host {
+			return true
+		}
+	}
+	return false
+}
+
+// isLocalhost はホスト名がループバックを指すかどうかを返します。
+func isLocalhost(host string) bool {
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
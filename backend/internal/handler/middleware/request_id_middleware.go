@@ -0,0 +1,33 @@
+// backend/internal/handler/middleware/request_id_middleware.go
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader はリクエストIDを受け渡すヘッダーです。
+const RequestIDHeader = "X-Request-ID"
+
+// validRequestID は受け入れるリクエストIDの形式です。ログの改ざんを防ぐため、英数字と一部の記号のみ許可します。
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// RequestIDMiddleware はリクエストごとのIDを決めてコンテキストとレスポンスヘッダーに設定します。
+// 前段のプロキシが X-Request-ID を付けている場合はその値を引き継ぎます。
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if !validRequestID.MatchString(requestID) {
+			b := make([]byte, 16)
+			_, _ = rand.Read(b)
+			requestID = hex.EncodeToString(b)
+		}
+
+		c.Set("requestID", requestID)
+		c.Header(RequestIDHeader, requestID)
+		c.Next()
+	}
+}
@@ -0,0 +1,182 @@
+// backend/internal/handler/oauth_handler.go
+package handler
+
+import (
+	"backend/internal/oauth"
+	"backend/internal/service"
+	"crypto/subtle"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// oauthStateCookie は認可リクエストを開始したブラウザに state を紐付ける Cookie です。
+	// 攻撃者が自分のアカウントで取得した code と state を被害者のブラウザから送らせ、
+	// 攻撃者のアカウントでログインさせる攻撃 (ログイン CSRF) を防ぐため、コールバックではこの Cookie と state の一致を確認します。
+	oauthStateCookie = "oauth_state"
+	// oauthStateCookiePath はログインとアカウント連携の両方のコールバックに Cookie が送られるパスです。
+	oauthStateCookiePath = "/api"
+)
+
+// OAuthCallbackRequest はフロントエンドがプロバイダーから受け取った値を送るリクエストボディです。
+type OAuthCallbackRequest struct {
+	Code  string `json:"code" binding:"required"`
+	State string `json:"state" binding:"required"`
+}
+
+// IdentityResponse は連携済みの外部アカウント1件分のレスポンスです。
+type IdentityResponse struct {
+	ID        int64     `json:"id"`
+	Provider  string    `json:"provider"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// HandleOAuthAuthorize は外部プロバイダーでのログインを開始し、認可 URL を返します。
+func HandleOAuthAuthorize(c *gin.Context) {
+	authURL, state, err := service.StartOAuth(c.Request.Context(), c.Param("provider"), 0)
+	if err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	setCookie(c, oauthStateCookie, state, oauthStateCookiePath, service.OAuthStateTTL, true)
+	c.JSON(http.StatusOK, gin.H{"authorization_url": authURL})
+}
+
+// HandleOAuthCallback は認可コードを検証してログインし、トークンを返します。
+func HandleOAuthCallback(c *gin.Context) {
+	var req OAuthCallbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "入力内容が正しくありません。", "details": err.Error()})
+		return
+	}
+
+	if !consumeOAuthStateCookie(c, req.State) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": service.ErrInvalidOAuthState.Error()})
+		return
+	}
+
+	pair, err := service.CompleteOAuthLogin(c.Request.Context(), c.Param("provider"), req.Code, req.State, clientInfo(c))
+	if err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	respondWithTokens(c, pair, "ログインに成功しました。")
+}
+
+// HandleListIdentities は認証済みユーザーの連携済み外部アカウントを返します。
+func HandleListIdentities(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	identities, err := service.ListIdentities(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "連携の取得に失敗しました。"})
+		return
+	}
+
+	res := make([]IdentityResponse, 0, len(identities))
+	for _, i := range identities {
+		res = append(res, IdentityResponse{ID: i.ID, Provider: i.Provider, Email: i.Email, CreatedAt: i.CreatedAt})
+	}
+	c.JSON(http.StatusOK, gin.H{"identities": res})
+}
+
+// HandleLinkIdentityStart はアカウント連携を開始し、認可 URL を返します。
+func HandleLinkIdentityStart(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	authURL, state, err := service.StartOAuth(c.Request.Context(), c.Param("provider"), userID)
+	if err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	setCookie(c, oauthStateCookie, state, oauthStateCookiePath, service.OAuthStateTTL, true)
+	c.JSON(http.StatusOK, gin.H{"authorization_url": authURL})
+}
+
+// HandleLinkIdentityCallback は認可コードを検証し、外部アカウントを連携します。
+func HandleLinkIdentityCallback(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req OAuthCallbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "入力内容が正しくありません。", "details": err.Error()})
+		return
+	}
+
+	if !consumeOAuthStateCookie(c, req.State) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": service.ErrInvalidOAuthState.Error()})
+		return
+	}
+
+	identity, err := service.LinkIdentity(c.Request.Context(), userID, c.Param("provider"), req.Code, req.State)
+	if err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, IdentityResponse{
+		ID:        identity.ID,
+		Provider:  identity.Provider,
+		Email:     identity.Email,
+		CreatedAt: identity.CreatedAt,
+	})
+}
+
+// HandleUnlinkIdentity は外部アカウントの連携を解除します。
+func HandleUnlinkIdentity(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := service.UnlinkIdentity(userID, c.Param("provider")); err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "連携を解除しました。"})
+}
+
+// consumeOAuthStateCookie は state Cookie を削除し、コールバックで受け取った state と一致するかを返します。
+func consumeOAuthStateCookie(c *gin.Context, state string) bool {
+	cookie, err := c.Cookie(oauthStateCookie)
+	setCookie(c, oauthStateCookie, "", oauthStateCookiePath, -1, true)
+	if err != nil || cookie == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(cookie), []byte(state)) == 1
+}
+
+// respondOAuthError はサービス層のエラーを適切なステータスコードに変換して返します。
+func respondOAuthError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, oauth.ErrUnknownProvider), errors.Is(err, service.ErrIdentityNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidOAuthState), errors.Is(err, service.ErrEmailNotVerified):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrIdentityAlreadyLinked), errors.Is(err, service.ErrEmailAlreadyRegistered),
+		errors.Is(err, service.ErrLastLoginMethod):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrAccountSuspended), errors.Is(err, service.ErrRegistrationClosed):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrOAuthProviderFailed):
+		log.Printf("handler.respondOAuthError: request=%s: %v", c.GetString("requestID"), err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "外部プロバイダーでの認証に失敗しました。"})
+	default:
+		// データベースのエラーなどを含むため、詳細はログにのみ残します。
+		log.Printf("handler.respondOAuthError: request=%s: %v", c.GetString("requestID"), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "外部アカウントでの処理に失敗しました。"})
+	}
+}
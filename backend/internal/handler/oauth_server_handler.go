@@ -0,0 +1,259 @@
+// backend/internal/handler/oauth_server_handler.go
+package handler
+
+import (
+	"backend/internal/auth"
+	"backend/internal/service"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterClientRequest はクライアント登録APIのリクエストボディです。
+type RegisterClientRequest struct {
+	Name         string   `json:"name" binding:"required,max=255"`
+	RedirectURIs []string `json:"redirect_uris"`
+	GrantTypes   []string `json:"grant_types"`
+	Scopes       []string `json:"scopes"`
+	Public       bool     `json:"public"`
+}
+
+// ClientResponse は登録済みクライアント1件分のレスポンスです。
+type ClientResponse struct {
+	ClientID     string    `json:"client_id"`
+	ClientSecret string    `json:"client_secret,omitempty"` // 登録直後のみ返されます
+	Name         string    `json:"name"`
+	RedirectURIs []string  `json:"redirect_uris"`
+	GrantTypes   []string  `json:"grant_types"`
+	Scopes       []string  `json:"scopes"`
+	Public       bool      `json:"public"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// AuthorizeDecisionRequest は同意画面でのユーザーの選択を送るリクエストボディです。
+type AuthorizeDecisionRequest struct {
+	service.AuthorizationRequest
+	Approve bool `json:"approve"`
+}
+
+// HandleRegisterClient は認証済みユーザーが所有するクライアントアプリケーションを登録します。
+func HandleRegisterClient(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req RegisterClientRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "入力内容が正しくありません。", "details": err.Error()})
+		return
+	}
+
+	client, secret, err := service.RegisterClient(userID, service.RegisterClientInput{
+		Name:         req.Name,
+		RedirectURIs: req.RedirectURIs,
+		GrantTypes:   req.GrantTypes,
+		Scopes:       req.Scopes,
+		Public:       req.Public,
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	res := clientResponse(client.ClientID, client.Name, client.RedirectURIs, client.GrantTypes, client.Scopes, client.IsPublic(), client.CreatedAt)
+	res.ClientSecret = secret
+	c.JSON(http.StatusCreated, res)
+}
+
+// HandleListClients は認証済みユーザーが登録したクライアントの一覧を返します。
+func HandleListClients(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	clients, err := service.ListClients(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "クライアントの取得に失敗しました。"})
+		return
+	}
+
+	res := make([]ClientResponse, 0, len(clients))
+	for _, cl := range clients {
+		res = append(res, clientResponse(cl.ClientID, cl.Name, cl.RedirectURIs, cl.GrantTypes, cl.Scopes, cl.IsPublic(), cl.CreatedAt))
+	}
+	c.JSON(http.StatusOK, gin.H{"clients": res})
+}
+
+// HandleDeleteClient は認証済みユーザーが登録したクライアントを削除します。
+func HandleDeleteClient(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := service.DeleteClient(userID, c.Param("client_id")); err != nil {
+		if errors.Is(err, service.ErrOAuthClientNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "クライアントの削除に失敗しました。"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "クライアントを削除しました。"})
+}
+
+// HandleAuthorizeInfo は同意画面に表示するクライアントとスコープの情報を返します。
+func HandleAuthorizeInfo(c *gin.Context) {
+	var req service.AuthorizationRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": err.Error()})
+		return
+	}
+
+	ac, err := service.ValidateAuthorizationRequest(req)
+	if err != nil {
+		var oerr *service.OAuthError
+		if !errors.As(err, &oerr) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "server_error"})
+			return
+		}
+		res := gin.H{"error": oerr.Code, "error_description": oerr.Description}
+		// リダイレクト URI が検証済みの場合のみ、クライアントへのエラー通知先を返します。
+		if ac != nil {
+			res["redirect_to"] = service.AuthorizationErrorRedirect(ac, oerr, req.State)
+		}
+		c.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"client": gin.H{
+			"client_id": ac.Client.ClientID,
+			"name":      ac.Client.Name,
+		},
+		"redirect_uri": ac.RedirectURI,
+		"scopes":       ac.Scopes,
+	})
+}
+
+// HandleAuthorizeDecision はユーザーの同意結果を受け取り、クライアントへのリダイレクト先を返します。
+func HandleAuthorizeDecision(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req AuthorizeDecisionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": err.Error()})
+		return
+	}
+
+	redirectTo, err := service.Authorize(userID, c.GetInt64("sessionID"), req.AuthorizationRequest, req.Approve)
+	if err != nil {
+		var oerr *service.OAuthError
+		if errors.As(err, &oerr) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": oerr.Code, "error_description": oerr.Description})
+			return
+		}
+		if errors.Is(err, service.ErrSessionRevoked) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "login_required", "error_description": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "server_error"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"redirect_to": redirectTo})
+}
+
+// HandleOAuthToken はトークンエンドポイントです (application/x-www-form-urlencoded)。
+func HandleOAuthToken(c *gin.Context) {
+	req := service.TokenRequest{
+		GrantType:    c.PostForm("grant_type"),
+		ClientID:     c.PostForm("client_id"),
+		ClientSecret: c.PostForm("client_secret"),
+		Code:         c.PostForm("code"),
+		RedirectURI:  c.PostForm("redirect_uri"),
+		CodeVerifier: c.PostForm("code_verifier"),
+		RefreshToken: c.PostForm("refresh_token"),
+		Scope:        c.PostForm("scope"),
+	}
+	// client_secret_basic の場合は Authorization ヘッダーから取得します。
+	if id, secret, ok := c.Request.BasicAuth(); ok {
+		req.ClientID, req.ClientSecret = id, secret
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+
+	res, err := service.Token(req)
+	if err != nil {
+		var oerr *service.OAuthError
+		if errors.As(err, &oerr) {
+			status := http.StatusBadRequest
+			if oerr.Code == "invalid_client" {
+				status = http.StatusUnauthorized
+				c.Header("WWW-Authenticate", `Basic realm="oauth"`)
+			}
+			c.JSON(status, gin.H{"error": oerr.Code, "error_description": oerr.Description})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "server_error"})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// HandleOAuthUserInfo は OIDC の UserInfo エンドポイントです。
+func HandleOAuthUserInfo(c *gin.Context) {
+	token, found := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !found || token == "" {
+		c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid_token"})
+		return
+	}
+
+	info, err := service.UserInfo(token)
+	if err != nil {
+		var oerr *service.OAuthError
+		if errors.As(err, &oerr) {
+			status := http.StatusUnauthorized
+			if oerr.Code == "insufficient_scope" {
+				status = http.StatusForbidden
+			}
+			c.Header("WWW-Authenticate", `Bearer error="`+oerr.Code+`"`)
+			c.JSON(status, gin.H{"error": oerr.Code, "error_description": oerr.Description})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "server_error"})
+		return
+	}
+	c.JSON(http.StatusOK, info)
+}
+
+// HandleOpenIDConfiguration はディスカバリードキュメントを返します。
+func HandleOpenIDConfiguration(c *gin.Context) {
+	c.JSON(http.StatusOK, service.DiscoveryDocument())
+}
+
+// HandleJWKS は ID トークンとアクセストークンの検証に使う公開鍵を返します。
+func HandleJWKS(c *gin.Context) {
+	c.JSON(http.StatusOK, auth.SigningKeyJWKS())
+}
+
+func clientResponse(clientID, name string, redirectURIs, grantTypes, scopes []string, public bool, createdAt time.Time) ClientResponse {
+	return ClientResponse{
+		ClientID:     clientID,
+		Name:         name,
+		RedirectURIs: redirectURIs,
+		GrantTypes:   grantTypes,
+		Scopes:       scopes,
+		Public:       public,
+		CreatedAt:    createdAt,
+	}
+}
@@ -0,0 +1,206 @@
+// backend/internal/handler/passkey_handler.go
+package handler
+
+import (
+	"backend/internal/domain"
+	"backend/internal/service"
+	"backend/internal/webauthn"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterPasskeyRequest はパスキー登録APIのリクエストボディです。
+// Credential には navigator.credentials.create() の結果を toJSON() したものを渡します。
+type RegisterPasskeyRequest struct {
+	Name       string                        `json:"name" binding:"max=100"`
+	Credential webauthn.RegistrationResponse `json:"credential" binding:"required"`
+}
+
+// RenamePasskeyRequest はパスキーの表示名変更APIのリクエストボディです。
+type RenamePasskeyRequest struct {
+	Name string `json:"name" binding:"required,max=100"`
+}
+
+// PasskeyResponse はパスキー1件分のレスポンスです。
+type PasskeyResponse struct {
+	ID         int64      `json:"id"`
+	Name       string     `json:"name"`
+	Synced     bool       `json:"synced"` // 複数の端末に同期されるパスキーかどうか
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
+// HandlePasskeyRegistrationOptions はパスキー登録用のオプションを返します。
+func HandlePasskeyRegistrationOptions(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	options, err := service.BeginPasskeyRegistration(userID)
+	if err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "パスキーの登録を開始できませんでした。"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"publicKey": options})
+}
+
+// HandleRegisterPasskey は認証器の応答を検証してパスキーを登録します。
+func HandleRegisterPasskey(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req RegisterPasskeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "入力内容が正しくありません。", "details": err.Error()})
+		return
+	}
+
+	cred, err := service.FinishPasskeyRegistration(userID, req.Name, &req.Credential)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidPasskeyChallenge), errors.Is(err, service.ErrPasskeyVerification):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrPasskeyAlreadyRegistered):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "パスキーの登録に失敗しました。"})
+		}
+		return
+	}
+	c.JSON(http.StatusCreated, passkeyResponse(cred))
+}
+
+// HandleListPasskeys は認証済みユーザーが登録したパスキーの一覧を返します。
+func HandleListPasskeys(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	credentials, err := service.ListPasskeys(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "パスキーの取得に失敗しました。"})
+		return
+	}
+
+	res := make([]PasskeyResponse, 0, len(credentials))
+	for i := range credentials {
+		res = append(res, passkeyResponse(&credentials[i]))
+	}
+	c.JSON(http.StatusOK, gin.H{"passkeys": res})
+}
+
+// HandleRenamePasskey はパスキーの表示名を変更します。
+func HandleRenamePasskey(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid passkey ID format"})
+		return
+	}
+
+	var req RenamePasskeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "入力内容が正しくありません。", "details": err.Error()})
+		return
+	}
+
+	cred, err := service.RenamePasskey(userID, id, req.Name)
+	if err != nil {
+		if errors.Is(err, service.ErrPasskeyNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "パスキーの名前の変更に失敗しました。"})
+		return
+	}
+	c.JSON(http.StatusOK, passkeyResponse(cred))
+}
+
+// HandleDeletePasskey はパスキーを削除します。
+func HandleDeletePasskey(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid passkey ID format"})
+		return
+	}
+
+	if err := service.DeletePasskey(userID, id); err != nil {
+		if errors.Is(err, service.ErrPasskeyNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "パスキーの削除に失敗しました。"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "パスキーを削除しました。"})
+}
+
+// HandlePasskeyLoginOptions はパスキーでのログイン用のオプションを返します。
+func HandlePasskeyLoginOptions(c *gin.Context) {
+	options, err := service.BeginPasskeyLogin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "パスキーでのログインを開始できませんでした。"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"publicKey": options})
+}
+
+// HandlePasskeyLogin は認証器の署名を検証し、通常のログインと同じトークンを発行します。
+// リクエストボディには navigator.credentials.get() の結果を toJSON() したものを渡します。
+func HandlePasskeyLogin(c *gin.Context) {
+	var req webauthn.AssertionResponse
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "入力内容が正しくありません。", "details": err.Error()})
+		return
+	}
+
+	pair, err := service.FinishPasskeyLogin(&req, clientInfo(c))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidPasskeyChallenge) || errors.Is(err, service.ErrPasskeyVerification) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, service.ErrAccountSuspended) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ログインに失敗しました。"})
+		return
+	}
+
+	respondWithTokens(c, pair, "ログインに成功しました。")
+}
+
+func passkeyResponse(cred *domain.WebAuthnCredential) PasskeyResponse {
+	res := PasskeyResponse{
+		ID:        cred.ID,
+		Name:      cred.Name,
+		Synced:    cred.BackupEligible,
+		CreatedAt: cred.CreatedAt,
+	}
+	if cred.LastUsedAt.Valid {
+		res.LastUsedAt = &cred.LastUsedAt.Time
+	}
+	return res
+}
@@ -0,0 +1,111 @@
+// backend/internal/handler/profile_handler.go
+package handler
+
+import (
+	"backend/internal/service"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxProfilePatchBytes はプロフィール更新のリクエストボディの上限です。
+const maxProfilePatchBytes = 16 << 10
+
+// HandleGetMe は認証済みユーザー自身の情報を返します。
+func HandleGetMe(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	user, err := service.GetCurrentUser(userID)
+	if err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ユーザー情報の取得に失敗しました。"})
+		return
+	}
+	c.JSON(http.StatusOK, newSelfUserResponse(user))
+}
+
+// HandleUpdateMe は認証済みユーザー自身のプロフィールを JSON Merge Patch (RFC 7396) で更新します。
+// 含まれていない項目は変更せず、null を指定した項目は未設定に戻します。
+//
+//	PATCH /api/users/me
+//	{"display_name": "ゆたか", "timezone": null}
+func HandleUpdateMe(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	if mediaType, _, err := mime.ParseMediaType(c.ContentType()); err != nil ||
+		(mediaType != "application/merge-patch+json" && mediaType != "application/json") {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Content-Type は application/merge-patch+json である必要があります。"})
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxProfilePatchBytes))
+	if err != nil {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "リクエストが大きすぎます。"})
+		return
+	}
+	update, err := parseProfilePatch(body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := service.UpdateProfile(userID, update, clientInfo(c))
+	if err != nil {
+		var validationErr *service.ProfileValidationError
+		switch {
+		case errors.As(err, &validationErr):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "プロフィールの入力が正しくありません。", "violations": validationErr.Violations})
+		case errors.Is(err, service.ErrUserNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "プロフィールの更新に失敗しました。"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, newSelfUserResponse(user))
+}
+
+// parseProfilePatch は Merge Patch のドキュメントをプロフィールの変更内容に変換します。
+// 変更できない項目や、文字列・null 以外の値が含まれている場合はエラーを返します。
+func parseProfilePatch(body []byte) (service.ProfileUpdate, error) {
+	var update service.ProfileUpdate
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(body, &doc); err != nil || doc == nil {
+		return update, errors.New("リクエストボディは JSON オブジェクトである必要があります。")
+	}
+
+	fields := map[string]**string{
+		"display_name": &update.DisplayName,
+		"bio":          &update.Bio,
+		"locale":       &update.Locale,
+		"timezone":     &update.Timezone,
+	}
+	for key, raw := range doc {
+		dst, ok := fields[key]
+		if !ok {
+			return update, errors.New("変更できない項目が含まれています: " + key)
+		}
+		var value *string
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return update, errors.New(key + " は文字列または null である必要があります。")
+		}
+		if value == nil {
+			value = new(string) // null は未設定に戻します
+		}
+		*dst = value
+	}
+	return update, nil
+}
@@ -0,0 +1,74 @@
+// backend/internal/handler/session_cookie.go
+package handler
+
+import (
+	"backend/internal/auth"
+	"backend/internal/config"
+	"backend/internal/handler/middleware"
+	"backend/internal/service"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// refreshCookiePath はリフレッシュトークン Cookie を送信するパスです。
+// トークン更新とログアウト以外のリクエストには送られないように制限します。
+const refreshCookiePath = "/api/auth"
+
+// setSessionCookies はトークンの組を HttpOnly Cookie に保存し、CSRF トークンを返します。
+func setSessionCookies(c *gin.Context, pair *service.TokenPair) (string, error) {
+	csrfToken, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+
+	cfg := config.AppConfig.Session
+	setCookie(c, middleware.AccessTokenCookie, pair.AccessToken, "/", cfg.AccessTokenTTL, true)
+	setCookie(c, middleware.RefreshTokenCookie, pair.RefreshToken, refreshCookiePath, time.Until(pair.RefreshExpiresAt), true)
+	// CSRF トークンはフロントエンドが読み取ってヘッダーに付与するため HttpOnly にしません。
+	setCookie(c, middleware.CSRFTokenCookie, csrfToken, "/", cfg.RefreshTokenTTL, false)
+	return csrfToken, nil
+}
+
+// clearSessionCookies はセッション用の Cookie を削除します。
+func clearSessionCookies(c *gin.Context) {
+	setCookie(c, middleware.AccessTokenCookie, "", "/", -1, true)
+	setCookie(c, middleware.RefreshTokenCookie, "", refreshCookiePath, -1, true)
+	setCookie(c, middleware.CSRFTokenCookie, "", "/", -1, false)
+}
+
+// setCookie は設定に従って Secure / SameSite 属性を付けた Cookie を設定します。
+// maxAge が負の場合は Cookie を削除します。
+func setCookie(c *gin.Context, name, value, path string, maxAge time.Duration, httpOnly bool) {
+	cfg := config.AppConfig.Session
+
+	seconds := int(maxAge.Seconds())
+	if maxAge < 0 {
+		seconds = -1
+	}
+
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		Domain:   cfg.CookieDomain,
+		MaxAge:   seconds,
+		Secure:   cfg.CookieSecure,
+		HttpOnly: httpOnly,
+		SameSite: sameSiteMode(cfg.CookieSameSite),
+	})
+}
+
+// sameSiteMode は設定値を http.SameSite に変換します。
+func sameSiteMode(v string) http.SameSite {
+	switch strings.ToLower(v) {
+	case "strict":
+		return http.SameSiteStrictMode
+	case "none":
+		return http.SameSiteNoneMode
+	default:
+		return http.SameSiteLaxMode
+	}
+}
@@ -0,0 +1,82 @@
+// backend/internal/handler/session_handler.go
+package handler
+
+import (
+	"backend/internal/service"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SessionResponse はログイン中の端末1件分のレスポンスです。
+type SessionResponse struct {
+	ID         int64     `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	Current    bool      `json:"current"` // このリクエストで使われているセッションかどうか
+	// Impersonated は管理者による代理ログインのセッションの場合に true です。
+	Impersonated bool `json:"impersonated"`
+}
+
+// HandleListSessions は認証済みユーザーのログイン中の端末一覧を返します。
+func HandleListSessions(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	currentSessionID := c.GetInt64("sessionID")
+
+	sessions, err := service.ListSessions(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "セッション一覧の取得に失敗しました。"})
+		return
+	}
+
+	res := make([]SessionResponse, 0, len(sessions))
+	for _, s := range sessions {
+		res = append(res, SessionResponse{
+			ID:           s.ID,
+			UserAgent:    s.UserAgent,
+			IPAddress:    s.IPAddress,
+			CreatedAt:    s.CreatedAt,
+			LastSeenAt:   s.LastSeenAt,
+			Current:      s.ID == currentSessionID,
+			Impersonated: s.ImpersonatorUserID.Valid,
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"sessions": res})
+}
+
+// HandleRevokeSession は指定した端末のセッションを失効させます (リモートログアウト)。
+func HandleRevokeSession(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	sessionID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID format"})
+		return
+	}
+
+	if err := service.RevokeSession(userID, sessionID); err != nil {
+		if errors.Is(err, service.ErrSessionNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "セッションの無効化に失敗しました。"})
+		return
+	}
+
+	// 現在のセッションを無効化した場合は Cookie も削除します。
+	if sessionID == c.GetInt64("sessionID") {
+		clearSessionCookies(c)
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "セッションを無効化しました。"})
+}
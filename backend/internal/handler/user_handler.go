@@ -1,17 +1,25 @@
 package handler
 
 import (
+	"backend/internal/domain"
+	"backend/internal/identifier"
 	"backend/internal/repository"
+	"backend/internal/service"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
+	"time"
+
 	"github.com/gin-gonic/gin"
 )
 
 type CreateUserRequest struct {
 	Username string `json:"username" binding:"required,min=3"`
-	Password string `json:"password" binding:"required,min=8"`
+	Password string `json:"password" binding:"required"` // 長さなどの条件はパスワードポリシーで確認します
 	Email    string `json:"email" binding:"required,email"`
+	// InviteCode は管理者が発行した招待コードです。REGISTRATION_MODE が invite_only の場合は必須です。
+	InviteCode string `json:"invite_code"`
 }
 
 func HandleCreateUser(c *gin.Context) {
@@ -20,15 +28,30 @@ func HandleCreateUser(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	newUserID, err := repository.CreateUser(req.Username, req.Password, req.Email)
+	// メールアドレスが登録済みの場合も成功した場合と同じ応答を返します。
+	err := service.RegisterUser(req.Username, req.Password, req.Email, req.InviteCode, clientInfo(c))
+	if respondPasswordPolicyError(c, err) {
+		return
+	}
+	if errors.Is(err, service.ErrRegistrationClosed) || errors.Is(err, service.ErrInviteCodeRequired) || errors.Is(err, service.ErrInvalidInviteCode) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, service.ErrUsernameTaken) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, service.ErrUsernameReserved) || errors.Is(err, service.ErrInvalidUsername) || errors.Is(err, identifier.ErrInvalidEmail) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user", "details": err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ユーザーの登録に失敗しました。"})
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{
-		"message": "User created successfully",
-		"user_id": newUserID,
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "登録を受け付けました。入力したメールアドレスにご案内を送信しました。",
 	})
 }
 
@@ -51,52 +74,96 @@ func HandleGetUserID(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, user)
+	respondUser(c, user)
 }
 
-func HandleGetAllUsers(c *gin.Context) {
-	user, err := repository.GetAllUsers()
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Can not get users"})
+// respondUser は閲覧者に応じたビューでユーザーを返します。
+// 閲覧者が本人または管理者の場合のみ、公開用以外の項目を返します。削除済みのユーザーは管理者にのみ返します。
+func respondUser(c *gin.Context, user *domain.User) {
+	viewerID := c.GetInt64("userID")
+	if viewerID != 0 {
+		if isAdmin, err := service.IsAdmin(viewerID); err == nil && isAdmin {
+			c.JSON(http.StatusOK, newAdminUserResponse(user))
+			return
+		}
+	}
+	if user.DeletedAt.Valid {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
 	}
-	if user == nil {
-		c.JSON(http.StatusOK, gin.H{})
+	if viewerID == user.ID {
+		c.JSON(http.StatusOK, newSelfUserResponse(user))
+		return
 	}
-	c.JSON(http.StatusOK, user)
+	c.JSON(http.StatusOK, newPublicUserResponse(user))
 }
 
-func HandleUpdateUser(c *gin.Context) {
-	type UpdateUserEmailRequest struct {
-		Email string `json:"email" binding:"required,email"`
-	}
+// UserListQuery は管理者用のユーザー一覧APIのクエリパラメーターです。
+type UserListQuery struct {
+	Username     string    `form:"username"` // 前方一致
+	Email        string    `form:"email"`    // 前方一致
+	CreatedFrom  time.Time `form:"created_from" time_format:"2006-01-02T15:04:05Z07:00"`
+	CreatedTo    time.Time `form:"created_to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Status       string    `form:"status" binding:"omitempty,oneof=active deleted suspended all"`
+	Verified     *bool     `form:"verified"`
+	Sort         string    `form:"sort" binding:"omitempty,oneof=id created_at username email"`
+	Order        string    `form:"order" binding:"omitempty,oneof=asc desc"`
+	Cursor       string    `form:"cursor"`
+	Limit        int       `form:"limit" binding:"omitempty,min=1,max=200"`
+	IncludeTotal bool      `form:"include_total"`
+}
 
-	strID := c.Param("id")
-	id, err := strconv.ParseInt(strID, 10, 64)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID format"})
+// HandleListUsers はユーザーの一覧を1ページずつ返します (管理者用)。
+// 既定では削除されていないユーザーを登録日時の新しい順に返します。
+func HandleListUsers(c *gin.Context) {
+	var q UserListQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "検索条件が正しくありません。", "details": err.Error()})
 		return
 	}
 
-	var update UpdateUserEmailRequest
-	if err := c.ShouldBindJSON(&update); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "请输入合法的邮箱地址"})
-		return
+	sort := q.Sort
+	desc := q.Order == "desc"
+	if sort == "" {
+		sort, desc = repository.UserSortCreatedAt, q.Order != "asc"
 	}
-	
-	rowsAffected, err := repository.UpdateUserEmail(id,update.Email)
+	page, err := service.ListUsers(service.UserListQuery{
+		Filter: repository.UserFilter{
+			UsernamePrefix: q.Username,
+			EmailPrefix:    q.Email,
+			CreatedFrom:    q.CreatedFrom,
+			CreatedTo:      q.CreatedTo,
+			Status:         q.Status,
+			Verified:       q.Verified,
+		},
+		Sort:         sort,
+		Desc:         desc,
+		Cursor:       q.Cursor,
+		Limit:        q.Limit,
+		IncludeTotal: q.IncludeTotal,
+	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		if errors.Is(err, service.ErrInvalidCursor) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ユーザー一覧の取得に失敗しました。"})
 		return
 	}
 
-	if rowsAffected == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在或邮箱未修改"})
-		return
+	users := make([]AdminUserResponse, 0, len(page.Users))
+	for i := range page.Users {
+		users = append(users, newAdminUserResponse(&page.Users[i]))
 	}
 
-	c.JSON(http.StatusOK, rowsAffected)
-
+	body := gin.H{"users": users, "next_cursor": nil}
+	if page.NextCursor != "" {
+		body["next_cursor"] = page.NextCursor
+	}
+	if page.Total != nil {
+		body["total"] = *page.Total
+	}
+	c.JSON(http.StatusOK, body)
 }
 
 func HandleDeleteUser(c *gin.Context) {
@@ -109,6 +176,17 @@ func HandleDeleteUser(c *gin.Context) {
 	}
 
 	rowsAffected, err := repository.DeleteUser(id)
+	outcome := auditOutcome(err)
+	if rowsAffected == 0 {
+		outcome = service.AuditOutcomeFailure
+	}
+	service.RecordAudit(service.AuditEntry{
+		Event:        service.AuditEventUserDelete,
+		Outcome:      outcome,
+		ActorUserID:  auditActor(c),
+		TargetUserID: id,
+		Client:       clientInfo(c),
+	})
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "删除失败,请输入正确的ID"})
 		return
@@ -0,0 +1,86 @@
+// backend/internal/handler/user_response.go
+package handler
+
+import (
+	"backend/internal/domain"
+	"backend/internal/service"
+	"time"
+)
+
+// ユーザーのレスポンスは閲覧者に応じて3種類あります。
+// domain.User を直接返さず、必ずここで項目を1つずつ写すことで、
+// domain.User に項目を追加しても明示的に追加しない限りレスポンスには含まれません。
+
+// PublicUserResponse は他のユーザーにも見せてよいユーザー情報です。
+type PublicUserResponse struct {
+	ID          int64  `json:"id"`
+	Username    string `json:"username"`
+	DisplayName string `json:"display_name"`
+	Bio         string `json:"bio"`
+	// AvatarURLs はサイズ ("64"・"128"・"256"・"512") ごとのアバター画像の署名付き URL です。未設定の場合は null です。
+	AvatarURLs map[string]string `json:"avatar_urls"`
+	CreatedAt  time.Time         `json:"created_at"`
+}
+
+// SelfUserResponse は本人に返すユーザー情報です。
+type SelfUserResponse struct {
+	PublicUserResponse
+	Email         string    `json:"email"`
+	EmailVerified bool      `json:"email_verified"`
+	Role          string    `json:"role"`
+	Locale        string    `json:"locale"`
+	Timezone      string    `json:"timezone"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	// PasswordChangeRequired は管理者にパスワードの変更を求められている場合に true です。
+	PasswordChangeRequired bool `json:"password_change_required"`
+}
+
+// AdminUserResponse は管理者に返すユーザー情報です。
+type AdminUserResponse struct {
+	SelfUserResponse
+	EmailVerifiedAt  *time.Time `json:"email_verified_at"`
+	DeletedAt        *time.Time `json:"deleted_at"`
+	SuspendedAt      *time.Time `json:"suspended_at"`
+	SuspensionReason string     `json:"suspension_reason"`
+}
+
+// newPublicUserResponse は公開用のユーザー情報を作成します。
+func newPublicUserResponse(u *domain.User) PublicUserResponse {
+	return PublicUserResponse{
+		ID:          u.ID,
+		Username:    u.Username,
+		DisplayName: u.DisplayName,
+		Bio:         u.Bio,
+		AvatarURLs:  service.AvatarURLs(u),
+		CreatedAt:   u.CreatedAt,
+	}
+}
+
+// newSelfUserResponse は本人用のユーザー情報を作成します。
+func newSelfUserResponse(u *domain.User) SelfUserResponse {
+	return SelfUserResponse{
+		PublicUserResponse:     newPublicUserResponse(u),
+		Email:                  u.Email,
+		EmailVerified:          u.EmailVerifiedAt.Valid,
+		Role:                   u.Role,
+		Locale:                 u.Locale,
+		Timezone:               u.Timezone,
+		UpdatedAt:              u.UpdatedAt,
+		PasswordChangeRequired: u.PasswordChangeRequired,
+	}
+}
+
+// newAdminUserResponse は管理者用のユーザー情報を作成します。
+func newAdminUserResponse(u *domain.User) AdminUserResponse {
+	res := AdminUserResponse{SelfUserResponse: newSelfUserResponse(u), SuspensionReason: u.SuspensionReason}
+	if u.EmailVerifiedAt.Valid {
+		res.EmailVerifiedAt = &u.EmailVerifiedAt.Time
+	}
+	if u.DeletedAt.Valid {
+		res.DeletedAt = &u.DeletedAt.Time
+	}
+	if u.SuspendedAt.Valid {
+		res.SuspendedAt = &u.SuspendedAt.Time
+	}
+	return res
+}
@@ -0,0 +1,152 @@
+// backend/internal/handler/user_transfer_handler.go
+package handler
+
+import (
+	"backend/internal/repository"
+	"backend/internal/service"
+	"errors"
+	"log"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxUserImportBodySize は一括登録で受け付けるリクエストボディの最大サイズです。
+const maxUserImportBodySize = 10 << 20
+
+// maxUserImportRows は API の一括登録で受け付ける最大の行数です。
+// 行ごとにパスワードのハッシュ化を行うため、プロキシのタイムアウトより前に応答できる行数に抑えます。
+// これを超える場合は CLI (server users import) を使用します。
+const maxUserImportRows = 1000
+
+// UserImportQuery は一括登録APIのクエリパラメーターです。
+// format を省略した場合は Content-Type (text/csv または application/x-ndjson) から判断します。
+type UserImportQuery struct {
+	Format          string `form:"format" binding:"omitempty,oneof=csv ndjson"`
+	DryRun          bool   `form:"dry_run"`
+	SendInvitations bool   `form:"send_invitations"`
+}
+
+// HandleImportUsers はリクエストボディの CSV または NDJSON からユーザーを一括登録し、行ごとの結果を返します (管理者用)。
+// dry_run=true の場合は登録せずに確認結果のみを返します。
+func HandleImportUsers(c *gin.Context) {
+	adminID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var q UserImportQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "条件が正しくありません。", "details": err.Error()})
+		return
+	}
+	format := q.Format
+	if format == "" {
+		format = userFileFormatFromContentType(c.GetHeader("Content-Type"))
+	}
+
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, maxUserImportBodySize)
+	report, err := service.ImportUsers(body, service.UserImportOptions{
+		Format:          format,
+		DryRun:          q.DryRun,
+		SendInvitations: q.SendInvitations,
+		MaxRows:         maxUserImportRows,
+	}, adminID, clientInfo(c))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		switch {
+		case errors.As(err, &tooLarge):
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "ファイルが大きすぎます。"})
+		case errors.Is(err, service.ErrUnsupportedUserFileFormat), errors.Is(err, service.ErrInvalidUserImportFile):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrInvitationsUnavailable):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			// 途中まで登録済みの場合があるため、そこまでの結果も返します。
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "ユーザーの一括登録に失敗しました。", "report": report})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// UserExportQuery はエクスポートAPIのクエリパラメーターです。fields はカンマ区切りで指定します。
+type UserExportQuery struct {
+	Format   string `form:"format" binding:"omitempty,oneof=csv ndjson"`
+	Fields   string `form:"fields"`
+	Username string `form:"username"` // 前方一致
+	Email    string `form:"email"`    // 前方一致
+	Status   string `form:"status" binding:"omitempty,oneof=active deleted suspended all"`
+	Verified *bool  `form:"verified"`
+}
+
+// HandleExportUsers は条件に一致するユーザーを CSV (既定) または NDJSON で返します (管理者用)。
+// すべてのユーザーをメモリに読み込まず、一定数ずつ取得しながらレスポンスに書き出します。
+func HandleExportUsers(c *gin.Context) {
+	var q UserExportQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "条件が正しくありません。", "details": err.Error()})
+		return
+	}
+	if q.Format == "" {
+		q.Format = service.UserFileFormatCSV
+	}
+	var fields []string
+	if q.Fields != "" {
+		for _, f := range strings.Split(q.Fields, ",") {
+			fields = append(fields, strings.TrimSpace(f))
+		}
+	}
+
+	export, err := service.PrepareUserExport(service.UserExportOptions{
+		Format: q.Format,
+		Fields: fields,
+		Filter: repository.UserFilter{
+			UsernamePrefix: q.Username,
+			EmailPrefix:    q.Email,
+			Status:         q.Status,
+			Verified:       q.Verified,
+		},
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	contentType := "text/csv; charset=utf-8"
+	if q.Format == service.UserFileFormatNDJSON {
+		contentType = "application/x-ndjson"
+	}
+	filename := "users-" + time.Now().UTC().Format("20060102T150405Z") + "." + q.Format
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	c.Status(http.StatusOK)
+
+	// 書き出しを始めた後はステータスコードを変えられないため、エラーはログに残して途中で終了します。
+	count, err := export.Stream(c.Writer)
+	if err != nil {
+		log.Printf("handler.HandleExportUsers: request=%s: after %d users: %v", c.GetString("requestID"), count, err)
+	}
+	service.RecordAudit(service.AuditEntry{
+		Event:       service.AuditEventUserExport,
+		Outcome:     auditOutcome(err),
+		ActorUserID: auditActor(c),
+		Client:      clientInfo(c),
+		Details:     map[string]any{"format": q.Format, "fields": q.Fields, "status": q.Status, "count": count},
+	})
+}
+
+// userFileFormatFromContentType は Content-Type からファイル形式を判断します。判断できない場合は空文字列を返します。
+func userFileFormatFromContentType(contentType string) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "text/csv":
+		return service.UserFileFormatCSV
+	case "application/x-ndjson", "application/jsonl":
+		return service.UserFileFormatNDJSON
+	}
+	return ""
+}
@@ -0,0 +1,79 @@
+// backend/internal/handler/username_handler.go
+package handler
+
+import (
+	"backend/internal/service"
+	"errors"
+	"math"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ChangeUsernameRequest はユーザー名変更APIのリクエストボディです。
+type ChangeUsernameRequest struct {
+	Username string `json:"username" binding:"required"` // 形式はサービス層で確認します
+}
+
+// HandleChangeUsername は認証済みユーザー自身のユーザー名を変更します。
+// 変更前のユーザー名は一定期間保持され、その間は他のユーザーが使用できません。
+func HandleChangeUsername(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req ChangeUsernameRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ユーザー名を入力してください。", "details": err.Error()})
+		return
+	}
+
+	user, err := service.ChangeUsername(userID, req.Username, clientInfo(c))
+	if err != nil {
+		var tooSoon *service.UsernameChangeTooSoonError
+		switch {
+		case errors.As(err, &tooSoon):
+			retryAfter := int64(math.Ceil(time.Until(tooSoon.NextChangeAt).Seconds()))
+			c.Header("Retry-After", strconv.FormatInt(max(retryAfter, 1), 10))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error(), "next_change_at": tooSoon.NextChangeAt})
+		case errors.Is(err, service.ErrInvalidUsername), errors.Is(err, service.ErrSameUsername), errors.Is(err, service.ErrUsernameReserved):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrUsernameTaken):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrUserNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "ユーザー名の変更に失敗しました。"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, newSelfUserResponse(user))
+}
+
+// HandleGetUserByUsername はユーザー名でユーザーを返します。
+// 変更前のユーザー名が指定された場合は、現在のユーザー名の URL へリダイレクトします。
+// ユーザー名は保持期間の後に他のユーザーが使用できるようになるため、恒久的なリダイレクトにはしません。
+//
+//	GET /api/users/by-username/old_name  →  302 Location: /api/users/by-username/new_name
+func HandleGetUserByUsername(c *gin.Context) {
+	user, renamed, err := service.FindUserByUsername(c.Param("username"))
+	if err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ユーザー情報の取得に失敗しました。"})
+		return
+	}
+
+	if renamed {
+		c.Redirect(http.StatusFound, path.Join(path.Dir(c.Request.URL.Path), url.PathEscape(user.Username)))
+		return
+	}
+	respondUser(c, user)
+}
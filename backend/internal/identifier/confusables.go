@@ -0,0 +1,69 @@
+// backend/internal/identifier/confusables.go
+package identifier
+
+// confusables は正規形 (小文字) のユーザー名で見分けにくい文字と、その代表の文字の対応です。
+// Unicode の confusables.txt (UTS #39) から、ユーザー名に使用できる文字体系のうち
+// 取り違えが起きやすいものを抜き出しています。
+var confusables = map[rune]rune{
+	// 数字とラテン文字
+	'0': 'o',
+	'1': 'l',
+
+	// キリル文字
+	'а': 'a',
+	'в': 'b',
+	'г': 'r',
+	'ԁ': 'd',
+	'е': 'e',
+	'һ': 'h',
+	'і': 'i',
+	'ј': 'j',
+	'к': 'k',
+	'ӏ': 'l',
+	'м': 'm',
+	'н': 'h',
+	'о': 'o',
+	'р': 'p',
+	'ԛ': 'q',
+	'ѕ': 's',
+	'т': 't',
+	'с': 'c',
+	'у': 'y',
+	'ԝ': 'w',
+	'х': 'x',
+	'ь': 'b',
+
+	// ギリシャ文字
+	'α': 'a',
+	'β': 'b',
+	'ε': 'e',
+	'η': 'n',
+	'ι': 'i',
+	'κ': 'k',
+	'ν': 'v',
+	'ο': 'o',
+	'ρ': 'p',
+	'τ': 't',
+	'υ': 'u',
+	'χ': 'x',
+
+	// アルメニア文字
+	'օ': 'o',
+	'ս': 'u',
+	'ց': 'g',
+	'հ': 'h',
+	'ո': 'n',
+
+	// かなと漢字 (形が同じで文字体系が異なるもの)
+	'力': 'カ',
+	'口': 'ロ',
+	'工': 'エ',
+	'二': 'ニ',
+	'八': 'ハ',
+	'夕': 'タ',
+	'卜': 'ト',
+	'一': 'ー',
+	'へ': 'ヘ',
+	'べ': 'ベ',
+	'ぺ': 'ペ',
+}
@@ -0,0 +1,175 @@
+// backend/internal/identifier/identifier.go
+//
+// Package identifier はユーザー名とメールアドレスの正規化を行います。
+//
+// ユーザー名は次の3つの形で扱います。
+//   - 表示用: NFKC で正規化した入力。users.username に保存します
+//   - 正規形: 表示用をケースフォールディングしたもの。ログインや重複の確認に使用します
+//   - スケルトン: 正規形の紛らわしい文字 (キリル文字の "а" とラテン文字の "a" など) を代表の文字に寄せたもの。
+//     見分けにくい別のユーザー名の登録を防ぐために使用します
+package identifier
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+// ユーザー名の長さの範囲 (文字数) です。
+const (
+	MinUsernameLength = 3
+	MaxUsernameLength = 32
+)
+
+var (
+	// ErrUsernameLength はユーザー名の長さが範囲外の場合に返されます。
+	ErrUsernameLength = errors.New("ユーザー名は3〜32文字で指定してください")
+	// ErrUsernameCharacters はユーザー名に使用できない文字が含まれている場合に返されます。
+	ErrUsernameCharacters = errors.New("ユーザー名には文字・数字・アンダースコアのみ使用できます")
+	// ErrUsernameMixedScript はユーザー名で組み合わせられない文字体系が混在している場合に返されます。
+	ErrUsernameMixedScript = errors.New("ユーザー名に組み合わせられない文字体系が混在しています")
+	// ErrInvalidEmail はメールアドレスの形式が正しくない場合に返されます。
+	ErrInvalidEmail = errors.New("メールアドレスの形式が正しくありません")
+)
+
+// usernameScripts はユーザー名に使用できる文字体系です。これ以外の文字体系の文字は使用できません。
+var usernameScripts = []*unicode.RangeTable{
+	unicode.Latin, unicode.Greek, unicode.Cyrillic, unicode.Armenian, unicode.Georgian,
+	unicode.Hebrew, unicode.Arabic, unicode.Thai, unicode.Devanagari,
+	unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul, unicode.Bopomofo,
+}
+
+// allowedScriptSets は複数の文字体系を混在させてよい組み合わせです (UTS #39 の Highly Restrictive に相当)。
+// 1つの文字体系のみで構成されたユーザー名は常に使用できます。
+var allowedScriptSets = [][]*unicode.RangeTable{
+	{unicode.Latin, unicode.Han, unicode.Hiragana, unicode.Katakana},
+	{unicode.Latin, unicode.Han, unicode.Hangul},
+	{unicode.Latin, unicode.Han, unicode.Bopomofo},
+}
+
+// prolongedSoundMark は長音符 "ー" です。Unicode の文字体系は Common ですが、かなとして扱います。
+const prolongedSoundMark = 'ー'
+
+var fold = cases.Fold()
+
+// NormalizeUsername は入力されたユーザー名を NFKC で正規化し、使用できるユーザー名か確認して返します。
+// 全角英数字は半角に、半角カナは全角になります。大文字・小文字は保持します (比較には CanonicalUsername を使用します)。
+func NormalizeUsername(s string) (string, error) {
+	name := norm.NFKC.String(s)
+	if n := utf8.RuneCountInString(name); n < MinUsernameLength || n > MaxUsernameLength {
+		return "", ErrUsernameLength
+	}
+
+	var scripts []*unicode.RangeTable
+	for i, r := range name {
+		switch {
+		case r == '_' || ('0' <= r && r <= '9'):
+			continue
+		case unicode.Is(unicode.M, r):
+			// 結合文字は直前の文字の一部としてのみ使用できます。
+			if i == 0 {
+				return "", ErrUsernameCharacters
+			}
+			continue
+		case r == prolongedSoundMark:
+			scripts = addScript(scripts, unicode.Katakana)
+			continue
+		case !unicode.IsLetter(r):
+			return "", ErrUsernameCharacters
+		}
+
+		script := scriptOf(r)
+		if script == nil {
+			return "", ErrUsernameCharacters
+		}
+		scripts = addScript(scripts, script)
+	}
+	if !allowedScripts(scripts) {
+		return "", ErrUsernameMixedScript
+	}
+	return name, nil
+}
+
+// CanonicalUsername はユーザー名の正規形 (NFKC + ケースフォールディング) を返します。
+// 入力の妥当性は確認しないため、登録済みのユーザー名の検索にも使用できます。
+func CanonicalUsername(s string) string {
+	return norm.NFKC.String(fold.String(norm.NFKC.String(s)))
+}
+
+// UsernameSkeleton はユーザー名の正規形の紛らわしい文字を代表の文字に置き換えた文字列を返します。
+// スケルトンが同じユーザー名は見分けにくいため、別のユーザーが使用することはできません。
+func UsernameSkeleton(s string) string {
+	canonical := CanonicalUsername(s)
+	var b strings.Builder
+	b.Grow(len(canonical))
+	for _, r := range canonical {
+		if p, ok := confusables[r]; ok {
+			r = p
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// NormalizeEmail はメールアドレスの前後の空白を取り除き、ドメイン部分を NFKC で正規化して小文字にして返します。
+// ローカル部は大文字・小文字を区別するメールサーバーがあるため NFC の正規化のみ行います。
+func NormalizeEmail(s string) (string, error) {
+	email := strings.TrimSpace(s)
+	at := strings.LastIndexByte(email, '@')
+	if at <= 0 || at == len(email)-1 {
+		return "", ErrInvalidEmail
+	}
+	return norm.NFC.String(email[:at]) + "@" + strings.ToLower(norm.NFKC.String(email[at+1:])), nil
+}
+
+// scriptOf は文字が属する、ユーザー名に使用できる文字体系を返します。該当しない場合は nil を返します。
+func scriptOf(r rune) *unicode.RangeTable {
+	for _, script := range usernameScripts {
+		if unicode.Is(script, r) {
+			return script
+		}
+	}
+	return nil
+}
+
+func addScript(scripts []*unicode.RangeTable, script *unicode.RangeTable) []*unicode.RangeTable {
+	for _, s := range scripts {
+		if s == script {
+			return scripts
+		}
+	}
+	return append(scripts, script)
+}
+
+// allowedScripts は文字体系の組み合わせがユーザー名として使用できるものか返します。
+func allowedScripts(scripts []*unicode.RangeTable) bool {
+	if len(scripts) <= 1 {
+		return true
+	}
+	for _, set := range allowedScriptSets {
+		if containsAll(set, scripts) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsAll(set []*unicode.RangeTable, scripts []*unicode.RangeTable) bool {
+	for _, s := range scripts {
+		found := false
+		for _, t := range set {
+			if s == t {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
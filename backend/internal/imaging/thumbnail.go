@@ -0,0 +1,81 @@
+// backend/internal/imaging/thumbnail.go
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/draw"
+	_ "image/gif" // 対応形式として image.Decode に登録します
+	"image/jpeg"
+	"image/png"
+	"net/http"
+
+	xdraw "golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+var (
+	// ErrUnsupportedFormat は画像の形式に対応していない場合に返されます。
+	ErrUnsupportedFormat = errors.New("対応していない画像形式です (JPEG / PNG / GIF / WebP に対応しています)")
+	// ErrTooLarge は画像の幅または高さが上限を超えている場合に返されます。
+	ErrTooLarge = errors.New("画像の幅または高さが大きすぎます")
+	// ErrInvalidImage は画像を読み込めない場合に返されます。
+	ErrInvalidImage = errors.New("画像を読み込めません")
+)
+
+// supportedTypes は受け付ける画像の Content-Type です。
+// 判定はクライアントが送った Content-Type ではなく、先頭のバイト列から行います。
+var supportedTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// Decode は画像の形式を先頭のバイト列から判定し、幅・高さが maxDimension 以下であれば読み込みます。
+// 展開する前に大きさを確認するため、小さなファイルで大量のメモリーを使わせる画像も拒否できます。
+func Decode(data []byte, maxDimension int) (image.Image, error) {
+	if !supportedTypes[http.DetectContentType(data)] {
+		return nil, ErrUnsupportedFormat
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+	if cfg.Width < 1 || cfg.Height < 1 {
+		return nil, ErrInvalidImage
+	}
+	if cfg.Width > maxDimension || cfg.Height > maxDimension {
+		return nil, ErrTooLarge
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+	return img, nil
+}
+
+// SquareThumbnail は画像の中央を正方形に切り抜き、size × size に縮小します。
+// 元の画像が size より小さい場合は拡大します。
+func SquareThumbnail(img image.Image, size int) *image.NRGBA {
+	b := img.Bounds()
+	side := min(b.Dx(), b.Dy())
+	crop := image.Rect(0, 0, side, side).Add(image.Pt(b.Min.X+(b.Dx()-side)/2, b.Min.Y+(b.Dy()-side)/2))
+
+	dst := image.NewNRGBA(image.Rect(0, 0, size, size))
+	xdraw.CatmullRom.Scale(dst, dst.Bounds(), img, crop, draw.Src, nil)
+	return dst
+}
+
+// Encode は画像を保存用に変換します。opaque (透過なし) の場合は JPEG、それ以外は PNG にします。
+// 再エンコードするため、元の画像に含まれていた Exif などのメタデータは残りません。
+func Encode(img image.Image, opaque bool) (data []byte, contentType string, ext string, err error) {
+	var buf bytes.Buffer
+	if opaque {
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85})
+		return buf.Bytes(), "image/jpeg", ".jpg", err
+	}
+	err = png.Encode(&buf, img)
+	return buf.Bytes(), "image/png", ".png", err
+}
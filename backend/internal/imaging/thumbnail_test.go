@@ -0,0 +1,76 @@
+// backend/internal/imaging/thumbnail_test.go
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func encodePNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	img.Set(0, 0, color.NRGBA{R: 255, A: 255})
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// gifHeader は画像データを持たず、ヘッダーで大きさだけを宣言する GIF です。
+// DecodeConfig で大きさを確認する前に展開すると、巨大な画像のメモリーを確保してしまいます。
+func gifHeader(w, h uint16) []byte {
+	b := []byte("GIF89a")
+	b = binary.LittleEndian.AppendUint16(b, w)
+	b = binary.LittleEndian.AppendUint16(b, h)
+	return append(b, 0x00, 0x00, 0x00) // フラグ・背景色・アスペクト比
+}
+
+func TestDecode(t *testing.T) {
+	img, err := Decode(encodePNG(t, 64, 32), 64)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != 64 || b.Dy() != 32 {
+		t.Errorf("bounds = %v, want 64x32", b)
+	}
+
+	valid := encodePNG(t, 16, 16)
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"too wide", encodePNG(t, 65, 8), ErrTooLarge},
+		{"too tall", encodePNG(t, 8, 65), ErrTooLarge},
+		{"huge declared size", gifHeader(60000, 60000), ErrTooLarge},
+		{"zero size", gifHeader(0, 0), ErrInvalidImage},
+		{"truncated", valid[:len(valid)/2], ErrInvalidImage},
+		{"not an image", []byte("<svg xmlns=\"http://www.w3.org/2000/svg\"></svg>"), ErrUnsupportedFormat},
+		{"empty", nil, ErrUnsupportedFormat},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Decode(tt.data, 64); !errors.Is(err, tt.want) {
+				t.Fatalf("Decode() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSquareThumbnail(t *testing.T) {
+	img, err := Decode(encodePNG(t, 60, 20), 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, size := range []int{8, 64} {
+		if b := SquareThumbnail(img, size).Bounds(); b.Dx() != size || b.Dy() != size {
+			t.Errorf("SquareThumbnail(%d) bounds = %v", size, b)
+		}
+	}
+}
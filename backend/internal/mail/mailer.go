@@ -0,0 +1,98 @@
+// backend/internal/mail/mailer.go
+package mail
+
+import (
+	"backend/internal/config"
+	"bytes"
+	"fmt"
+	"log"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+// Message は送信するメール1通分の内容です。本文はプレーンテキストです。
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer はメールの送信方式です。
+type Mailer interface {
+	Send(msg Message) error
+}
+
+// mailer は Send で使用する送信方式です。Init で設定されます。
+var mailer Mailer = logMailer{}
+
+// Init は設定から送信方式を選択します。
+func Init(cfg config.MailConfig) {
+	switch cfg.Driver {
+	case config.MailDriverSMTP:
+		mailer = &smtpMailer{cfg: cfg}
+	default:
+		mailer = logMailer{}
+	}
+}
+
+// Send は設定された送信方式でメールを送信します。
+func Send(msg Message) error {
+	if err := mailer.Send(msg); err != nil {
+		return fmt.Errorf("mail.Send: %w", err)
+	}
+	return nil
+}
+
+// logMailer はメールを送信せずにログへ出力します (開発用)。
+type logMailer struct{}
+
+func (logMailer) Send(msg Message) error {
+	log.Printf("--- MAIL to=%s subject=%q ---\n%s\n--- MAIL END ---", msg.To, msg.Subject, msg.Body)
+	return nil
+}
+
+// smtpMailer は SMTP サーバー経由でメールを送信します。
+// サーバーが対応していれば net/smtp が STARTTLS を使用します。
+type smtpMailer struct {
+	cfg config.MailConfig
+}
+
+func (m *smtpMailer) Send(msg Message) error {
+	from, err := mail.ParseAddress(m.cfg.From)
+	if err != nil {
+		return fmt.Errorf("送信元アドレスが不正です: %w", err)
+	}
+	to, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return fmt.Errorf("宛先アドレスが不正です: %w", err)
+	}
+
+	addr := net.JoinHostPort(m.cfg.SMTPHost, strconv.Itoa(m.cfg.SMTPPort))
+	var auth smtp.Auth
+	if m.cfg.SMTPUsername != "" {
+		auth = smtp.PlainAuth("", m.cfg.SMTPUsername, m.cfg.SMTPPassword, m.cfg.SMTPHost)
+	}
+	if err := smtp.SendMail(addr, auth, from.Address, []string{to.Address}, buildMessage(from, to, msg)); err != nil {
+		return fmt.Errorf("SMTP での送信に失敗しました: %w", err)
+	}
+	return nil
+}
+
+// buildMessage は RFC 5322 形式のメッセージを組み立てます。件名は日本語を含むため MIME エンコードします。
+func buildMessage(from, to *mail.Address, msg Message) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from.String())
+	fmt.Fprintf(&b, "To: %s\r\n", to.String())
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.BEncoding.Encode("UTF-8", msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(msg.Body)
+	return b.Bytes()
+}
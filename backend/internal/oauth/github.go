@@ -0,0 +1,86 @@
+// backend/internal/oauth/github.go
+package oauth
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strconv"
+)
+
+// GitHub は OpenID Connect に対応していないため、OAuth2 と REST API でユーザー情報を取得します。
+const (
+	githubAuthorizeURL = "https://github.com/login/oauth/authorize"
+	githubTokenURL     = "https://github.com/login/oauth/access_token"
+	githubAPIURL       = "https://api.github.com"
+)
+
+type githubProvider struct {
+	clientID     string
+	clientSecret string
+	redirectURI  string
+}
+
+func newGitHubProvider(clientID, clientSecret, redirectURI string) *githubProvider {
+	return &githubProvider{clientID: clientID, clientSecret: clientSecret, redirectURI: redirectURI}
+}
+
+func (p *githubProvider) Name() string { return "github" }
+
+// AuthCodeURL は GitHub の認可 URL を返します。GitHub は nonce を使わないため無視します。
+func (p *githubProvider) AuthCodeURL(_ context.Context, state, codeChallenge, _ string) (string, error) {
+	q := url.Values{
+		"client_id":             {p.clientID},
+		"redirect_uri":          {p.redirectURI},
+		"scope":                 {"read:user user:email"},
+		"state":                 {state},
+		"code_challenge":        {codeChallenge},
+		"code_challenge_method": {"S256"},
+		"allow_signup":          {"true"},
+	}
+	return appendQuery(githubAuthorizeURL, q), nil
+}
+
+func (p *githubProvider) Exchange(ctx context.Context, code, codeVerifier, _ string) (*Identity, error) {
+	tok, err := exchangeCode(ctx, githubTokenURL, p.clientID, p.clientSecret, p.redirectURI, code, codeVerifier)
+	if err != nil {
+		return nil, err
+	}
+
+	var user struct {
+		ID    int64  `json:"id"`
+		Login string `json:"login"`
+		Name  string `json:"name"`
+	}
+	if err := getJSON(ctx, githubAPIURL+"/user", tok.AccessToken, &user); err != nil {
+		return nil, err
+	}
+	if user.ID == 0 {
+		return nil, errors.New("GitHub のユーザー情報を取得できませんでした")
+	}
+
+	// 公開設定に関わらず確認済みのプライマリーメールアドレスを取得します。
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	if err := getJSON(ctx, githubAPIURL+"/user/emails", tok.AccessToken, &emails); err != nil {
+		return nil, err
+	}
+
+	identity := &Identity{
+		Provider: "github",
+		Subject:  strconv.FormatInt(user.ID, 10),
+		Name:     user.Name,
+		Username: user.Login,
+	}
+	for _, e := range emails {
+		if e.Primary {
+			identity.Email = e.Email
+			identity.EmailVerified = e.Verified
+			break
+		}
+	}
+	return identity, nil
+}
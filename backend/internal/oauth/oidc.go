@@ -0,0 +1,285 @@
+// backend/internal/oauth/oidc.go
+package oauth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// discoveryTTL はディスカバリードキュメントと JWKS をキャッシュする期間です。
+const discoveryTTL = time.Hour
+
+// oidcProvider は OpenID Connect のディスカバリーに対応した汎用プロバイダーです。
+type oidcProvider struct {
+	name         string
+	issuer       string
+	clientID     string
+	clientSecret string
+	redirectURI  string
+	scopes       []string
+
+	mu        sync.Mutex
+	discovery *discoveryDocument
+	keys      map[string]any // kid -> 公開鍵
+	fetchedAt time.Time
+}
+
+// discoveryDocument は /.well-known/openid-configuration の必要な項目です。
+type discoveryDocument struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// jsonWebKey は JWKS に含まれる公開鍵1つ分です (RSA と EC に対応)。
+type jsonWebKey struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// idTokenClaims は ID トークンのクレームです。
+type idTokenClaims struct {
+	Nonce             string `json:"nonce"`
+	Email             string `json:"email"`
+	EmailVerified     any    `json:"email_verified"` // プロバイダーによっては文字列で返されます
+	Name              string `json:"name"`
+	PreferredUsername string `json:"preferred_username"`
+	jwt.RegisteredClaims
+}
+
+func newOIDCProvider(name, issuer, clientID, clientSecret, redirectURI string, scopes []string) *oidcProvider {
+	return &oidcProvider{
+		name:         name,
+		issuer:       strings.TrimRight(issuer, "/"),
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		redirectURI:  redirectURI,
+		scopes:       scopes,
+	}
+}
+
+func (p *oidcProvider) Name() string { return p.name }
+
+func (p *oidcProvider) AuthCodeURL(ctx context.Context, state, codeChallenge, nonce string) (string, error) {
+	doc, err := p.getDiscovery(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	q := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.clientID},
+		"redirect_uri":          {p.redirectURI},
+		"scope":                 {strings.Join(p.scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {codeChallenge},
+		"code_challenge_method": {"S256"},
+	}
+	return appendQuery(doc.AuthorizationEndpoint, q), nil
+}
+
+func (p *oidcProvider) Exchange(ctx context.Context, code, codeVerifier, nonce string) (*Identity, error) {
+	doc, err := p.getDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tok, err := exchangeCode(ctx, doc.TokenEndpoint, p.clientID, p.clientSecret, p.redirectURI, code, codeVerifier)
+	if err != nil {
+		return nil, err
+	}
+	if tok.IDToken == "" {
+		return nil, errors.New("ID トークンが返されませんでした")
+	}
+
+	claims, err := p.verifyIDToken(ctx, tok.IDToken, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Identity{
+		Provider:      p.name,
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified == true || claims.EmailVerified == "true",
+		Name:          claims.Name,
+		Username:      claims.PreferredUsername,
+	}, nil
+}
+
+// verifyIDToken は ID トークンの署名・発行者・対象者・有効期限・nonce を検証します。
+func (p *oidcProvider) verifyIDToken(ctx context.Context, idToken, nonce string) (*idTokenClaims, error) {
+	doc, err := p.getDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := &idTokenClaims{}
+	_, err = jwt.ParseWithClaims(idToken, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.getKey(ctx, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"}),
+		jwt.WithIssuer(doc.Issuer),
+		jwt.WithAudience(p.clientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ID トークンの検証に失敗しました: %w", err)
+	}
+	if claims.Nonce != nonce {
+		return nil, errors.New("ID トークンの nonce が一致しません")
+	}
+	if claims.Subject == "" {
+		return nil, errors.New("ID トークンに sub がありません")
+	}
+	return claims, nil
+}
+
+// getDiscovery はディスカバリードキュメントをキャッシュから、または取得して返します。
+func (p *oidcProvider) getDiscovery(ctx context.Context) (*discoveryDocument, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovery != nil && time.Since(p.fetchedAt) < discoveryTTL {
+		return p.discovery, nil
+	}
+
+	var doc discoveryDocument
+	if err := getJSON(ctx, p.issuer+"/.well-known/openid-configuration", "", &doc); err != nil {
+		return nil, fmt.Errorf("ディスカバリードキュメントの取得に失敗しました: %w", err)
+	}
+	if strings.TrimRight(doc.Issuer, "/") != p.issuer {
+		return nil, fmt.Errorf("ディスカバリードキュメントの issuer %q が設定 %q と一致しません", doc.Issuer, p.issuer)
+	}
+	if doc.AuthorizationEndpoint == "" || doc.TokenEndpoint == "" || doc.JWKSURI == "" {
+		return nil, errors.New("ディスカバリードキュメントに必要なエンドポイントがありません")
+	}
+
+	p.discovery = &doc
+	p.keys = nil
+	p.fetchedAt = time.Now()
+	return p.discovery, nil
+}
+
+// getKey は kid に対応する公開鍵を返します。
+// 見つからない場合は鍵のローテーションに備えて JWKS を取得し直します。
+func (p *oidcProvider) getKey(ctx context.Context, kid string) (any, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key, ok := p.lookupKey(kid); ok {
+		return key, nil
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := getJSON(ctx, p.discovery.JWKSURI, "", &set); err != nil {
+		return nil, fmt.Errorf("JWKS の取得に失敗しました: %w", err)
+	}
+
+	p.keys = make(map[string]any, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		if key, err := k.publicKey(); err == nil {
+			p.keys[k.Kid] = key
+		}
+	}
+
+	if key, ok := p.lookupKey(kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("署名鍵 %q が見つかりません", kid)
+}
+
+// lookupKey はキャッシュから鍵を探します。kid が空の場合は鍵が1つだけのときに限りそれを返します。
+func (p *oidcProvider) lookupKey(kid string) (any, bool) {
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key, true
+		}
+	}
+	key, ok := p.keys[kid]
+	return key, ok
+}
+
+// publicKey は JWK を Go の公開鍵に変換します。
+func (k jsonWebKey) publicKey() (any, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("対応していない曲線です: %s", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("対応していない鍵の種類です: %s", k.Kty)
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+// appendQuery は URL にクエリパラメーターを追加します。
+func appendQuery(rawURL string, q url.Values) string {
+	sep := "?"
+	if strings.Contains(rawURL, "?") {
+		sep = "&"
+	}
+	return rawURL + sep + q.Encode()
+}
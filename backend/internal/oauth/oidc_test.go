@@ -0,0 +1,329 @@
+// backend/internal/oauth/oidc_test.go
+package oauth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testClientID    = "client-id"
+	testRedirectURI = "https://app.example.com/auth/callback/test"
+	testKeyID       = "test-key"
+	testNonce       = "nonce-1"
+	testVerifier    = "code-verifier-0123456789-0123456789-0123456789"
+)
+
+// fakeIssuer は httptest で立てたテスト用の OpenID プロバイダーです。
+// ディスカバリー・JWKS・トークンエンドポイントを持ち、トークンエンドポイントでは PKCE を検証します。
+type fakeIssuer struct {
+	server *httptest.Server
+	key    *ecdsa.PrivateKey
+
+	mu    sync.Mutex
+	next  int
+	codes map[string]fakeAuthorization
+}
+
+// fakeAuthorization は発行済みの認可コード1つ分です。
+type fakeAuthorization struct {
+	codeChallenge string
+	idToken       string
+}
+
+func newFakeIssuer(t *testing.T) *fakeIssuer {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeIssuer{key: key, codes: map[string]fakeAuthorization{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{
+			"issuer":                 f.server.URL,
+			"authorization_endpoint": f.server.URL + "/authorize",
+			"token_endpoint":         f.server.URL + "/token",
+			"jwks_uri":               f.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("GET /jwks", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{"keys": []map[string]string{{
+			"kid": testKeyID,
+			"kty": "EC",
+			"use": "sig",
+			"crv": "P-256",
+			"x":   base64.RawURLEncoding.EncodeToString(key.PublicKey.X.FillBytes(make([]byte, 32))),
+			"y":   base64.RawURLEncoding.EncodeToString(key.PublicKey.Y.FillBytes(make([]byte, 32))),
+		}}})
+	})
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+			return
+		}
+		f.mu.Lock()
+		auth, ok := f.codes[r.PostForm.Get("code")]
+		delete(f.codes, r.PostForm.Get("code"))
+		f.mu.Unlock()
+
+		if !ok || r.PostForm.Get("grant_type") != "authorization_code" ||
+			r.PostForm.Get("client_id") != testClientID || r.PostForm.Get("redirect_uri") != testRedirectURI ||
+			CodeChallenge(r.PostForm.Get("code_verifier")) != auth.codeChallenge {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{
+			"access_token": "access-token",
+			"token_type":   "Bearer",
+			"id_token":     auth.idToken,
+		})
+	})
+
+	f.server = httptest.NewServer(mux)
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// provider はテスト用のプロバイダーに接続する oidcProvider を返します。
+func (f *fakeIssuer) provider() *oidcProvider {
+	return newOIDCProvider("test", f.server.URL, testClientID, "client-secret", testRedirectURI, []string{"openid", "email", "profile"})
+}
+
+// authorize は codeChallenge に対して idToken を返す認可コードを発行します。
+func (f *fakeIssuer) authorize(codeChallenge, idToken string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.next++
+	code := fmt.Sprintf("code-%d", f.next)
+	f.codes[code] = fakeAuthorization{codeChallenge: codeChallenge, idToken: idToken}
+	return code
+}
+
+// claims は検証に成功する ID トークンのクレームを返します。
+func (f *fakeIssuer) claims() jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"iss":                f.server.URL,
+		"aud":                testClientID,
+		"sub":                "subject-1",
+		"iat":                now.Unix(),
+		"exp":                now.Add(5 * time.Minute).Unix(),
+		"nonce":              testNonce,
+		"email":              "alice@example.com",
+		"email_verified":     true,
+		"name":               "Alice",
+		"preferred_username": "alice",
+	}
+}
+
+// sign はプロバイダーの鍵で ID トークンに署名します。
+func (f *fakeIssuer) sign(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
+	token.Header["kid"] = testKeyID
+	signed, err := token.SignedString(f.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+// exchange は idToken を返す認可コードを発行し、oidcProvider で交換します。
+func (f *fakeIssuer) exchange(idToken string) (*Identity, error) {
+	code := f.authorize(CodeChallenge(testVerifier), idToken)
+	return f.provider().Exchange(context.Background(), code, testVerifier, testNonce)
+}
+
+func TestOIDCAuthCodeURL(t *testing.T) {
+	f := newFakeIssuer(t)
+
+	raw, err := f.provider().AuthCodeURL(context.Background(), "state-1", CodeChallenge(testVerifier), testNonce)
+	if err != nil {
+		t.Fatalf("AuthCodeURL: %v", err)
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := u.Scheme + "://" + u.Host + u.Path; got != f.server.URL+"/authorize" {
+		t.Errorf("endpoint = %s, want %s/authorize", got, f.server.URL)
+	}
+	want := map[string]string{
+		"response_type":         "code",
+		"client_id":             testClientID,
+		"redirect_uri":          testRedirectURI,
+		"scope":                 "openid email profile",
+		"state":                 "state-1",
+		"nonce":                 testNonce,
+		"code_challenge":        CodeChallenge(testVerifier),
+		"code_challenge_method": "S256",
+	}
+	for k, v := range want {
+		if got := u.Query().Get(k); got != v {
+			t.Errorf("%s = %q, want %q", k, got, v)
+		}
+	}
+}
+
+func TestOIDCExchange(t *testing.T) {
+	f := newFakeIssuer(t)
+
+	identity, err := f.exchange(f.sign(t, f.claims()))
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	want := Identity{
+		Provider:      "test",
+		Subject:       "subject-1",
+		Email:         "alice@example.com",
+		EmailVerified: true,
+		Name:          "Alice",
+		Username:      "alice",
+	}
+	if *identity != want {
+		t.Errorf("identity = %+v, want %+v", *identity, want)
+	}
+}
+
+func TestOIDCExchangeEmailVerified(t *testing.T) {
+	f := newFakeIssuer(t)
+
+	for _, tt := range []struct {
+		value any
+		want  bool
+	}{
+		{true, true},
+		{"true", true},
+		{false, false},
+		{"false", false},
+		{nil, false},
+	} {
+		claims := f.claims()
+		claims["email_verified"] = tt.value
+		identity, err := f.exchange(f.sign(t, claims))
+		if err != nil {
+			t.Fatalf("Exchange(email_verified=%v): %v", tt.value, err)
+		}
+		if identity.EmailVerified != tt.want {
+			t.Errorf("EmailVerified(email_verified=%v) = %v, want %v", tt.value, identity.EmailVerified, tt.want)
+		}
+	}
+}
+
+func TestOIDCExchangeRejectsWrongCodeVerifier(t *testing.T) {
+	f := newFakeIssuer(t)
+
+	code := f.authorize(CodeChallenge(testVerifier), f.sign(t, f.claims()))
+	if _, err := f.provider().Exchange(context.Background(), code, "another-verifier", testNonce); err == nil {
+		t.Fatal("Exchange succeeded with a wrong code_verifier, want error")
+	}
+}
+
+func TestOIDCExchangeRejectsInvalidIDToken(t *testing.T) {
+	f := newFakeIssuer(t)
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	withClaim := func(k string, v any) func(t *testing.T) string {
+		return func(t *testing.T) string {
+			claims := f.claims()
+			if v == nil {
+				delete(claims, k)
+			} else {
+				claims[k] = v
+			}
+			return f.sign(t, claims)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		idToken func(t *testing.T) string
+	}{
+		{"wrong audience", withClaim("aud", "another-client")},
+		{"wrong issuer", withClaim("iss", "https://evil.example.net")},
+		{"expired", withClaim("exp", time.Now().Add(-2*time.Minute).Unix())},
+		{"missing exp", withClaim("exp", nil)},
+		{"not yet valid", withClaim("nbf", time.Now().Add(5*time.Minute).Unix())},
+		{"wrong nonce", withClaim("nonce", "another-nonce")},
+		{"missing nonce", withClaim("nonce", nil)},
+		{"missing sub", withClaim("sub", nil)},
+		{"alg none", func(t *testing.T) string {
+			token := jwt.NewWithClaims(jwt.SigningMethodNone, f.claims())
+			token.Header["kid"] = testKeyID
+			signed, err := token.SignedString(jwt.UnsafeAllowNoneSignatureType)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return signed
+		}},
+		// 公開鍵を HMAC の鍵として使う、アルゴリズムの取り違えを狙ったトークンです。
+		{"HS256", func(t *testing.T) string {
+			token := jwt.NewWithClaims(jwt.SigningMethodHS256, f.claims())
+			token.Header["kid"] = testKeyID
+			signed, err := token.SignedString(elliptic.MarshalCompressed(elliptic.P256(), f.key.PublicKey.X, f.key.PublicKey.Y))
+			if err != nil {
+				t.Fatal(err)
+			}
+			return signed
+		}},
+		{"signed by another key", func(t *testing.T) string {
+			token := jwt.NewWithClaims(jwt.SigningMethodES256, f.claims())
+			token.Header["kid"] = testKeyID
+			signed, err := token.SignedString(otherKey)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return signed
+		}},
+		{"unknown kid", func(t *testing.T) string {
+			token := jwt.NewWithClaims(jwt.SigningMethodES256, f.claims())
+			token.Header["kid"] = "another-key"
+			signed, err := token.SignedString(f.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return signed
+		}},
+		{"empty", func(t *testing.T) string { return "" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := f.exchange(tt.idToken(t)); err == nil {
+				t.Fatal("Exchange succeeded, want error")
+			}
+		})
+	}
+}
+
+func TestOIDCDiscoveryRejectsIssuerMismatch(t *testing.T) {
+	f := newFakeIssuer(t)
+
+	p := newOIDCProvider("test", f.server.URL+"/tenant", testClientID, "", testRedirectURI, []string{"openid"})
+	if _, err := p.AuthCodeURL(context.Background(), "state-1", CodeChallenge(testVerifier), testNonce); err == nil {
+		t.Fatal("AuthCodeURL succeeded with a mismatching issuer, want error")
+	}
+}
@@ -0,0 +1,160 @@
+// backend/internal/oauth/provider.go
+package oauth
+
+import (
+	"backend/internal/config"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Identity は外部プロバイダーで認証されたユーザーの情報です。
+type Identity struct {
+	Provider      string
+	Subject       string // プロバイダー内で不変のユーザーID (OIDC の sub)
+	Email         string
+	EmailVerified bool
+	Name          string
+	Username      string // プロバイダー上のユーザー名 (preferred_username / GitHub の login)
+}
+
+// Provider は認可コードフロー (PKCE) に対応した外部の認証プロバイダーです。
+type Provider interface {
+	// Name は URL などで使うプロバイダー名を返します。
+	Name() string
+	// AuthCodeURL はユーザーをリダイレクトする認可エンドポイントの URL を返します。
+	AuthCodeURL(ctx context.Context, state, codeChallenge, nonce string) (string, error)
+	// Exchange は認可コードをトークンに交換し、ユーザー情報を返します。
+	Exchange(ctx context.Context, code, codeVerifier, nonce string) (*Identity, error)
+}
+
+// ErrUnknownProvider は有効になっていないプロバイダー名が指定された場合に返されます。
+var ErrUnknownProvider = errors.New("対応していない認証プロバイダーです")
+
+// providers は有効なプロバイダーの一覧です。Init で設定されます。
+var providers = map[string]Provider{}
+
+// httpClient は外部プロバイダーとの通信に使用する HTTP クライアントです。
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+// Init は設定から有効なプロバイダーを登録します。
+// ディスカバリードキュメントは初回使用時に取得するため、ここでは通信しません。
+func Init(cfg config.OAuthConfig) {
+	providers = map[string]Provider{}
+	redirect := func(name string) string {
+		return strings.TrimRight(cfg.RedirectBaseURL, "/") + "/" + name
+	}
+
+	if cfg.Google.ClientID != "" {
+		providers["google"] = newOIDCProvider("google", "https://accounts.google.com",
+			cfg.Google.ClientID, cfg.Google.ClientSecret, redirect("google"),
+			[]string{"openid", "email", "profile"})
+	}
+	if cfg.GitHub.ClientID != "" {
+		providers["github"] = newGitHubProvider(cfg.GitHub.ClientID, cfg.GitHub.ClientSecret, redirect("github"))
+	}
+	if cfg.OIDC.ClientID != "" {
+		providers[cfg.OIDC.Name] = newOIDCProvider(cfg.OIDC.Name, cfg.OIDC.IssuerURL,
+			cfg.OIDC.ClientID, cfg.OIDC.ClientSecret, redirect(cfg.OIDC.Name), cfg.OIDC.Scopes)
+	}
+}
+
+// Get は名前からプロバイダーを取得します。
+func Get(name string) (Provider, error) {
+	p, ok := providers[name]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+	return p, nil
+}
+
+// CodeChallenge は PKCE の code_verifier から S256 の code_challenge を求めます。
+func CodeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// tokenResponse はトークンエンドポイントのレスポンスです。
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// exchangeCode は認可コードをトークンエンドポイントでトークンに交換します。
+func exchangeCode(ctx context.Context, tokenURL, clientID, clientSecret, redirectURI, code, codeVerifier string) (*tokenResponse, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {redirectURI},
+		"client_id":     {clientID},
+		"code_verifier": {codeVerifier},
+	}
+	if clientSecret != "" {
+		form.Set("client_secret", clientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	var tok tokenResponse
+	if err := doJSON(req, &tok); err != nil {
+		return nil, fmt.Errorf("トークンの取得に失敗しました: %w", err)
+	}
+	if tok.Error != "" {
+		return nil, fmt.Errorf("トークンの取得に失敗しました: %s %s", tok.Error, tok.ErrorDescription)
+	}
+	if tok.AccessToken == "" {
+		return nil, errors.New("トークンの取得に失敗しました: access_token がありません")
+	}
+	return &tok, nil
+}
+
+// getJSON は GET リクエストを送り、JSON レスポンスを out にデコードします。
+func getJSON(ctx context.Context, rawURL, accessToken string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+	return doJSON(req, out)
+}
+
+// doJSON はリクエストを送り、JSON レスポンスを out にデコードします。
+func doJSON(req *http.Request, out any) error {
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	// トークンエンドポイントはエラー時も JSON を返すため、400 はデコードを試みます。
+	if res.StatusCode >= 300 && res.StatusCode != http.StatusBadRequest {
+		return fmt.Errorf("%s %s: unexpected status %d", req.Method, req.URL.Redacted(), res.StatusCode)
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("%s %s: invalid JSON response: %w", req.Method, req.URL.Redacted(), err)
+	}
+	return nil
+}
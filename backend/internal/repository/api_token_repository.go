@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"backend/internal/database"
+	"backend/internal/domain"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// CreateAPIToken は個人用アクセストークンを保存します。
+func CreateAPIToken(t *domain.APIToken) (int64, error) {
+	query := "INSERT INTO api_tokens (user_id, name, prefix, token_hash, scopes, expires_at) VALUES (?, ?, ?, ?, ?, ?)"
+
+	result, err := database.DB.Exec(query, t.UserID, t.Name, t.Prefix, t.TokenHash, strings.Join(t.Scopes, " "), t.ExpiresAt)
+	if err != nil {
+		return 0, fmt.Errorf("CreateAPIToken: could not insert api token: %v", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("CreateAPIToken: could not retrieve last insert ID: %v", err)
+	}
+	return id, nil
+}
+
+// GetAPITokenByHash はハッシュから個人用アクセストークンを取得します。
+func GetAPITokenByHash(tokenHash string) (*domain.APIToken, error) {
+	query := "SELECT id, user_id, name, prefix, token_hash, scopes, expires_at, last_used_at, created_at, revoked_at FROM api_tokens WHERE token_hash = ?"
+
+	t, err := scanAPIToken(database.DB.QueryRow(query, tokenHash))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("GetAPITokenByHash: could not retrieve api token: %v", err)
+	}
+	return t, nil
+}
+
+// GetActiveAPITokensByUserID はユーザーの失効していないアクセストークンを新しい順に返します。
+func GetActiveAPITokensByUserID(userID int64) ([]domain.APIToken, error) {
+	query := "SELECT id, user_id, name, prefix, token_hash, scopes, expires_at, last_used_at, created_at, revoked_at FROM api_tokens WHERE user_id = ? AND revoked_at IS NULL ORDER BY id DESC"
+
+	rows, err := database.DB.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("GetActiveAPITokensByUserID: could not retrieve api tokens: %v", err)
+	}
+	defer rows.Close()
+
+	var tokens []domain.APIToken
+	for rows.Next() {
+		t, err := scanAPIToken(rows)
+		if err != nil {
+			return nil, fmt.Errorf("GetActiveAPITokensByUserID: error scanning api token row: %v", err)
+		}
+		tokens = append(tokens, *t)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("GetActiveAPITokensByUserID: error iterating api token rows: %v", err)
+	}
+	return tokens, nil
+}
+
+// TouchAPIToken はアクセストークンの最終使用日時を更新します。
+func TouchAPIToken(id int64) error {
+	if _, err := database.DB.Exec("UPDATE api_tokens SET last_used_at = CURRENT_TIMESTAMP WHERE id = ?", id); err != nil {
+		return fmt.Errorf("TouchAPIToken: could not update api token %d: %v", id, err)
+	}
+	return nil
+}
+
+// RevokeAPIToken はユーザーのアクセストークンを失効させます。
+func RevokeAPIToken(id int64, userID int64) (int64, error) {
+	query := "UPDATE api_tokens SET revoked_at = CURRENT_TIMESTAMP WHERE id = ? AND user_id = ? AND revoked_at IS NULL"
+	result, err := database.DB.Exec(query, id, userID)
+	if err != nil {
+		return 0, fmt.Errorf("RevokeAPIToken: could not revoke api token %d: %v", id, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("RevokeAPIToken: could not get rows affected after update: %v", err)
+	}
+	return rowsAffected, nil
+}
+
+// RevokeAPITokensByUserID はユーザーのすべてのアクセストークンを失効させます。
+func RevokeAPITokensByUserID(userID int64) (int64, error) {
+	query := "UPDATE api_tokens SET revoked_at = CURRENT_TIMESTAMP WHERE user_id = ? AND revoked_at IS NULL"
+	result, err := database.DB.Exec(query, userID)
+	if err != nil {
+		return 0, fmt.Errorf("RevokeAPITokensByUserID: could not revoke api tokens for user %d: %v", userID, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("RevokeAPITokensByUserID: could not get rows affected after update: %v", err)
+	}
+	return rowsAffected, nil
+}
+
+func scanAPIToken(row rowScanner) (*domain.APIToken, error) {
+	var t domain.APIToken
+	var scopes string
+	err := row.Scan(&t.ID, &t.UserID, &t.Name, &t.Prefix, &t.TokenHash, &scopes, &t.ExpiresAt, &t.LastUsedAt, &t.CreatedAt, &t.RevokedAt)
+	if err != nil {
+		return nil, err
+	}
+	t.Scopes = strings.Fields(scopes)
+	return &t, nil
+}
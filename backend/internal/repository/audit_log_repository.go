@@ -0,0 +1,216 @@
+package repository
+
+import (
+	"backend/internal/audit"
+	"backend/internal/database"
+	"backend/internal/domain"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// AuditLogFilter は監査ログの検索条件です。ゼロ値の項目は条件に含めません。
+type AuditLogFilter struct {
+	Event        string
+	Outcome      string
+	ActorUserID  int64
+	TargetUserID int64
+	// SubjectUserID は操作したユーザーまたは対象のユーザーのどちらかが一致するものを検索します。
+	SubjectUserID int64
+	IPAddress     string
+	From          time.Time
+	To            time.Time
+	BeforeID      int64 // ページング用。この ID より古いものを返します
+	Limit         int
+}
+
+// CreateAuditLog は監査ログを1件追加し、ハッシュチェーンの末尾に連結します。
+// チェーンの末尾の行をロックするため、同時に追加された場合も1件ずつ順番に連結されます。
+func CreateAuditLog(l *domain.AuditLog) error {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("CreateAuditLog: could not begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	if err := tx.QueryRow("SELECT last_hash FROM audit_chain_head WHERE id = 1 FOR UPDATE").Scan(&l.PrevHash); err != nil {
+		return fmt.Errorf("CreateAuditLog: could not lock audit chain head: %v", err)
+	}
+	l.OccurredAt = audit.Timestamp(l.OccurredAt)
+	l.EntryHash = audit.EntryHash(l)
+
+	query := "INSERT INTO audit_logs (occurred_at, event, outcome, actor_user_id, target_user_id, ip_address, user_agent, request_id, details, prev_hash, entry_hash) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	result, err := tx.Exec(query, l.OccurredAt, l.Event, l.Outcome, l.ActorUserID, l.TargetUserID, l.IPAddress, l.UserAgent, l.RequestID, l.Details, l.PrevHash, l.EntryHash)
+	if err != nil {
+		return fmt.Errorf("CreateAuditLog: could not insert audit log: %v", err)
+	}
+	if l.ID, err = result.LastInsertId(); err != nil {
+		return fmt.Errorf("CreateAuditLog: could not get last insert ID: %v", err)
+	}
+
+	if _, err := tx.Exec("UPDATE audit_chain_head SET last_log_id = ?, last_hash = ? WHERE id = 1", l.ID, l.EntryHash); err != nil {
+		return fmt.Errorf("CreateAuditLog: could not update audit chain head: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("CreateAuditLog: could not commit: %v", err)
+	}
+	return nil
+}
+
+// GetAuditChainHead はハッシュチェーンの末尾のエントリーの ID とハッシュを返します。
+// チェーンが空の場合は 0 と空文字列を返します。
+func GetAuditChainHead() (int64, string, error) {
+	var lastID int64
+	var lastHash string
+	if err := database.DB.QueryRow("SELECT last_log_id, last_hash FROM audit_chain_head WHERE id = 1").Scan(&lastID, &lastHash); err != nil {
+		return 0, "", fmt.Errorf("GetAuditChainHead: could not retrieve audit chain head: %v", err)
+	}
+	return lastID, lastHash, nil
+}
+
+// WalkAuditLogs はすべての監査ログを ID の昇順に1件ずつ fn に渡します。
+// fn がエラーを返した場合はそこで中断し、そのエラーを返します。
+func WalkAuditLogs(fn func(l *domain.AuditLog) error) error {
+	query := "SELECT id, occurred_at, event, outcome, actor_user_id, target_user_id, ip_address, user_agent, request_id, COALESCE(details, ''), prev_hash, entry_hash FROM audit_logs ORDER BY id"
+	rows, err := database.DB.Query(query)
+	if err != nil {
+		return fmt.Errorf("WalkAuditLogs: could not retrieve audit logs: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var l domain.AuditLog
+		if err := rows.Scan(&l.ID, &l.OccurredAt, &l.Event, &l.Outcome, &l.ActorUserID, &l.TargetUserID, &l.IPAddress, &l.UserAgent, &l.RequestID, &l.Details, &l.PrevHash, &l.EntryHash); err != nil {
+			return fmt.Errorf("WalkAuditLogs: error scanning audit log row: %v", err)
+		}
+		if err := fn(&l); err != nil {
+			return err
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("WalkAuditLogs: error iterating audit log rows: %v", err)
+	}
+	return nil
+}
+
+// CreateAuditCheckpoint は署名付きチェックポイントを保存します。
+func CreateAuditCheckpoint(cp *domain.AuditCheckpoint) (int64, error) {
+	query := "INSERT INTO audit_checkpoints (last_log_id, last_hash, signed_at, key_id, signature) VALUES (?, ?, ?, ?, ?)"
+	result, err := database.DB.Exec(query, cp.LastLogID, cp.LastHash, cp.SignedAt, cp.KeyID, cp.Signature)
+	if err != nil {
+		return 0, fmt.Errorf("CreateAuditCheckpoint: could not insert audit checkpoint: %v", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("CreateAuditCheckpoint: could not get last insert ID: %v", err)
+	}
+	return id, nil
+}
+
+// GetLatestAuditCheckpoint は最後に保存されたチェックポイントを返します。存在しない場合は nil を返します。
+func GetLatestAuditCheckpoint() (*domain.AuditCheckpoint, error) {
+	query := "SELECT id, last_log_id, last_hash, signed_at, key_id, signature FROM audit_checkpoints ORDER BY id DESC LIMIT 1"
+	cp, err := scanAuditCheckpoint(database.DB.QueryRow(query))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("GetLatestAuditCheckpoint: could not retrieve audit checkpoint: %v", err)
+	}
+	return cp, nil
+}
+
+// GetAuditCheckpoints はすべてのチェックポイントを対象のエントリーの ID の昇順に返します。
+func GetAuditCheckpoints() ([]domain.AuditCheckpoint, error) {
+	query := "SELECT id, last_log_id, last_hash, signed_at, key_id, signature FROM audit_checkpoints ORDER BY last_log_id, id"
+	rows, err := database.DB.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("GetAuditCheckpoints: could not retrieve audit checkpoints: %v", err)
+	}
+	defer rows.Close()
+
+	var checkpoints []domain.AuditCheckpoint
+	for rows.Next() {
+		cp, err := scanAuditCheckpoint(rows)
+		if err != nil {
+			return nil, fmt.Errorf("GetAuditCheckpoints: error scanning audit checkpoint row: %v", err)
+		}
+		checkpoints = append(checkpoints, *cp)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("GetAuditCheckpoints: error iterating audit checkpoint rows: %v", err)
+	}
+	return checkpoints, nil
+}
+
+// scanAuditCheckpoint は1行分のチェックポイントを読み取ります。
+func scanAuditCheckpoint(row rowScanner) (*domain.AuditCheckpoint, error) {
+	var cp domain.AuditCheckpoint
+	if err := row.Scan(&cp.ID, &cp.LastLogID, &cp.LastHash, &cp.SignedAt, &cp.KeyID, &cp.Signature); err != nil {
+		return nil, err
+	}
+	return &cp, nil
+}
+
+// FindAuditLogs は条件に一致する監査ログを新しい順に最大 Limit 件返します。
+func FindAuditLogs(f AuditLogFilter) ([]domain.AuditLog, error) {
+	var conds []string
+	var args []any
+	if f.Event != "" {
+		conds, args = append(conds, "event = ?"), append(args, f.Event)
+	}
+	if f.Outcome != "" {
+		conds, args = append(conds, "outcome = ?"), append(args, f.Outcome)
+	}
+	if f.ActorUserID != 0 {
+		conds, args = append(conds, "actor_user_id = ?"), append(args, f.ActorUserID)
+	}
+	if f.TargetUserID != 0 {
+		conds, args = append(conds, "target_user_id = ?"), append(args, f.TargetUserID)
+	}
+	if f.SubjectUserID != 0 {
+		conds, args = append(conds, "(actor_user_id = ? OR target_user_id = ?)"), append(args, f.SubjectUserID, f.SubjectUserID)
+	}
+	if f.IPAddress != "" {
+		conds, args = append(conds, "ip_address = ?"), append(args, f.IPAddress)
+	}
+	if !f.From.IsZero() {
+		conds, args = append(conds, "occurred_at >= ?"), append(args, f.From)
+	}
+	if !f.To.IsZero() {
+		conds, args = append(conds, "occurred_at < ?"), append(args, f.To)
+	}
+	if f.BeforeID != 0 {
+		conds, args = append(conds, "id < ?"), append(args, f.BeforeID)
+	}
+
+	query := "SELECT id, occurred_at, event, outcome, actor_user_id, target_user_id, ip_address, user_agent, request_id, COALESCE(details, '') FROM audit_logs"
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+	query += " ORDER BY id DESC LIMIT ?"
+	args = append(args, f.Limit)
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("FindAuditLogs: could not retrieve audit logs: %v", err)
+	}
+	defer rows.Close()
+
+	var logs []domain.AuditLog
+	for rows.Next() {
+		var l domain.AuditLog
+		if err := rows.Scan(&l.ID, &l.OccurredAt, &l.Event, &l.Outcome, &l.ActorUserID, &l.TargetUserID, &l.IPAddress, &l.UserAgent, &l.RequestID, &l.Details); err != nil {
+			return nil, fmt.Errorf("FindAuditLogs: error scanning audit log row: %v", err)
+		}
+		logs = append(logs, l)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("FindAuditLogs: error iterating audit log rows: %v", err)
+	}
+	return logs, nil
+}
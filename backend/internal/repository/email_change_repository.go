@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"backend/internal/database"
+	"backend/internal/domain"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+const emailChangeColumns = "id, user_id, old_email, new_email, confirm_token_hash, revert_token_hash, ip_address, " +
+	"expires_at, revert_expires_at, created_at, confirmed_at, reverted_at, cancelled_at"
+
+// CreateEmailChange はメールアドレス変更の申請を、ハッシュ化された確認・取り消しトークンとともに保存します。
+func CreateEmailChange(c *domain.EmailChange) (int64, error) {
+	query := "INSERT INTO email_changes (user_id, old_email, new_email, confirm_token_hash, revert_token_hash, ip_address, expires_at, revert_expires_at) " +
+		"VALUES (?, ?, ?, ?, ?, ?, ?, ?)"
+	result, err := database.DB.Exec(query, c.UserID, c.OldEmail, c.NewEmail, c.ConfirmTokenHash, c.RevertTokenHash, c.IPAddress, c.ExpiresAt, c.RevertExpiresAt)
+	if err != nil {
+		return 0, fmt.Errorf("CreateEmailChange: could not insert email change: %v", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("CreateEmailChange: could not retrieve last insert ID: %v", err)
+	}
+	return id, nil
+}
+
+// CancelPendingEmailChanges はユーザーの確認待ちのメールアドレス変更申請をすべて無効にします。
+func CancelPendingEmailChanges(userID int64) (int64, error) {
+	query := "UPDATE email_changes SET cancelled_at = CURRENT_TIMESTAMP " +
+		"WHERE user_id = ? AND confirmed_at IS NULL AND reverted_at IS NULL AND cancelled_at IS NULL"
+	result, err := database.DB.Exec(query, userID)
+	if err != nil {
+		return 0, fmt.Errorf("CancelPendingEmailChanges: could not cancel email changes for user %d: %v", userID, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("CancelPendingEmailChanges: could not get rows affected after update: %v", err)
+	}
+	return rowsAffected, nil
+}
+
+// ConfirmEmailChange は確認トークンに対応する申請を取得して確認済みにします (1回限り有効)。
+// 存在しない、または既に確認・取り消し・無効化済みの場合は nil を返します。
+func ConfirmEmailChange(confirmTokenHash string) (*domain.EmailChange, error) {
+	query := "SELECT " + emailChangeColumns + " FROM email_changes WHERE confirm_token_hash = ?"
+	c, err := scanEmailChange(database.DB.QueryRow(query, confirmTokenHash))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("ConfirmEmailChange: could not retrieve email change: %v", err)
+	}
+	if c.ConfirmedAt.Valid || c.RevertedAt.Valid || c.CancelledAt.Valid {
+		return nil, nil
+	}
+
+	// 同じリンクが並行して使われた場合や、直前に取り消された場合に備え、実際に更新できた場合のみ有効とします。
+	result, err := database.DB.Exec("UPDATE email_changes SET confirmed_at = CURRENT_TIMESTAMP "+
+		"WHERE id = ? AND confirmed_at IS NULL AND reverted_at IS NULL AND cancelled_at IS NULL", c.ID)
+	if err != nil {
+		return nil, fmt.Errorf("ConfirmEmailChange: could not mark email change as confirmed: %v", err)
+	}
+	if n, err := result.RowsAffected(); err != nil || n == 0 {
+		return nil, nil
+	}
+	c.ConfirmedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	return c, nil
+}
+
+// RevertEmailChange は取り消しトークンに対応する申請を取得して取り消し済みにします (1回限り有効)。
+// 確認済みかどうかに関わらず取り消せます。存在しない、または既に取り消し済みの場合は nil を返します。
+func RevertEmailChange(revertTokenHash string) (*domain.EmailChange, error) {
+	query := "SELECT " + emailChangeColumns + " FROM email_changes WHERE revert_token_hash = ?"
+	c, err := scanEmailChange(database.DB.QueryRow(query, revertTokenHash))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("RevertEmailChange: could not retrieve email change: %v", err)
+	}
+	if c.RevertedAt.Valid {
+		return nil, nil
+	}
+
+	result, err := database.DB.Exec("UPDATE email_changes SET reverted_at = CURRENT_TIMESTAMP WHERE id = ? AND reverted_at IS NULL", c.ID)
+	if err != nil {
+		return nil, fmt.Errorf("RevertEmailChange: could not mark email change as reverted: %v", err)
+	}
+	if n, err := result.RowsAffected(); err != nil || n == 0 {
+		return nil, nil
+	}
+	c.RevertedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	return c, nil
+}
+
+func scanEmailChange(row rowScanner) (*domain.EmailChange, error) {
+	var c domain.EmailChange
+	err := row.Scan(&c.ID, &c.UserID, &c.OldEmail, &c.NewEmail, &c.ConfirmTokenHash, &c.RevertTokenHash, &c.IPAddress,
+		&c.ExpiresAt, &c.RevertExpiresAt, &c.CreatedAt, &c.ConfirmedAt, &c.RevertedAt, &c.CancelledAt)
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"backend/internal/auth"
+	"backend/internal/database"
+	"backend/internal/domain"
+	"backend/internal/identifier"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+const inviteCodeColumns = "id, prefix, code_hash, created_by, note, role, email, max_uses, use_count, expires_at, created_at, revoked_at"
+
+// CreateInviteCode は招待コードを保存します。
+func CreateInviteCode(c *domain.InviteCode) (int64, error) {
+	query := "INSERT INTO invite_codes (prefix, code_hash, created_by, note, role, email, max_uses, expires_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)"
+
+	var email sql.NullString
+	if c.Email != "" {
+		email = sql.NullString{String: c.Email, Valid: true}
+	}
+	result, err := database.DB.Exec(query, c.Prefix, c.CodeHash, c.CreatedBy, c.Note, c.Role, email, c.MaxUses, c.ExpiresAt)
+	if err != nil {
+		return 0, fmt.Errorf("CreateInviteCode: could not insert invite code: %v", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("CreateInviteCode: could not retrieve last insert ID: %v", err)
+	}
+	return id, nil
+}
+
+// GetInviteCodeByHash はハッシュから招待コードを取得します。
+func GetInviteCodeByHash(codeHash string) (*domain.InviteCode, error) {
+	query := "SELECT " + inviteCodeColumns + " FROM invite_codes WHERE code_hash = ?"
+
+	c, err := scanInviteCode(database.DB.QueryRow(query, codeHash))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("GetInviteCodeByHash: could not retrieve invite code: %v", err)
+	}
+	return c, nil
+}
+
+// GetInviteCodes は招待コードを新しい順に返します。activeOnly が true の場合は、
+// now の時点で失効・使い切り・期限切れのいずれでもない招待コードのみを返します。
+func GetInviteCodes(activeOnly bool, now time.Time) ([]domain.InviteCode, error) {
+	query := "SELECT " + inviteCodeColumns + " FROM invite_codes"
+	var args []any
+	if activeOnly {
+		query += " WHERE revoked_at IS NULL AND use_count < max_uses AND expires_at > ?"
+		args = append(args, now)
+	}
+	query += " ORDER BY id DESC"
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("GetInviteCodes: could not retrieve invite codes: %v", err)
+	}
+	defer rows.Close()
+
+	var codes []domain.InviteCode
+	for rows.Next() {
+		c, err := scanInviteCode(rows)
+		if err != nil {
+			return nil, fmt.Errorf("GetInviteCodes: error scanning invite code row: %v", err)
+		}
+		codes = append(codes, *c)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("GetInviteCodes: error iterating invite code rows: %v", err)
+	}
+	return codes, nil
+}
+
+// RevokeInviteCode は招待コードを失効させます。既に失効済みの場合は 0 を返します。
+func RevokeInviteCode(id int64) (int64, error) {
+	result, err := database.DB.Exec("UPDATE invite_codes SET revoked_at = CURRENT_TIMESTAMP WHERE id = ? AND revoked_at IS NULL", id)
+	if err != nil {
+		return 0, fmt.Errorf("RevokeInviteCode: could not revoke invite code %d: %v", id, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("RevokeInviteCode: could not get rows affected after update: %v", err)
+	}
+	return rowsAffected, nil
+}
+
+// CreateUserWithInviteCode は招待コードの使用回数を1つ加算し、同じトランザクションでユーザーを作成します。
+// ユーザーには招待コードのロールを付与します。招待コードが now の時点で失効・使い切り・期限切れの場合
+// (並行して最後の1回が使われた場合を含みます) はユーザーを作成せずに 0 を返します。
+func CreateUserWithInviteCode(inviteCodeID int64, username string, password string, email string, now time.Time) (int64, error) {
+	hashedPassword, err := auth.HashPassword(password)
+	if err != nil {
+		return 0, fmt.Errorf("repository.CreateUserWithInviteCode: %w", err)
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("CreateUserWithInviteCode: could not begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("UPDATE invite_codes SET use_count = use_count + 1 WHERE id = ? AND revoked_at IS NULL AND use_count < max_uses AND expires_at > ?", inviteCodeID, now)
+	if err != nil {
+		return 0, fmt.Errorf("CreateUserWithInviteCode: could not update invite code %d: %v", inviteCodeID, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("CreateUserWithInviteCode: could not get rows affected after update: %v", err)
+	}
+	if rowsAffected == 0 {
+		return 0, nil
+	}
+
+	query := "INSERT INTO users (username, username_canonical, username_skeleton, password, email, role) " +
+		"SELECT ?, ?, ?, ?, ?, role FROM invite_codes WHERE id = ?"
+	result, err = tx.Exec(query, username, identifier.CanonicalUsername(username), identifier.UsernameSkeleton(username), hashedPassword, email, inviteCodeID)
+	if err != nil {
+		return 0, fmt.Errorf("CreateUserWithInviteCode: could not insert user: %v", err)
+	}
+	userID, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("CreateUserWithInviteCode: could not retrieve last insert ID: %v", err)
+	}
+
+	if _, err := tx.Exec("INSERT INTO invite_code_uses (invite_code_id, user_id) VALUES (?, ?)", inviteCodeID, userID); err != nil {
+		return 0, fmt.Errorf("CreateUserWithInviteCode: could not insert invite code use: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("CreateUserWithInviteCode: could not commit: %v", err)
+	}
+	return userID, nil
+}
+
+func scanInviteCode(row rowScanner) (*domain.InviteCode, error) {
+	var c domain.InviteCode
+	var email sql.NullString
+	err := row.Scan(&c.ID, &c.Prefix, &c.CodeHash, &c.CreatedBy, &c.Note, &c.Role, &email, &c.MaxUses, &c.UseCount, &c.ExpiresAt, &c.CreatedAt, &c.RevokedAt)
+	if err != nil {
+		return nil, err
+	}
+	c.Email = email.String
+	return &c, nil
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"backend/internal/database"
+	"backend/internal/domain"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// CreateMagicLink はハッシュ化されたログインリンクのトークンを保存します。
+func CreateMagicLink(userID int64, tokenHash string, ipAddress string, expiresAt time.Time) error {
+	query := "INSERT INTO magic_links (user_id, token_hash, ip_address, expires_at) VALUES (?, ?, ?, ?)"
+	if _, err := database.DB.Exec(query, userID, tokenHash, ipAddress, expiresAt); err != nil {
+		return fmt.Errorf("CreateMagicLink: could not insert magic link: %v", err)
+	}
+	return nil
+}
+
+// ConsumeMagicLink はログインリンクを取得して使用済みにします (1回限り有効)。
+// 存在しない、または既に使用済みの場合は nil を返します。
+func ConsumeMagicLink(tokenHash string) (*domain.MagicLink, error) {
+	query := "SELECT id, user_id, token_hash, ip_address, expires_at, created_at, consumed_at FROM magic_links WHERE token_hash = ?"
+
+	var m domain.MagicLink
+	err := database.DB.QueryRow(query, tokenHash).Scan(&m.ID, &m.UserID, &m.TokenHash, &m.IPAddress, &m.ExpiresAt, &m.CreatedAt, &m.ConsumedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("ConsumeMagicLink: could not retrieve magic link: %v", err)
+	}
+	if m.ConsumedAt.Valid {
+		return nil, nil
+	}
+
+	// 同じリンクが並行して使われた場合に備え、実際に更新できた場合のみ有効とします。
+	result, err := database.DB.Exec("UPDATE magic_links SET consumed_at = CURRENT_TIMESTAMP WHERE id = ? AND consumed_at IS NULL", m.ID)
+	if err != nil {
+		return nil, fmt.Errorf("ConsumeMagicLink: could not mark magic link as consumed: %v", err)
+	}
+	if n, err := result.RowsAffected(); err != nil || n == 0 {
+		return nil, nil
+	}
+	return &m, nil
+}
+
+// InvalidateMagicLinks はユーザーの未使用のログインリンクをすべて使用済みにします。
+// メールアドレスの変更後に、旧アドレスに送られたリンクでログインできないようにするために使用します。
+func InvalidateMagicLinks(userID int64) (int64, error) {
+	query := "UPDATE magic_links SET consumed_at = CURRENT_TIMESTAMP WHERE user_id = ? AND consumed_at IS NULL"
+	result, err := database.DB.Exec(query, userID)
+	if err != nil {
+		return 0, fmt.Errorf("InvalidateMagicLinks: could not invalidate magic links for user %d: %v", userID, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("InvalidateMagicLinks: could not get rows affected after update: %v", err)
+	}
+	return rowsAffected, nil
+}
@@ -0,0 +1,187 @@
+package repository
+
+import (
+	"backend/internal/database"
+	"backend/internal/domain"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// CreateOAuthClient はクライアントアプリケーションを登録します。
+func CreateOAuthClient(client *domain.OAuthClient) (int64, error) {
+	query := "INSERT INTO oauth_clients (client_id, client_secret_hash, name, redirect_uris, grant_types, scopes, owner_user_id) VALUES (?, ?, ?, ?, ?, ?, ?)"
+
+	result, err := database.DB.Exec(query, client.ClientID, client.ClientSecretHash, client.Name,
+		strings.Join(client.RedirectURIs, " "), strings.Join(client.GrantTypes, " "), strings.Join(client.Scopes, " "),
+		client.OwnerUserID)
+	if err != nil {
+		return 0, fmt.Errorf("CreateOAuthClient: could not insert client: %v", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("CreateOAuthClient: could not retrieve last insert ID: %v", err)
+	}
+	return id, nil
+}
+
+// GetOAuthClientByClientID はクライアントIDでクライアントを取得します。
+func GetOAuthClientByClientID(clientID string) (*domain.OAuthClient, error) {
+	query := "SELECT id, client_id, client_secret_hash, name, redirect_uris, grant_types, scopes, owner_user_id, created_at FROM oauth_clients WHERE client_id = ?"
+
+	c, err := scanOAuthClient(database.DB.QueryRow(query, clientID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("GetOAuthClientByClientID: could not retrieve client: %v", err)
+	}
+	return c, nil
+}
+
+// GetOAuthClientsByOwner はユーザーが登録したクライアントの一覧を返します。
+func GetOAuthClientsByOwner(ownerUserID int64) ([]domain.OAuthClient, error) {
+	query := "SELECT id, client_id, client_secret_hash, name, redirect_uris, grant_types, scopes, owner_user_id, created_at FROM oauth_clients WHERE owner_user_id = ? ORDER BY id"
+
+	rows, err := database.DB.Query(query, ownerUserID)
+	if err != nil {
+		return nil, fmt.Errorf("GetOAuthClientsByOwner: could not retrieve clients: %v", err)
+	}
+	defer rows.Close()
+
+	var clients []domain.OAuthClient
+	for rows.Next() {
+		c, err := scanOAuthClient(rows)
+		if err != nil {
+			return nil, fmt.Errorf("GetOAuthClientsByOwner: error scanning client row: %v", err)
+		}
+		clients = append(clients, *c)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("GetOAuthClientsByOwner: error iterating client rows: %v", err)
+	}
+	return clients, nil
+}
+
+// DeleteOAuthClient はユーザーが登録したクライアントを削除します。
+func DeleteOAuthClient(clientID string, ownerUserID int64) (int64, error) {
+	query := "DELETE FROM oauth_clients WHERE client_id = ? AND owner_user_id = ?"
+	result, err := database.DB.Exec(query, clientID, ownerUserID)
+	if err != nil {
+		return 0, fmt.Errorf("DeleteOAuthClient: could not delete client %s: %v", clientID, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("DeleteOAuthClient: could not get rows affected after delete: %v", err)
+	}
+	return rowsAffected, nil
+}
+
+// rowScanner は *sql.Row と *sql.Rows の共通インターフェースです。
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanOAuthClient(row rowScanner) (*domain.OAuthClient, error) {
+	var c domain.OAuthClient
+	var redirectURIs, grantTypes, scopes string
+	err := row.Scan(&c.ID, &c.ClientID, &c.ClientSecretHash, &c.Name, &redirectURIs, &grantTypes, &scopes, &c.OwnerUserID, &c.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	c.RedirectURIs = strings.Fields(redirectURIs)
+	c.GrantTypes = strings.Fields(grantTypes)
+	c.Scopes = strings.Fields(scopes)
+	return &c, nil
+}
+
+// CreateAuthorizationCode はハッシュ化された認可コードを保存します。
+func CreateAuthorizationCode(codeHash string, code *domain.OAuthAuthorizationCode) error {
+	query := "INSERT INTO oauth_authorization_codes (code_hash, client_id, user_id, redirect_uri, scope, nonce, code_challenge, auth_time, expires_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	_, err := database.DB.Exec(query, codeHash, code.ClientID, code.UserID, code.RedirectURI, code.Scope, code.Nonce, code.CodeChallenge, code.AuthTime, code.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("CreateAuthorizationCode: could not insert code: %v", err)
+	}
+	return nil
+}
+
+// ConsumeAuthorizationCode は認可コードを取得して削除します (1回限り有効)。
+func ConsumeAuthorizationCode(codeHash string) (*domain.OAuthAuthorizationCode, error) {
+	query := "SELECT id, client_id, user_id, redirect_uri, scope, nonce, code_challenge, auth_time, expires_at, created_at FROM oauth_authorization_codes WHERE code_hash = ?"
+
+	var c domain.OAuthAuthorizationCode
+	err := database.DB.QueryRow(query, codeHash).Scan(&c.ID, &c.ClientID, &c.UserID, &c.RedirectURI, &c.Scope, &c.Nonce, &c.CodeChallenge, &c.AuthTime, &c.ExpiresAt, &c.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("ConsumeAuthorizationCode: could not retrieve code: %v", err)
+	}
+
+	result, err := database.DB.Exec("DELETE FROM oauth_authorization_codes WHERE id = ?", c.ID)
+	if err != nil {
+		return nil, fmt.Errorf("ConsumeAuthorizationCode: could not delete code: %v", err)
+	}
+	if n, err := result.RowsAffected(); err != nil || n == 0 {
+		return nil, nil
+	}
+	return &c, nil
+}
+
+// CreateOAuthRefreshToken はクライアントに発行したリフレッシュトークンを保存します。
+func CreateOAuthRefreshToken(tokenHash string, clientID string, userID int64, scope string, expiresAt time.Time) error {
+	query := "INSERT INTO oauth_refresh_tokens (token_hash, client_id, user_id, scope, expires_at) VALUES (?, ?, ?, ?, ?)"
+	if _, err := database.DB.Exec(query, tokenHash, clientID, userID, scope, expiresAt); err != nil {
+		return fmt.Errorf("CreateOAuthRefreshToken: could not insert refresh token: %v", err)
+	}
+	return nil
+}
+
+// GetOAuthRefreshTokenByHash はハッシュからクライアントのリフレッシュトークンを取得します。
+func GetOAuthRefreshTokenByHash(tokenHash string) (*domain.OAuthRefreshToken, error) {
+	query := "SELECT id, client_id, user_id, scope, expires_at, revoked_at FROM oauth_refresh_tokens WHERE token_hash = ?"
+
+	var t domain.OAuthRefreshToken
+	err := database.DB.QueryRow(query, tokenHash).Scan(&t.ID, &t.ClientID, &t.UserID, &t.Scope, &t.ExpiresAt, &t.RevokedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("GetOAuthRefreshTokenByHash: could not retrieve refresh token: %v", err)
+	}
+	return &t, nil
+}
+
+// RevokeOAuthRefreshToken はクライアントのリフレッシュトークンを失効させます。
+func RevokeOAuthRefreshToken(id int64) (int64, error) {
+	query := "UPDATE oauth_refresh_tokens SET revoked_at = CURRENT_TIMESTAMP WHERE id = ? AND revoked_at IS NULL"
+	result, err := database.DB.Exec(query, id)
+	if err != nil {
+		return 0, fmt.Errorf("RevokeOAuthRefreshToken: could not revoke refresh token %d: %v", id, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("RevokeOAuthRefreshToken: could not get rows affected after update: %v", err)
+	}
+	return rowsAffected, nil
+}
+
+// RevokeOAuthRefreshTokensByUserID はユーザーがクライアントに許可したすべてのリフレッシュトークンを失効させます。
+func RevokeOAuthRefreshTokensByUserID(userID int64) (int64, error) {
+	query := "UPDATE oauth_refresh_tokens SET revoked_at = CURRENT_TIMESTAMP WHERE user_id = ? AND revoked_at IS NULL"
+	result, err := database.DB.Exec(query, userID)
+	if err != nil {
+		return 0, fmt.Errorf("RevokeOAuthRefreshTokensByUserID: could not revoke refresh tokens for user %d: %v", userID, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("RevokeOAuthRefreshTokensByUserID: could not get rows affected after update: %v", err)
+	}
+	return rowsAffected, nil
+}
@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"backend/internal/database"
+	"fmt"
+)
+
+// CreatePasswordHistory はユーザーが設定したパスワードのハッシュを履歴に追加し、
+// 直近 keep 件より古い履歴を削除します。
+func CreatePasswordHistory(userID int64, passwordHash string, keep int) error {
+	if _, err := database.DB.Exec("INSERT INTO password_history (user_id, password_hash) VALUES (?, ?)", userID, passwordHash); err != nil {
+		return fmt.Errorf("CreatePasswordHistory: could not insert password history: %v", err)
+	}
+
+	// MySQL では同じテーブルを参照するサブクエリに LIMIT を使えないため、派生テーブルを経由します。
+	query := `DELETE FROM password_history WHERE user_id = ? AND id NOT IN (
+		SELECT id FROM (SELECT id FROM password_history WHERE user_id = ? ORDER BY id DESC LIMIT ?) AS recent)`
+	if _, err := database.DB.Exec(query, userID, userID, keep); err != nil {
+		return fmt.Errorf("CreatePasswordHistory: could not prune password history: %v", err)
+	}
+	return nil
+}
+
+// GetRecentPasswordHashes はユーザーが直近に設定したパスワードのハッシュを新しい順に最大 limit 件返します。
+func GetRecentPasswordHashes(userID int64, limit int) ([]string, error) {
+	rows, err := database.DB.Query("SELECT password_hash FROM password_history WHERE user_id = ? ORDER BY id DESC LIMIT ?", userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("GetRecentPasswordHashes: could not retrieve password history: %v", err)
+	}
+	defer rows.Close()
+
+	var hashes []string
+	for rows.Next() {
+		var h string
+		if err := rows.Scan(&h); err != nil {
+			return nil, fmt.Errorf("GetRecentPasswordHashes: error scanning password history row: %v", err)
+		}
+		hashes = append(hashes, h)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("GetRecentPasswordHashes: error iterating password history rows: %v", err)
+	}
+	return hashes, nil
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"backend/internal/database"
+	"backend/internal/domain"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// CreateRefreshToken はハッシュ化されたリフレッシュトークンを保存します。
+func CreateRefreshToken(userID int64, sessionID int64, tokenHash string, expiresAt time.Time) (int64, error) {
+	query := "INSERT INTO refresh_tokens (user_id, session_id, token_hash, expires_at) VALUES (?, ?, ?, ?)"
+
+	result, err := database.DB.Exec(query, userID, sessionID, tokenHash, expiresAt)
+	if err != nil {
+		return 0, fmt.Errorf("CreateRefreshToken: could not insert refresh token: %v", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("CreateRefreshToken: could not retrieve last insert ID: %v", err)
+	}
+	return id, nil
+}
+
+// GetRefreshTokenByHash はトークンのハッシュからリフレッシュトークンを取得します。
+func GetRefreshTokenByHash(tokenHash string) (*domain.RefreshToken, error) {
+	query := "SELECT id, user_id, session_id, token_hash, expires_at, created_at, revoked_at FROM refresh_tokens WHERE token_hash = ?"
+
+	row := database.DB.QueryRow(query, tokenHash)
+
+	var t domain.RefreshToken
+	err := row.Scan(&t.ID, &t.UserID, &t.SessionID, &t.TokenHash, &t.ExpiresAt, &t.CreatedAt, &t.RevokedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("GetRefreshTokenByHash: could not retrieve refresh token: %v", err)
+	}
+	return &t, nil
+}
+
+// RevokeRefreshToken はリフレッシュトークンを失効させます。
+// すでに失効済みのトークンに対しては 0 を返します。
+func RevokeRefreshToken(id int64) (int64, error) {
+	query := "UPDATE refresh_tokens SET revoked_at = CURRENT_TIMESTAMP WHERE id = ? AND revoked_at IS NULL"
+	result, err := database.DB.Exec(query, id)
+	if err != nil {
+		return 0, fmt.Errorf("RevokeRefreshToken: could not revoke refresh token %d: %v", id, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("RevokeRefreshToken: could not get rows affected after update: %v", err)
+	}
+	return rowsAffected, nil
+}
+
+// RevokeRefreshTokensBySession はセッションに紐付くすべてのリフレッシュトークンを失効させます。
+func RevokeRefreshTokensBySession(sessionID int64) (int64, error) {
+	query := "UPDATE refresh_tokens SET revoked_at = CURRENT_TIMESTAMP WHERE session_id = ? AND revoked_at IS NULL"
+	result, err := database.DB.Exec(query, sessionID)
+	if err != nil {
+		return 0, fmt.Errorf("RevokeRefreshTokensBySession: could not revoke refresh tokens for session %d: %v", sessionID, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("RevokeRefreshTokensBySession: could not get rows affected after update: %v", err)
+	}
+	return rowsAffected, nil
+}
+
+// RevokeRefreshTokensByUserID はユーザーのすべてのリフレッシュトークンを失効させます。
+func RevokeRefreshTokensByUserID(userID int64) (int64, error) {
+	query := "UPDATE refresh_tokens SET revoked_at = CURRENT_TIMESTAMP WHERE user_id = ? AND revoked_at IS NULL"
+	result, err := database.DB.Exec(query, userID)
+	if err != nil {
+		return 0, fmt.Errorf("RevokeRefreshTokensByUserID: could not revoke refresh tokens for user %d: %v", userID, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("RevokeRefreshTokensByUserID: could not get rows affected after update: %v", err)
+	}
+	return rowsAffected, nil
+}
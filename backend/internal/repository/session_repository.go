@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"backend/internal/database"
+	"backend/internal/domain"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// CreateSession はログイン時に新しいセッションを記録します。
+func CreateSession(userID int64, userAgent string, ipAddress string) (int64, error) {
+	query := "INSERT INTO sessions (user_id, user_agent, ip_address) VALUES (?, ?, ?)"
+
+	result, err := database.DB.Exec(query, userID, userAgent, ipAddress)
+	if err != nil {
+		return 0, fmt.Errorf("CreateSession: could not insert session: %v", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("CreateSession: could not retrieve last insert ID: %v", err)
+	}
+	return id, nil
+}
+
+// CreateImpersonationSession は管理者による代理ログインのセッションを記録します。
+// 通常のセッションと異なり、expiresAt を過ぎると無効になります。
+func CreateImpersonationSession(userID int64, impersonatorUserID int64, userAgent string, ipAddress string, expiresAt time.Time) (int64, error) {
+	query := "INSERT INTO sessions (user_id, user_agent, ip_address, impersonator_user_id, expires_at) VALUES (?, ?, ?, ?, ?)"
+
+	result, err := database.DB.Exec(query, userID, userAgent, ipAddress, impersonatorUserID, expiresAt)
+	if err != nil {
+		return 0, fmt.Errorf("CreateImpersonationSession: could not insert session: %v", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("CreateImpersonationSession: could not retrieve last insert ID: %v", err)
+	}
+	return id, nil
+}
+
+// GetSessionByID は ID でセッションを取得します。
+func GetSessionByID(id int64) (*domain.Session, error) {
+	query := "SELECT id, user_id, user_agent, ip_address, created_at, last_seen_at, revoked_at, impersonator_user_id, expires_at FROM sessions WHERE id = ?"
+
+	row := database.DB.QueryRow(query, id)
+
+	var s domain.Session
+	err := row.Scan(&s.ID, &s.UserID, &s.UserAgent, &s.IPAddress, &s.CreatedAt, &s.LastSeenAt, &s.RevokedAt, &s.ImpersonatorUserID, &s.ExpiresAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("GetSessionByID: could not retrieve session with id %d: %v", id, err)
+	}
+	return &s, nil
+}
+
+// GetActiveSessionsByUserID はユーザーの失効していないセッションを最終アクセスの新しい順に返します。
+func GetActiveSessionsByUserID(userID int64) ([]domain.Session, error) {
+	query := "SELECT id, user_id, user_agent, ip_address, created_at, last_seen_at, revoked_at, impersonator_user_id, expires_at FROM sessions WHERE user_id = ? AND revoked_at IS NULL ORDER BY last_seen_at DESC"
+
+	rows, err := database.DB.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("GetActiveSessionsByUserID: could not retrieve sessions: %v", err)
+	}
+	defer rows.Close()
+
+	var sessions []domain.Session
+	for rows.Next() {
+		var s domain.Session
+		if err := rows.Scan(&s.ID, &s.UserID, &s.UserAgent, &s.IPAddress, &s.CreatedAt, &s.LastSeenAt, &s.RevokedAt, &s.ImpersonatorUserID, &s.ExpiresAt); err != nil {
+			return nil, fmt.Errorf("GetActiveSessionsByUserID: error scanning session row: %v", err)
+		}
+		sessions = append(sessions, s)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("GetActiveSessionsByUserID: error iterating session rows: %v", err)
+	}
+	return sessions, nil
+}
+
+// TouchSession はセッションの最終アクセス日時と IP アドレスを更新します。
+func TouchSession(id int64, ipAddress string) error {
+	query := "UPDATE sessions SET last_seen_at = CURRENT_TIMESTAMP, ip_address = ? WHERE id = ?"
+	if _, err := database.DB.Exec(query, ipAddress, id); err != nil {
+		return fmt.Errorf("TouchSession: could not update session %d: %v", id, err)
+	}
+	return nil
+}
+
+// RevokeSession はユーザーのセッションを失効させます。
+// 他のユーザーのセッションやすでに失効済みのセッションに対しては 0 を返します。
+func RevokeSession(id int64, userID int64) (int64, error) {
+	query := "UPDATE sessions SET revoked_at = CURRENT_TIMESTAMP WHERE id = ? AND user_id = ? AND revoked_at IS NULL"
+	result, err := database.DB.Exec(query, id, userID)
+	if err != nil {
+		return 0, fmt.Errorf("RevokeSession: could not revoke session %d: %v", id, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("RevokeSession: could not get rows affected after update: %v", err)
+	}
+	return rowsAffected, nil
+}
+
+// RevokeSessionsByUserID はユーザーのすべての有効なセッションを失効させ、失効させた件数を返します。
+func RevokeSessionsByUserID(userID int64) (int64, error) {
+	query := "UPDATE sessions SET revoked_at = CURRENT_TIMESTAMP WHERE user_id = ? AND revoked_at IS NULL"
+	result, err := database.DB.Exec(query, userID)
+	if err != nil {
+		return 0, fmt.Errorf("RevokeSessionsByUserID: could not revoke sessions for user %d: %v", userID, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("RevokeSessionsByUserID: could not get rows affected after update: %v", err)
+	}
+	return rowsAffected, nil
+}
@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"backend/internal/database"
+	"backend/internal/domain"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// CreateUserIdentity は外部プロバイダーのアカウントをユーザーに紐付けます。
+func CreateUserIdentity(userID int64, provider string, subject string, email string) (int64, error) {
+	query := "INSERT INTO user_identities (user_id, provider, subject, email) VALUES (?, ?, ?, ?)"
+
+	result, err := database.DB.Exec(query, userID, provider, subject, email)
+	if err != nil {
+		return 0, fmt.Errorf("CreateUserIdentity: could not insert identity: %v", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("CreateUserIdentity: could not retrieve last insert ID: %v", err)
+	}
+	return id, nil
+}
+
+// GetUserIdentity はプロバイダーとサブジェクトから紐付けを取得します。
+func GetUserIdentity(provider string, subject string) (*domain.UserIdentity, error) {
+	query := "SELECT id, user_id, provider, subject, email, created_at FROM user_identities WHERE provider = ? AND subject = ?"
+
+	row := database.DB.QueryRow(query, provider, subject)
+
+	var i domain.UserIdentity
+	err := row.Scan(&i.ID, &i.UserID, &i.Provider, &i.Subject, &i.Email, &i.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("GetUserIdentity: could not retrieve identity: %v", err)
+	}
+	return &i, nil
+}
+
+// GetUserIdentitiesByUserID はユーザーに紐付くすべての外部アカウントを返します。
+func GetUserIdentitiesByUserID(userID int64) ([]domain.UserIdentity, error) {
+	query := "SELECT id, user_id, provider, subject, email, created_at FROM user_identities WHERE user_id = ? ORDER BY id"
+
+	rows, err := database.DB.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("GetUserIdentitiesByUserID: could not retrieve identities: %v", err)
+	}
+	defer rows.Close()
+
+	var identities []domain.UserIdentity
+	for rows.Next() {
+		var i domain.UserIdentity
+		if err := rows.Scan(&i.ID, &i.UserID, &i.Provider, &i.Subject, &i.Email, &i.CreatedAt); err != nil {
+			return nil, fmt.Errorf("GetUserIdentitiesByUserID: error scanning identity row: %v", err)
+		}
+		identities = append(identities, i)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("GetUserIdentitiesByUserID: error iterating identity rows: %v", err)
+	}
+	return identities, nil
+}
+
+// DeleteUserIdentity はユーザーとプロバイダーの紐付けを解除します。
+func DeleteUserIdentity(userID int64, provider string) (int64, error) {
+	query := "DELETE FROM user_identities WHERE user_id = ? AND provider = ?"
+	result, err := database.DB.Exec(query, userID, provider)
+	if err != nil {
+		return 0, fmt.Errorf("DeleteUserIdentity: could not delete %s identity of user %d: %v", provider, userID, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("DeleteUserIdentity: could not get rows affected after delete: %v", err)
+	}
+	return rowsAffected, nil
+}
+
+// CreateOAuthState は認可リクエストの state を保存します。
+func CreateOAuthState(stateHash string, provider string, codeVerifier string, nonce string, linkUserID sql.NullInt64, expiresAt time.Time) error {
+	query := "INSERT INTO oauth_states (state_hash, provider, code_verifier, nonce, link_user_id, expires_at) VALUES (?, ?, ?, ?, ?, ?)"
+	if _, err := database.DB.Exec(query, stateHash, provider, codeVerifier, nonce, linkUserID, expiresAt); err != nil {
+		return fmt.Errorf("CreateOAuthState: could not insert state: %v", err)
+	}
+	return nil
+}
+
+// ConsumeOAuthState は state を取得して削除します (1回限り有効)。
+// 並行して同じ state が使われた場合は、削除に成功した方だけが値を受け取ります。
+func ConsumeOAuthState(stateHash string) (*domain.OAuthState, error) {
+	query := "SELECT id, provider, code_verifier, nonce, link_user_id, expires_at FROM oauth_states WHERE state_hash = ?"
+
+	row := database.DB.QueryRow(query, stateHash)
+
+	var s domain.OAuthState
+	err := row.Scan(&s.ID, &s.Provider, &s.CodeVerifier, &s.Nonce, &s.LinkUserID, &s.ExpiresAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("ConsumeOAuthState: could not retrieve state: %v", err)
+	}
+
+	result, err := database.DB.Exec("DELETE FROM oauth_states WHERE id = ?", s.ID)
+	if err != nil {
+		return nil, fmt.Errorf("ConsumeOAuthState: could not delete state: %v", err)
+	}
+	if n, err := result.RowsAffected(); err != nil || n == 0 {
+		return nil, nil
+	}
+	return &s, nil
+}
@@ -4,20 +4,39 @@ import (
 	"backend/internal/auth"
 	"backend/internal/database"
 	"backend/internal/domain"
+	"backend/internal/identifier"
 	"database/sql"
 	"fmt"
+	"strings"
+	"time"
 )
 
+// userColumns は1人分のユーザーを取得するときの列です。scanUser と同じ順序にしてください。
+const userColumns = "id, username, password, email, role, created_at, updated_at, deleted_at, email_verified_at, display_name, bio, locale, timezone, avatar_key, " +
+	"suspended_at, suspension_reason, password_change_required"
+
+// scanUser は userColumns の順序で1行分のユーザーを読み取ります。
+func scanUser(row rowScanner) (*domain.User, error) {
+	var u domain.User
+	err := row.Scan(&u.ID, &u.Username, &u.Password, &u.Email, &u.Role, &u.CreatedAt, &u.UpdatedAt, &u.DeletedAt, &u.EmailVerifiedAt,
+		&u.DisplayName, &u.Bio, &u.Locale, &u.Timezone, &u.AvatarKey, &u.SuspendedAt, &u.SuspensionReason, &u.PasswordChangeRequired)
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
 // CreateUser はハッシュ化されたパスワードで新しいユーザーをデータベースに保存します。
+// ユーザー名の正規形とスケルトンも合わせて保存します。
 func CreateUser(username string, password string, email string) (int64, error) {
 	hashedPassword, err := auth.HashPassword(password)
 	if err != nil {
 		return 0, fmt.Errorf("repository.CreateUser: %w", err)
 	}
 
-	query := "INSERT INTO users (username, password, email) VALUES (?, ?, ?)"
+	query := "INSERT INTO users (username, username_canonical, username_skeleton, password, email) VALUES (?, ?, ?, ?, ?)"
 
-	result, err := database.DB.Exec(query, username, hashedPassword, email)
+	result, err := database.DB.Exec(query, username, identifier.CanonicalUsername(username), identifier.UsernameSkeleton(username), hashedPassword, email)
 	if err != nil {
 		return 0, fmt.Errorf("could not insert user: %v", err)
 	}
@@ -32,59 +51,219 @@ func CreateUser(username string, password string, email string) (int64, error) {
 
 // GetUserByID
 func GetUserByID(id int64) (*domain.User, error) {
-	query := "SELECT id, username, password, email, created_at, updated_at FROM users WHERE id = ?"
+	query := "SELECT " + userColumns + " FROM users WHERE id = ?"
 
 	row := database.DB.QueryRow(query, id)
 
-	var u domain.User
-
-	err := row.Scan(&u.ID, &u.Username, &u.Password, &u.Email, &u.CreatedAt, &u.UpdatedAt)
+	u, err := scanUser(row)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
 		}
 		return nil, fmt.Errorf("GetUserByID: could not retrieve user with id %d: %v", id, err)
 	}
-	return &u, nil
+	return u, nil
 }
 
-// GetAllUsers
-func GetAllUsers() ([]domain.User, error) {
-	query := "SELECT id, username, password, email, created_at, updated_at FROM users"
+// ユーザー一覧の並べ替えに使える列です。
+const (
+	UserSortID        = "id"
+	UserSortCreatedAt = "created_at"
+	UserSortUsername  = "username"
+	UserSortEmail     = "email"
+)
+
+// ユーザー一覧の削除状態の条件です。
+const (
+	UserStatusActive    = "active"
+	UserStatusDeleted   = "deleted"
+	UserStatusSuspended = "suspended" // 削除されていない停止中のユーザー
+	UserStatusAll       = "all"
+)
+
+// UserFilter はユーザー一覧の検索条件です。ゼロ値の項目は条件に含めません。
+type UserFilter struct {
+	UsernamePrefix string
+	EmailPrefix    string
+	CreatedFrom    time.Time
+	CreatedTo      time.Time
+	Status         string // active (既定) / deleted / suspended / all
+	Verified       *bool  // メールアドレスの確認状態
+}
 
-	// db.Query
-	rows, err := database.DB.Query(query)
+// UserPageRequest はユーザー一覧の1ページ分の取得条件です。
+// After が設定されている場合は、並び順で (AfterValue, AfterID) より後のユーザーを返します (キーセットページング)。
+type UserPageRequest struct {
+	Sort       string // UserSort* のいずれか
+	Desc       bool
+	After      bool
+	AfterValue any // Sort の列の値 (id の場合は使いません)
+	AfterID    int64
+	Limit      int
+}
+
+// FindUsers は条件に一致するユーザーを指定された順に最大 Limit 件返します。
+// 同じ値の行の順序を固定するため、常に id を第2キーとして並べます。
+func FindUsers(f UserFilter, page UserPageRequest) ([]domain.User, error) {
+	var column string
+	switch page.Sort {
+	case UserSortID, UserSortCreatedAt, UserSortUsername, UserSortEmail:
+		column = page.Sort
+	default:
+		return nil, fmt.Errorf("FindUsers: unsupported sort column %q", page.Sort)
+	}
+
+	conds, args := userFilterConditions(f)
+	op, order := ">", "ASC"
+	if page.Desc {
+		op, order = "<", "DESC"
+	}
+	if page.After {
+		if column == UserSortID {
+			conds, args = append(conds, "id "+op+" ?"), append(args, page.AfterID)
+		} else {
+			conds = append(conds, fmt.Sprintf("(%s %s ? OR (%s = ? AND id %s ?))", column, op, column, op))
+			args = append(args, page.AfterValue, page.AfterValue, page.AfterID)
+		}
+	}
+
+	// 一覧にはパスワードのハッシュは不要なため取得しません。
+	query := "SELECT id, username, email, role, created_at, updated_at, deleted_at, email_verified_at, display_name, bio, locale, timezone, avatar_key, " +
+		"suspended_at, suspension_reason, password_change_required FROM users"
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+	if column == UserSortID {
+		query += " ORDER BY id " + order
+	} else {
+		query += fmt.Sprintf(" ORDER BY %s %s, id %s", column, order, order)
+	}
+	query += " LIMIT ?"
+	args = append(args, page.Limit)
+
+	rows, err := database.DB.Query(query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("GetAllUsers: could not retrieve users: %v", err)
+		return nil, fmt.Errorf("FindUsers: could not retrieve users: %v", err)
 	}
 	defer rows.Close()
 
 	var users []domain.User
-
 	for rows.Next() {
 		var u domain.User
-		if err := rows.Scan(&u.ID, &u.Username, &u.Password, &u.Email, &u.CreatedAt, &u.UpdatedAt); err != nil {
-			return nil, fmt.Errorf("GetAllUsers: error scanning user row: %v", err)
+		if err := rows.Scan(&u.ID, &u.Username, &u.Email, &u.Role, &u.CreatedAt, &u.UpdatedAt, &u.DeletedAt, &u.EmailVerifiedAt,
+			&u.DisplayName, &u.Bio, &u.Locale, &u.Timezone, &u.AvatarKey, &u.SuspendedAt, &u.SuspensionReason, &u.PasswordChangeRequired); err != nil {
+			return nil, fmt.Errorf("FindUsers: error scanning user row: %v", err)
 		}
 		users = append(users, u)
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("GetAllUsers: error iterating user rows: %v", err)
+		return nil, fmt.Errorf("FindUsers: error iterating user rows: %v", err)
 	}
-
 	return users, nil
 }
 
-//名前でユーザーを取得する
-func GetUserByUsername(username string) (*domain.User, error) {
-	query := "SELECT id, username, password, email, created_at, updated_at,deleted_at FROM users WHERE username= ? AND deleted_at IS NULL"
+// CountUsers は条件に一致するユーザーの総数を返します。
+func CountUsers(f UserFilter) (int64, error) {
+	conds, args := userFilterConditions(f)
+	query := "SELECT COUNT(*) FROM users"
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
 
-	row := database.DB.QueryRow(query, username)
+	var total int64
+	if err := database.DB.QueryRow(query, args...).Scan(&total); err != nil {
+		return 0, fmt.Errorf("CountUsers: could not count users: %v", err)
+	}
+	return total, nil
+}
 
-	var u domain.User
+// userFilterConditions は検索条件を WHERE 句の条件と引数に変換します。
+func userFilterConditions(f UserFilter) ([]string, []any) {
+	var conds []string
+	var args []any
+	if f.UsernamePrefix != "" {
+		conds, args = append(conds, "username LIKE ?"), append(args, escapeLike(f.UsernamePrefix)+"%")
+	}
+	if f.EmailPrefix != "" {
+		conds, args = append(conds, "email LIKE ?"), append(args, escapeLike(f.EmailPrefix)+"%")
+	}
+	if !f.CreatedFrom.IsZero() {
+		conds, args = append(conds, "created_at >= ?"), append(args, f.CreatedFrom)
+	}
+	if !f.CreatedTo.IsZero() {
+		conds, args = append(conds, "created_at < ?"), append(args, f.CreatedTo)
+	}
+	switch f.Status {
+	case UserStatusDeleted:
+		conds = append(conds, "deleted_at IS NOT NULL")
+	case UserStatusSuspended:
+		conds = append(conds, "deleted_at IS NULL", "suspended_at IS NOT NULL")
+	case UserStatusAll:
+	default:
+		conds = append(conds, "deleted_at IS NULL")
+	}
+	if f.Verified != nil {
+		if *f.Verified {
+			conds = append(conds, "email_verified_at IS NOT NULL")
+		} else {
+			conds = append(conds, "email_verified_at IS NULL")
+		}
+	}
+	return conds, args
+}
+
+// escapeLike は LIKE のパターンで特別な意味を持つ文字をエスケープします。
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// UpdateUserProfile はユーザーのプロフィール (表示名・自己紹介・言語・タイムゾーン) を更新します。
+func UpdateUserProfile(id int64, displayName, bio, locale, timezone string) (int64, error) {
+	query := "UPDATE users SET display_name = ?, bio = ?, locale = ?, timezone = ? WHERE id = ? AND deleted_at IS NULL"
+	result, err := database.DB.Exec(query, displayName, bio, locale, timezone, id)
+	if err != nil {
+		return 0, fmt.Errorf("UpdateUserProfile: could not update profile for user %d: %v", id, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("UpdateUserProfile: could not get rows affected after update: %v", err)
+	}
+	return rowsAffected, nil
+}
+
+// UpdateUserAvatar はユーザーのアバター画像のキーを更新します。空文字列の場合はアバターを削除します。
+func UpdateUserAvatar(id int64, avatarKey string) (int64, error) {
+	query := "UPDATE users SET avatar_key = ? WHERE id = ? AND deleted_at IS NULL"
+	result, err := database.DB.Exec(query, avatarKey, id)
+	if err != nil {
+		return 0, fmt.Errorf("UpdateUserAvatar: could not update avatar for user %d: %v", id, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("UpdateUserAvatar: could not get rows affected after update: %v", err)
+	}
+	return rowsAffected, nil
+}
+
+// MarkUserEmailVerified はユーザーのメールアドレスを確認済みにします。確認済みの場合は何もしません。
+func MarkUserEmailVerified(id int64) error {
+	query := "UPDATE users SET email_verified_at = CURRENT_TIMESTAMP WHERE id = ? AND email_verified_at IS NULL"
+	if _, err := database.DB.Exec(query, id); err != nil {
+		return fmt.Errorf("MarkUserEmailVerified: could not update user %d: %v", id, err)
+	}
+	return nil
+}
+
+// GetUserByUsername はユーザー名の正規形で削除されていないユーザーを取得します ("Taro" と "ｔａｒｏ" は同じユーザーです)。
+// 正規形が未設定の既存の行は、ユーザー名の完全一致で検索します。
+func GetUserByUsername(username string) (*domain.User, error) {
+	query := "SELECT " + userColumns + " FROM users " +
+		"WHERE (username_canonical = ? OR (username_canonical IS NULL AND username = ?)) AND deleted_at IS NULL"
+
+	row := database.DB.QueryRow(query, identifier.CanonicalUsername(username), username)
 
-	err := row.Scan(&u.ID, &u.Username, &u.Password, &u.Email, &u.CreatedAt, &u.UpdatedAt, &u.DeletedAt)
+	u, err := scanUser(row)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -93,26 +272,150 @@ func GetUserByUsername(username string) (*domain.User, error) {
 		return nil, fmt.Errorf("repository.GetUserByUsername: データベースクエリエラー: %w", err)
 	}
 
-	return &u, nil
+	return u, nil
 
 }
 
-func UpdateUserEmail(id int64, newEmail string) (int64, error) {
-	query := "UPDATE users SET email = ? WHERE id = ?"
-	result, err := database.DB.Exec(query, newEmail, id)
+// UsernameInUse はユーザー名と正規形またはスケルトンが同じユーザーが excludeUserID 以外にいるか返します。
+// 削除済みのユーザーも一意制約の対象のため含めます。正規形が未設定の既存の行はユーザー名の完全一致で比較します。
+func UsernameInUse(username string, excludeUserID int64) (bool, error) {
+	query := "SELECT COUNT(*) FROM users WHERE (username_canonical = ? OR username_skeleton = ? OR (username_canonical IS NULL AND username = ?)) AND id <> ?"
+
+	var count int64
+	err := database.DB.QueryRow(query, identifier.CanonicalUsername(username), identifier.UsernameSkeleton(username), username, excludeUserID).Scan(&count)
 	if err != nil {
-		return 0, fmt.Errorf("UpdateUserEmail: could not update user email for id %d: %v", id, err)
+		return false, fmt.Errorf("UsernameInUse: could not count users: %v", err)
+	}
+	return count > 0, nil
+}
+
+// GetUsersWithoutCanonicalUsername は正規形が未設定のユーザーの ID とユーザー名を ID 順に最大 limit 件返します。
+func GetUsersWithoutCanonicalUsername(afterID int64, limit int) ([]domain.User, error) {
+	query := "SELECT id, username FROM users WHERE username_canonical IS NULL AND id > ? ORDER BY id LIMIT ?"
+	rows, err := database.DB.Query(query, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("GetUsersWithoutCanonicalUsername: could not query users: %v", err)
+	}
+	defer rows.Close()
+
+	var users []domain.User
+	for rows.Next() {
+		var u domain.User
+		if err := rows.Scan(&u.ID, &u.Username); err != nil {
+			return nil, fmt.Errorf("GetUsersWithoutCanonicalUsername: could not scan user row: %v", err)
+		}
+		users = append(users, u)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("GetUsersWithoutCanonicalUsername: error iterating user rows: %v", err)
+	}
+	return users, nil
+}
+
+// SetCanonicalUsername は既存のユーザーのユーザー名から正規形とスケルトンを計算して保存します。
+func SetCanonicalUsername(id int64, username string) error {
+	query := "UPDATE users SET username_canonical = ?, username_skeleton = ? WHERE id = ? AND username = ?"
+	if _, err := database.DB.Exec(query, identifier.CanonicalUsername(username), identifier.UsernameSkeleton(username), id, username); err != nil {
+		return fmt.Errorf("SetCanonicalUsername: could not update user %d: %v", id, err)
+	}
+	return nil
+}
+
+// GetUserByEmail はメールアドレスで削除されていないユーザーを取得します。
+func GetUserByEmail(email string) (*domain.User, error) {
+	query := "SELECT " + userColumns + " FROM users WHERE email = ? AND deleted_at IS NULL"
+
+	row := database.DB.QueryRow(query, email)
+
+	u, err := scanUser(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("repository.GetUserByEmail: データベースクエリエラー: %w", err)
+	}
+	return u, nil
+}
+
+// EmailInUse はメールアドレスを excludeUserID 以外のユーザーが使用しているか返します。
+// 削除済みのユーザーも一意制約の対象のため含めます。
+func EmailInUse(email string, excludeUserID int64) (bool, error) {
+	var count int64
+	err := database.DB.QueryRow("SELECT COUNT(*) FROM users WHERE email = ? AND id <> ?", email, excludeUserID).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("EmailInUse: could not count users: %v", err)
+	}
+	return count > 0, nil
+}
+
+// CreateInvitedUser は一括登録で招待するユーザーをパスワードなしで作成します。
+// 招待メールのログインリンクでログインするまで、メールアドレスは未確認のままです。
+func CreateInvitedUser(username string, email string) (int64, error) {
+	query := "INSERT INTO users (username, username_canonical, username_skeleton, password, email) VALUES (?, ?, ?, '', ?)"
+
+	result, err := database.DB.Exec(query, username, identifier.CanonicalUsername(username), identifier.UsernameSkeleton(username), email)
+	if err != nil {
+		return 0, fmt.Errorf("CreateInvitedUser: could not insert user: %v", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("CreateInvitedUser: could not retrieve last insert ID: %v", err)
+	}
+	return id, nil
+}
+
+// CreateExternalUser は外部プロバイダーでログインしたユーザーをパスワードなしで作成し、
+// 同じトランザクションで外部アカウントとの連携を登録します。
+// 連携の登録に失敗した場合 (同じ外部アカウントで並行してログインした場合など) はユーザーも作成しません。
+// パスワードは空文字列で保存されるため、パスワードによるログインはできません。
+// メールアドレスはプロバイダーで確認済みのもののみ渡されるため、確認済みとして保存します。
+func CreateExternalUser(username string, email string, provider string, subject string) (int64, error) {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("CreateExternalUser: could not begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	query := "INSERT INTO users (username, username_canonical, username_skeleton, password, email, email_verified_at) VALUES (?, ?, ?, '', ?, CURRENT_TIMESTAMP)"
+	result, err := tx.Exec(query, username, identifier.CanonicalUsername(username), identifier.UsernameSkeleton(username), email)
+	if err != nil {
+		return 0, fmt.Errorf("CreateExternalUser: could not insert user: %v", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("CreateExternalUser: could not retrieve last insert ID: %v", err)
+	}
+
+	query = "INSERT INTO user_identities (user_id, provider, subject, email) VALUES (?, ?, ?, ?)"
+	if _, err := tx.Exec(query, id, provider, subject, email); err != nil {
+		return 0, fmt.Errorf("CreateExternalUser: could not insert identity: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("CreateExternalUser: could not commit: %v", err)
+	}
+	return id, nil
+}
+
+// ChangeUserEmail はユーザーのメールアドレスが fromEmail のままである場合に限り toEmail に変更し、確認済みにします。
+// 変更の確認・取り消しリンクを受け取れたことでアドレスの確認も済んでいるため、確認日時を更新します。
+// 申請後に別の経路でアドレスが変わっていた場合や、ユーザーが削除済みの場合は 0 を返します。
+func ChangeUserEmail(id int64, fromEmail string, toEmail string) (int64, error) {
+	query := "UPDATE users SET email = ?, email_verified_at = CURRENT_TIMESTAMP WHERE id = ? AND email = ? AND deleted_at IS NULL"
+	result, err := database.DB.Exec(query, toEmail, id, fromEmail)
+	if err != nil {
+		return 0, fmt.Errorf("ChangeUserEmail: could not update user email for id %d: %v", id, err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return 0, fmt.Errorf("UpdateUserEmail: could not get rows affected after update: %v", err)
+		return 0, fmt.Errorf("ChangeUserEmail: could not get rows affected after update: %v", err)
 	}
 
 	return rowsAffected, nil
 }
 
-
 func UpdateUserPassword(newPassword string, id int64) (int64, error) {
 	query := "UPDATE users SET password = ?  WHERE id = ?"
 	result, err := database.DB.Exec(query, newPassword, id)
@@ -127,6 +430,49 @@ func UpdateUserPassword(newPassword string, id int64) (int64, error) {
 	return rowsAffected, nil
 }
 
+// SuspendUser は削除されていない、停止中でないユーザーを停止します。
+// 対象のユーザーが存在しない、削除済み、またはすでに停止中の場合は 0 を返します。
+func SuspendUser(id int64, reason string) (int64, error) {
+	query := "UPDATE users SET suspended_at = CURRENT_TIMESTAMP, suspension_reason = ? WHERE id = ? AND deleted_at IS NULL AND suspended_at IS NULL"
+	result, err := database.DB.Exec(query, reason, id)
+	if err != nil {
+		return 0, fmt.Errorf("SuspendUser: could not suspend user %d: %v", id, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("SuspendUser: could not get rows affected after update: %v", err)
+	}
+	return rowsAffected, nil
+}
+
+// UnsuspendUser はユーザーの停止を解除します。停止中でない場合は 0 を返します。
+func UnsuspendUser(id int64) (int64, error) {
+	query := "UPDATE users SET suspended_at = NULL, suspension_reason = '' WHERE id = ? AND deleted_at IS NULL AND suspended_at IS NOT NULL"
+	result, err := database.DB.Exec(query, id)
+	if err != nil {
+		return 0, fmt.Errorf("UnsuspendUser: could not unsuspend user %d: %v", id, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("UnsuspendUser: could not get rows affected after update: %v", err)
+	}
+	return rowsAffected, nil
+}
+
+// SetPasswordChangeRequired は次回ログイン時にパスワードの変更を求めるかどうかを設定します。
+func SetPasswordChangeRequired(id int64, required bool) (int64, error) {
+	query := "UPDATE users SET password_change_required = ? WHERE id = ? AND deleted_at IS NULL"
+	result, err := database.DB.Exec(query, required, id)
+	if err != nil {
+		return 0, fmt.Errorf("SetPasswordChangeRequired: could not update user %d: %v", id, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("SetPasswordChangeRequired: could not get rows affected after update: %v", err)
+	}
+	return rowsAffected, nil
+}
+
 func DeleteUser(id int64) (int64, error) {
 	query := "DELETE FROM users WHERE id = ?"
 	result, err := database.DB.Exec(query, id)
@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"backend/internal/database"
+	"backend/internal/domain"
+	"backend/internal/identifier"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+const usernameHistoryColumns = "id, user_id, username, changed_at, held_until"
+
+// ChangeUsername はユーザー名が oldUsername のままである場合に限り newUsername に変更し、
+// 変更前のユーザー名を heldUntil まで保持する履歴として記録します。
+// 並行して変更された場合や、ユーザーが削除済みの場合は 0 を返します。
+func ChangeUsername(userID int64, oldUsername string, newUsername string, heldUntil time.Time) (int64, error) {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("ChangeUsername: could not begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("UPDATE users SET username = ?, username_canonical = ?, username_skeleton = ? WHERE id = ? AND username = ? AND deleted_at IS NULL",
+		newUsername, identifier.CanonicalUsername(newUsername), identifier.UsernameSkeleton(newUsername), userID, oldUsername)
+	if err != nil {
+		return 0, fmt.Errorf("ChangeUsername: could not update username for user %d: %v", userID, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("ChangeUsername: could not get rows affected after update: %v", err)
+	}
+	if rowsAffected == 0 {
+		return 0, nil
+	}
+
+	query := "INSERT INTO username_history (user_id, username, username_canonical, username_skeleton, held_until) VALUES (?, ?, ?, ?, ?)"
+	if _, err := tx.Exec(query, userID, oldUsername, identifier.CanonicalUsername(oldUsername), identifier.UsernameSkeleton(oldUsername), heldUntil); err != nil {
+		return 0, fmt.Errorf("ChangeUsername: could not insert username history: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("ChangeUsername: could not commit: %v", err)
+	}
+	return rowsAffected, nil
+}
+
+// GetLatestUsernameHistory は指定したユーザー名 (正規形で比較します) が最後に手放されたときの履歴を取得します。
+// 一度も変更前のユーザー名として使われていない場合は nil を返します。
+func GetLatestUsernameHistory(username string) (*domain.UsernameHistory, error) {
+	query := "SELECT " + usernameHistoryColumns + " FROM username_history WHERE username_canonical = ? ORDER BY changed_at DESC, id DESC LIMIT 1"
+	h, err := scanUsernameHistory(database.DB.QueryRow(query, identifier.CanonicalUsername(username)))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("GetLatestUsernameHistory: could not retrieve username history: %v", err)
+	}
+	return h, nil
+}
+
+// UsernameHeld はユーザー名と正規形またはスケルトンが同じユーザー名が、excludeUserID 以外のユーザーのために
+// now の時点で保持されているかどうかを返します。
+func UsernameHeld(username string, excludeUserID int64, now time.Time) (bool, error) {
+	query := "SELECT COUNT(*) FROM username_history WHERE (username_canonical = ? OR username_skeleton = ?) AND held_until > ? AND user_id <> ?"
+
+	var count int64
+	err := database.DB.QueryRow(query, identifier.CanonicalUsername(username), identifier.UsernameSkeleton(username), now, excludeUserID).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("UsernameHeld: could not count username history: %v", err)
+	}
+	return count > 0, nil
+}
+
+// GetLatestUsernameChangeByUserID はユーザーが最後にユーザー名を変更したときの履歴を取得します。
+// 一度も変更していない場合は nil を返します。
+func GetLatestUsernameChangeByUserID(userID int64) (*domain.UsernameHistory, error) {
+	query := "SELECT " + usernameHistoryColumns + " FROM username_history WHERE user_id = ? ORDER BY changed_at DESC, id DESC LIMIT 1"
+	h, err := scanUsernameHistory(database.DB.QueryRow(query, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("GetLatestUsernameChangeByUserID: could not retrieve username history for user %d: %v", userID, err)
+	}
+	return h, nil
+}
+
+// BackfillUsernameHistoryCanonical は正規形またはスケルトンが未設定の履歴に、変更前のユーザー名の正規形とスケルトンを保存します。
+// 更新した件数を返します。
+func BackfillUsernameHistoryCanonical() (int64, error) {
+	rows, err := database.DB.Query("SELECT id, username FROM username_history WHERE username_canonical = '' OR username_skeleton = ''")
+	if err != nil {
+		return 0, fmt.Errorf("BackfillUsernameHistoryCanonical: could not query username history: %v", err)
+	}
+	type pending struct {
+		id       int64
+		username string
+	}
+	var items []pending
+	for rows.Next() {
+		var p pending
+		if err := rows.Scan(&p.id, &p.username); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("BackfillUsernameHistoryCanonical: could not scan username history row: %v", err)
+		}
+		items = append(items, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("BackfillUsernameHistoryCanonical: error iterating username history rows: %v", err)
+	}
+
+	var updated int64
+	for _, p := range items {
+		query := "UPDATE username_history SET username_canonical = ?, username_skeleton = ? WHERE id = ?"
+		if _, err := database.DB.Exec(query, identifier.CanonicalUsername(p.username), identifier.UsernameSkeleton(p.username), p.id); err != nil {
+			return updated, fmt.Errorf("BackfillUsernameHistoryCanonical: could not update username history %d: %v", p.id, err)
+		}
+		updated++
+	}
+	return updated, nil
+}
+
+func scanUsernameHistory(row rowScanner) (*domain.UsernameHistory, error) {
+	var h domain.UsernameHistory
+	if err := row.Scan(&h.ID, &h.UserID, &h.Username, &h.ChangedAt, &h.HeldUntil); err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"backend/internal/database"
+	"backend/internal/domain"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const webAuthnCredentialColumns = "id, user_id, credential_id, public_key, algorithm, sign_count, aaguid, transports, backup_eligible, backed_up, name, created_at, last_used_at"
+
+// CreateWebAuthnCredential はパスキーを保存します。
+func CreateWebAuthnCredential(c *domain.WebAuthnCredential) (int64, error) {
+	query := "INSERT INTO webauthn_credentials (user_id, credential_id, public_key, algorithm, sign_count, aaguid, transports, backup_eligible, backed_up, name) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+
+	result, err := database.DB.Exec(query, c.UserID, c.CredentialID, c.PublicKey, c.Algorithm, c.SignCount, c.AAGUID,
+		strings.Join(c.Transports, " "), c.BackupEligible, c.BackedUp, c.Name)
+	if err != nil {
+		return 0, fmt.Errorf("CreateWebAuthnCredential: could not insert credential: %v", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("CreateWebAuthnCredential: could not retrieve last insert ID: %v", err)
+	}
+	return id, nil
+}
+
+// GetWebAuthnCredentialByCredentialID は認証器が返した資格情報IDでパスキーを取得します。
+func GetWebAuthnCredentialByCredentialID(credentialID []byte) (*domain.WebAuthnCredential, error) {
+	query := "SELECT " + webAuthnCredentialColumns + " FROM webauthn_credentials WHERE credential_id = ?"
+
+	c, err := scanWebAuthnCredential(database.DB.QueryRow(query, credentialID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("GetWebAuthnCredentialByCredentialID: could not retrieve credential: %v", err)
+	}
+	return c, nil
+}
+
+// GetWebAuthnCredentialByID は ID でパスキーを取得します。
+func GetWebAuthnCredentialByID(id int64) (*domain.WebAuthnCredential, error) {
+	query := "SELECT " + webAuthnCredentialColumns + " FROM webauthn_credentials WHERE id = ?"
+
+	c, err := scanWebAuthnCredential(database.DB.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("GetWebAuthnCredentialByID: could not retrieve credential: %v", err)
+	}
+	return c, nil
+}
+
+// GetWebAuthnCredentialsByUserID はユーザーが登録したパスキーの一覧を返します。
+func GetWebAuthnCredentialsByUserID(userID int64) ([]domain.WebAuthnCredential, error) {
+	query := "SELECT " + webAuthnCredentialColumns + " FROM webauthn_credentials WHERE user_id = ? ORDER BY id"
+
+	rows, err := database.DB.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("GetWebAuthnCredentialsByUserID: could not retrieve credentials: %v", err)
+	}
+	defer rows.Close()
+
+	var credentials []domain.WebAuthnCredential
+	for rows.Next() {
+		c, err := scanWebAuthnCredential(rows)
+		if err != nil {
+			return nil, fmt.Errorf("GetWebAuthnCredentialsByUserID: error scanning credential row: %v", err)
+		}
+		credentials = append(credentials, *c)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("GetWebAuthnCredentialsByUserID: error iterating credential rows: %v", err)
+	}
+	return credentials, nil
+}
+
+// UpdateWebAuthnCredentialUsage はログイン後に署名カウンターと同期状態、最終使用日時を更新します。
+// 並行したログインでカウンターが巻き戻らないよう、保存済みの値が変わっていない場合のみ更新します。
+func UpdateWebAuthnCredentialUsage(id int64, oldSignCount, newSignCount uint32, backedUp bool) (int64, error) {
+	query := "UPDATE webauthn_credentials SET sign_count = ?, backed_up = ?, last_used_at = CURRENT_TIMESTAMP WHERE id = ? AND sign_count = ?"
+	result, err := database.DB.Exec(query, newSignCount, backedUp, id, oldSignCount)
+	if err != nil {
+		return 0, fmt.Errorf("UpdateWebAuthnCredentialUsage: could not update credential %d: %v", id, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("UpdateWebAuthnCredentialUsage: could not get rows affected after update: %v", err)
+	}
+	return rowsAffected, nil
+}
+
+// RenameWebAuthnCredential はパスキーの表示名を変更します。
+func RenameWebAuthnCredential(id int64, userID int64, name string) error {
+	query := "UPDATE webauthn_credentials SET name = ? WHERE id = ? AND user_id = ?"
+	if _, err := database.DB.Exec(query, name, id, userID); err != nil {
+		return fmt.Errorf("RenameWebAuthnCredential: could not rename credential %d: %v", id, err)
+	}
+	return nil
+}
+
+// DeleteWebAuthnCredential はユーザーのパスキーを削除します。
+func DeleteWebAuthnCredential(id int64, userID int64) (int64, error) {
+	query := "DELETE FROM webauthn_credentials WHERE id = ? AND user_id = ?"
+	result, err := database.DB.Exec(query, id, userID)
+	if err != nil {
+		return 0, fmt.Errorf("DeleteWebAuthnCredential: could not delete credential %d: %v", id, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("DeleteWebAuthnCredential: could not get rows affected after delete: %v", err)
+	}
+	return rowsAffected, nil
+}
+
+func scanWebAuthnCredential(row rowScanner) (*domain.WebAuthnCredential, error) {
+	var c domain.WebAuthnCredential
+	var transports string
+	err := row.Scan(&c.ID, &c.UserID, &c.CredentialID, &c.PublicKey, &c.Algorithm, &c.SignCount, &c.AAGUID,
+		&transports, &c.BackupEligible, &c.BackedUp, &c.Name, &c.CreatedAt, &c.LastUsedAt)
+	if err != nil {
+		return nil, err
+	}
+	c.Transports = strings.Fields(transports)
+	return &c, nil
+}
+
+// CreateWebAuthnChallenge はハッシュ化されたチャレンジを保存します。
+func CreateWebAuthnChallenge(challengeHash string, userID sql.NullInt64, ceremony string, expiresAt time.Time) error {
+	query := "INSERT INTO webauthn_challenges (challenge_hash, user_id, ceremony, expires_at) VALUES (?, ?, ?, ?)"
+	if _, err := database.DB.Exec(query, challengeHash, userID, ceremony, expiresAt); err != nil {
+		return fmt.Errorf("CreateWebAuthnChallenge: could not insert challenge: %v", err)
+	}
+	return nil
+}
+
+// ConsumeWebAuthnChallenge はチャレンジを取得して削除します (1回限り有効)。
+func ConsumeWebAuthnChallenge(challengeHash string) (*domain.WebAuthnChallenge, error) {
+	query := "SELECT id, challenge_hash, user_id, ceremony, expires_at, created_at FROM webauthn_challenges WHERE challenge_hash = ?"
+
+	var ch domain.WebAuthnChallenge
+	err := database.DB.QueryRow(query, challengeHash).Scan(&ch.ID, &ch.ChallengeHash, &ch.UserID, &ch.Ceremony, &ch.ExpiresAt, &ch.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("ConsumeWebAuthnChallenge: could not retrieve challenge: %v", err)
+	}
+
+	result, err := database.DB.Exec("DELETE FROM webauthn_challenges WHERE id = ?", ch.ID)
+	if err != nil {
+		return nil, fmt.Errorf("ConsumeWebAuthnChallenge: could not delete challenge: %v", err)
+	}
+	if n, err := result.RowsAffected(); err != nil || n == 0 {
+		return nil, nil
+	}
+	return &ch, nil
+}
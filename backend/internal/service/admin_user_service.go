@@ -0,0 +1,151 @@
+// backend/internal/service/admin_user_service.go
+package service
+
+import (
+	"backend/internal/auth"
+	"backend/internal/config"
+	"backend/internal/domain"
+	"backend/internal/repository"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrAccountSuspended はユーザーが管理者によって停止されている場合に返されます。
+	ErrAccountSuspended = errors.New("このアカウントは停止されています")
+	// ErrAlreadySuspended は停止しようとしたユーザーがすでに停止中の場合に返されます。
+	ErrAlreadySuspended = errors.New("このユーザーはすでに停止されています")
+	// ErrNotSuspended は停止を解除しようとしたユーザーが停止中でない場合に返されます。
+	ErrNotSuspended = errors.New("このユーザーは停止されていません")
+	// ErrCannotTargetSelf は管理者が自分自身を対象に操作しようとした場合に返されます。
+	ErrCannotTargetSelf = errors.New("自分自身に対しては実行できません")
+	// ErrCannotImpersonate は代理ログインできないユーザー (管理者など) を指定した場合に返されます。
+	ErrCannotImpersonate = errors.New("このユーザーとして代理ログインすることはできません")
+	// ErrNoPassword はパスワードが設定されていないユーザーにパスワードの変更を求めようとした場合に返されます。
+	ErrNoPassword = errors.New("このユーザーにはパスワードが設定されていません")
+)
+
+// Impersonation は管理者による代理ログインで発行されたトークンです。リフレッシュトークンは発行しません。
+type Impersonation struct {
+	AccessToken string
+	SessionID   int64
+	ExpiresAt   time.Time
+}
+
+// SuspendUser はユーザーを停止し、すべてのセッションを失効させます。
+// 停止中のユーザーはログインできず、発行済みのアクセストークンや個人用アクセストークンも使用できなくなります。
+func SuspendUser(adminID int64, userID int64, reason string, client ClientInfo) error {
+	if adminID == userID {
+		return ErrCannotTargetSelf
+	}
+	if _, err := managedUser(userID); err != nil {
+		return err
+	}
+
+	suspended, err := repository.SuspendUser(userID, truncate(reason, 255))
+	if err != nil {
+		return fmt.Errorf("service.SuspendUser: %w", err)
+	}
+	if suspended == 0 {
+		return ErrAlreadySuspended
+	}
+	revoked, err := RevokeAllSessions(userID)
+	if err != nil {
+		return fmt.Errorf("service.SuspendUser: %w", err)
+	}
+
+	RecordAudit(AuditEntry{Event: AuditEventUserSuspend, Outcome: AuditOutcomeSuccess, ActorUserID: adminID, TargetUserID: userID, Client: client,
+		Details: map[string]any{"reason": reason, "revoked_sessions": revoked}})
+	return nil
+}
+
+// UnsuspendUser はユーザーの停止を解除します。停止時に失効させたセッションは元に戻らないため、再度ログインが必要です。
+func UnsuspendUser(adminID int64, userID int64, client ClientInfo) error {
+	if _, err := managedUser(userID); err != nil {
+		return err
+	}
+
+	unsuspended, err := repository.UnsuspendUser(userID)
+	if err != nil {
+		return fmt.Errorf("service.UnsuspendUser: %w", err)
+	}
+	if unsuspended == 0 {
+		return ErrNotSuspended
+	}
+
+	RecordAudit(AuditEntry{Event: AuditEventUserUnsuspend, Outcome: AuditOutcomeSuccess, ActorUserID: adminID, TargetUserID: userID, Client: client})
+	return nil
+}
+
+// RequirePasswordChange はユーザーに次回ログイン時のパスワード変更を求めます。
+// 変更するまでは、ログイン中のセッションや発行済みのアクセストークンでも、パスワードの変更とプロフィールの取得以外の操作ができなくなります。
+func RequirePasswordChange(adminID int64, userID int64, client ClientInfo) error {
+	user, err := managedUser(userID)
+	if err != nil {
+		return err
+	}
+	// 外部プロバイダーのみで登録したユーザーは現在のパスワードを入力できないため、変更を求めることができません。
+	if user.Password == "" {
+		return ErrNoPassword
+	}
+
+	if _, err := repository.SetPasswordChangeRequired(userID, true); err != nil {
+		return fmt.Errorf("service.RequirePasswordChange: %w", err)
+	}
+
+	RecordAudit(AuditEntry{Event: AuditEventPasswordChangeRequire, Outcome: AuditOutcomeSuccess, ActorUserID: adminID, TargetUserID: userID, Client: client})
+	return nil
+}
+
+// StartImpersonation は管理者がユーザーとして操作するための代理ログインのセッションを開始します。
+// 発行するアクセストークンには act クレームで管理者が記録され、SESSION_IMPERSONATION_TTL を過ぎると延長できずに失効します。
+// 管理者や停止中のユーザーには代理ログインできません。
+func StartImpersonation(adminID int64, userID int64, client ClientInfo) (*Impersonation, error) {
+	if adminID == userID {
+		return nil, ErrCannotTargetSelf
+	}
+	user, err := managedUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	audit := func(outcome string, details map[string]any) {
+		RecordAudit(AuditEntry{Event: AuditEventImpersonationStart, Outcome: outcome, ActorUserID: adminID, TargetUserID: userID, Client: client,
+			Details: details})
+	}
+
+	if user.Role == domain.RoleAdmin {
+		audit(AuditOutcomeFailure, map[string]any{"reason": "target_is_admin"})
+		return nil, ErrCannotImpersonate
+	}
+	if user.SuspendedAt.Valid {
+		audit(AuditOutcomeFailure, map[string]any{"reason": "suspended"})
+		return nil, ErrAccountSuspended
+	}
+
+	expiresAt := time.Now().Add(config.AppConfig.Session.ImpersonationTTL)
+	sessionID, err := repository.CreateImpersonationSession(user.ID, adminID, truncate(client.UserAgent, 512), client.IPAddress, expiresAt)
+	if err != nil {
+		return nil, fmt.Errorf("service.StartImpersonation: %w", err)
+	}
+	token, err := auth.GenerateImpersonationToken(user.ID, user.Username, sessionID, adminID, expiresAt)
+	if err != nil {
+		return nil, fmt.Errorf("service.StartImpersonation: %w", err)
+	}
+
+	audit(AuditOutcomeSuccess, map[string]any{"session_id": sessionID, "expires_at": expiresAt.UTC().Format(time.RFC3339)})
+	return &Impersonation{AccessToken: token, SessionID: sessionID, ExpiresAt: expiresAt}, nil
+}
+
+// managedUser は管理者の操作の対象となる、削除されていないユーザーを取得します。
+func managedUser(userID int64) (*domain.User, error) {
+	user, err := repository.GetUserByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("service.managedUser: %w", err)
+	}
+	if user == nil || user.DeletedAt.Valid {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
@@ -0,0 +1,150 @@
+// backend/internal/service/api_token_service.go
+package service
+
+import (
+	"backend/internal/auth"
+	"backend/internal/config"
+	"backend/internal/domain"
+	"backend/internal/repository"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+)
+
+// APITokenPrefix は個人用アクセストークンの接頭辞です。
+// JWT と区別するため、またシークレットスキャナーで検出できるようにするために付けます。
+const APITokenPrefix = "yut_"
+
+// 個人用アクセストークンのスコープです。
+const (
+	APITokenScopeRead  = "read"  // GET / HEAD のみ
+	APITokenScopeWrite = "write" // すべてのメソッド
+)
+
+var (
+	// ErrInvalidAPIToken はアクセストークンが存在しない、期限切れ、または失効済みの場合に返されます。
+	ErrInvalidAPIToken = errors.New("アクセストークンが無効です")
+	// ErrAPITokenNotFound はアクセストークンが存在しない、または他のユーザーのものである場合に返されます。
+	ErrAPITokenNotFound = errors.New("アクセストークンが見つかりません")
+	// ErrInvalidAPITokenScope は対応していないスコープが指定された場合に返されます。
+	ErrInvalidAPITokenScope = errors.New("対応していないスコープです")
+	// ErrInvalidAPITokenExpiry は有効期限が過去の日時の場合に返されます。
+	ErrInvalidAPITokenExpiry = errors.New("有効期限は未来の日時である必要があります")
+)
+
+// CreateAPIToken は個人用アクセストークンを発行します。
+// 戻り値のトークンはハッシュのみ保存されるため、この時点でしか確認できません。
+func CreateAPIToken(userID int64, name string, scopes []string, expiresAt *time.Time) (*domain.APIToken, string, error) {
+	if len(scopes) == 0 {
+		scopes = []string{APITokenScopeRead}
+	}
+	for _, s := range scopes {
+		if s != APITokenScopeRead && s != APITokenScopeWrite {
+			return nil, "", fmt.Errorf("%w: %s", ErrInvalidAPITokenScope, s)
+		}
+	}
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return nil, "", ErrInvalidAPITokenExpiry
+	}
+
+	idBytes := make([]byte, 4)
+	if _, err := rand.Read(idBytes); err != nil {
+		return nil, "", fmt.Errorf("service.CreateAPIToken: %w", err)
+	}
+	secret, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return nil, "", err
+	}
+	prefix := APITokenPrefix + hex.EncodeToString(idBytes)
+	token := prefix + "_" + secret
+
+	t := &domain.APIToken{
+		UserID:    userID,
+		Name:      name,
+		Prefix:    prefix,
+		TokenHash: auth.HashToken(token),
+		Scopes:    scopes,
+		CreatedAt: time.Now(),
+	}
+	if expiresAt != nil {
+		t.ExpiresAt = sql.NullTime{Time: *expiresAt, Valid: true}
+	}
+
+	id, err := repository.CreateAPIToken(t)
+	if err != nil {
+		return nil, "", fmt.Errorf("service.CreateAPIToken: %w", err)
+	}
+	t.ID = id
+	return t, token, nil
+}
+
+// ListAPITokens はユーザーの有効なアクセストークンの一覧を返します。
+func ListAPITokens(userID int64) ([]domain.APIToken, error) {
+	tokens, err := repository.GetActiveAPITokensByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("service.ListAPITokens: %w", err)
+	}
+	return tokens, nil
+}
+
+// RevokeAPIToken はユーザーのアクセストークンを失効させます。
+func RevokeAPIToken(userID int64, tokenID int64) error {
+	revoked, err := repository.RevokeAPIToken(tokenID, userID)
+	if err != nil {
+		return fmt.Errorf("service.RevokeAPIToken: %w", err)
+	}
+	if revoked == 0 {
+		return ErrAPITokenNotFound
+	}
+	return nil
+}
+
+// IsAPIToken はトークン文字列が個人用アクセストークンの形式かどうかを返します。
+func IsAPIToken(token string) bool {
+	return strings.HasPrefix(token, APITokenPrefix)
+}
+
+// AuthenticateAPIToken はアクセストークンを検証し、トークンの持ち主を返します。
+// 最終使用日時は LastSeenInterval 以上経過している場合にのみ更新します。
+func AuthenticateAPIToken(token string) (*domain.APIToken, *domain.User, error) {
+	t, err := repository.GetAPITokenByHash(auth.HashToken(token))
+	if err != nil {
+		return nil, nil, fmt.Errorf("service.AuthenticateAPIToken: %w", err)
+	}
+	if t == nil || t.RevokedAt.Valid || (t.ExpiresAt.Valid && time.Now().After(t.ExpiresAt.Time)) {
+		return nil, nil, ErrInvalidAPIToken
+	}
+
+	user, err := repository.GetUserByID(t.UserID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("service.AuthenticateAPIToken: %w", err)
+	}
+	if user == nil || user.DeletedAt.Valid {
+		return nil, nil, ErrInvalidAPIToken
+	}
+	if user.SuspendedAt.Valid {
+		return nil, nil, ErrAccountSuspended
+	}
+
+	if !t.LastUsedAt.Valid || time.Since(t.LastUsedAt.Time) >= config.AppConfig.Session.LastSeenInterval {
+		// 最終使用日時の更新に失敗しても認証自体は成功とします。
+		if err := repository.TouchAPIToken(t.ID); err != nil {
+			log.Printf("service.AuthenticateAPIToken: %v", err)
+		}
+	}
+	return t, user, nil
+}
+
+// APITokenAllows はアクセストークンのスコープで HTTP メソッドが許可されているかを返します。
+func APITokenAllows(t *domain.APIToken, method string) bool {
+	if slices.Contains(t.Scopes, APITokenScopeWrite) {
+		return true
+	}
+	return method == "GET" || method == "HEAD"
+}
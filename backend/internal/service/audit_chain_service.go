@@ -0,0 +1,165 @@
+// backend/internal/service/audit_chain_service.go
+package service
+
+import (
+	"backend/internal/audit"
+	"backend/internal/domain"
+	"backend/internal/repository"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// errChainBroken は検証中にチェーンの切れ目を見つけたときに走査を止めるための内部エラーです。
+var errChainBroken = errors.New("audit chain broken")
+
+// AuditChainBreak は検証で見つかった最初のチェーンの切れ目です。
+// LogID / CheckpointID は該当しない場合 0 です。
+type AuditChainBreak struct {
+	LogID        int64
+	CheckpointID int64
+	Reason       string
+}
+
+// AuditChainReport は監査ログのハッシュチェーンの検証結果です。
+type AuditChainReport struct {
+	Entries     int   // 検証したチェーン上のエントリー数
+	Unchained   int   // チェーン導入前のエントリー数 (検証対象外)
+	Checkpoints int   // 検証したチェックポイント数
+	LastLogID   int64 // チェーンの末尾のエントリー
+	LastHash    string
+	// SignaturesVerified は公開鍵でチェックポイントの署名を検証したかどうかです。
+	SignaturesVerified bool
+	Broken             *AuditChainBreak // nil の場合はチェーンに問題はありません
+}
+
+// CreateAuditCheckpoint はハッシュチェーンの現在の末尾に署名したチェックポイントを保存します。
+// 署名鍵が設定されていない場合や、前回のチェックポイントからエントリーが増えていない場合は何もせず nil を返します。
+func CreateAuditCheckpoint() (*domain.AuditCheckpoint, error) {
+	if !audit.CheckpointEnabled() {
+		return nil, nil
+	}
+	lastID, lastHash, err := repository.GetAuditChainHead()
+	if err != nil {
+		return nil, fmt.Errorf("service.CreateAuditCheckpoint: %w", err)
+	}
+	if lastID == 0 {
+		return nil, nil
+	}
+	latest, err := repository.GetLatestAuditCheckpoint()
+	if err != nil {
+		return nil, fmt.Errorf("service.CreateAuditCheckpoint: %w", err)
+	}
+	if latest != nil && latest.LastLogID == lastID {
+		return nil, nil
+	}
+
+	cp, err := audit.SignCheckpoint(lastID, lastHash, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("service.CreateAuditCheckpoint: %w", err)
+	}
+	if cp.ID, err = repository.CreateAuditCheckpoint(cp); err != nil {
+		return nil, fmt.Errorf("service.CreateAuditCheckpoint: %w", err)
+	}
+	return cp, nil
+}
+
+// StartAuditCheckpoints は起動時と interval ごとにチェックポイントを作成するゴルーチンを開始します。
+func StartAuditCheckpoints(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if _, err := CreateAuditCheckpoint(); err != nil {
+				log.Printf("service.StartAuditCheckpoints: %v", err)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// VerifyAuditChain は監査ログを先頭から順にたどり、ハッシュチェーンとチェックポイントを検証します。
+// pub が nil の場合はチェックポイントの署名を検証せず、チェーンとの整合性だけを確認します。
+// 最初に見つかった切れ目を Broken に設定して検証を終えます。
+func VerifyAuditChain(pub ed25519.PublicKey) (*AuditChainReport, error) {
+	report := &AuditChainReport{SignaturesVerified: pub != nil}
+	brokenAt := func(logID, checkpointID int64, reason string) error {
+		report.Broken = &AuditChainBreak{LogID: logID, CheckpointID: checkpointID, Reason: reason}
+		return errChainBroken
+	}
+
+	checkpoints, err := repository.GetAuditCheckpoints()
+	if err != nil {
+		return nil, fmt.Errorf("service.VerifyAuditChain: %w", err)
+	}
+	if pub != nil {
+		for i := range checkpoints {
+			if !audit.VerifyCheckpoint(pub, &checkpoints[i]) {
+				report.Broken = &AuditChainBreak{CheckpointID: checkpoints[i].ID, Reason: "チェックポイントの署名が正しくありません"}
+				return report, nil
+			}
+		}
+	}
+
+	// 検証中に追加されたエントリーは対象外とするため、走査の前にチェーンの末尾を読み取ります。
+	headID, headHash, err := repository.GetAuditChainHead()
+	if err != nil {
+		return nil, fmt.Errorf("service.VerifyAuditChain: %w", err)
+	}
+
+	prevHash := ""
+	next := 0 // 次に照合するチェックポイント
+	err = repository.WalkAuditLogs(func(l *domain.AuditLog) error {
+		if l.EntryHash != "" && l.ID > headID {
+			return nil
+		}
+		// チェーン導入前のエントリーはハッシュを持たないため対象外とします。
+		if l.EntryHash == "" && report.Entries == 0 {
+			report.Unchained++
+			return nil
+		}
+		// チェックポイントが指すエントリーを飛び越えた場合は、そのエントリーが削除されています。
+		if next < len(checkpoints) && checkpoints[next].LastLogID < l.ID {
+			return brokenAt(checkpoints[next].LastLogID, checkpoints[next].ID, "チェックポイントが指すエントリーが存在しません")
+		}
+		if l.EntryHash == "" {
+			return brokenAt(l.ID, 0, "エントリーにハッシュがありません")
+		}
+		if l.PrevHash != prevHash {
+			return brokenAt(l.ID, 0, "直前のエントリーのハッシュと一致しません (エントリーの削除・挿入・並べ替え)")
+		}
+		if audit.EntryHash(l) != l.EntryHash {
+			return brokenAt(l.ID, 0, "エントリーの内容がハッシュと一致しません (エントリーの改ざん)")
+		}
+		for next < len(checkpoints) && checkpoints[next].LastLogID == l.ID {
+			if checkpoints[next].LastHash != l.EntryHash {
+				return brokenAt(l.ID, checkpoints[next].ID, "チェックポイントのハッシュと一致しません (チェーンの再計算)")
+			}
+			report.Checkpoints++
+			next++
+		}
+		prevHash = l.EntryHash
+		report.Entries++
+		report.LastLogID = l.ID
+		report.LastHash = l.EntryHash
+		return nil
+	})
+	if errors.Is(err, errChainBroken) {
+		return report, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("service.VerifyAuditChain: %w", err)
+	}
+
+	// 末尾のエントリーが削除されていないかを、チェックポイントとチェーンの末尾の記録で確認します。
+	if next < len(checkpoints) {
+		brokenAt(checkpoints[next].LastLogID, checkpoints[next].ID, "チェックポイントが指すエントリーが存在しません (末尾の削除)")
+		return report, nil
+	}
+	if headID != report.LastLogID || headHash != report.LastHash {
+		brokenAt(headID, 0, "チェーンの末尾の記録と最後のエントリーが一致しません (末尾の削除)")
+	}
+	return report, nil
+}
@@ -0,0 +1,140 @@
+// backend/internal/service/audit_service.go
+package service
+
+import (
+	"backend/internal/domain"
+	"backend/internal/repository"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+)
+
+// 監査ログのイベント名です。
+const (
+	AuditEventLogin          = "auth.login"
+	AuditEventPasswordChange = "user.password_change"
+	AuditEventUserCreate     = "user.create"
+	AuditEventUserUpdate     = "user.update"
+	AuditEventUserDelete     = "user.delete"
+
+	AuditEventEmailChangeRequest = "user.email_change_request"
+	AuditEventEmailChange        = "user.email_change"
+	AuditEventEmailChangeRevert  = "user.email_change_revert"
+
+	AuditEventUserSuspend           = "user.suspend"
+	AuditEventUserUnsuspend         = "user.unsuspend"
+	AuditEventPasswordChangeRequire = "user.password_change_require"
+	AuditEventImpersonationStart    = "auth.impersonation_start"
+
+	AuditEventUserExport = "user.export"
+
+	AuditEventInviteCodeCreate = "invite_code.create"
+	AuditEventInviteCodeRevoke = "invite_code.revoke"
+)
+
+// 監査ログの結果です。
+const (
+	AuditOutcomeSuccess = "success"
+	AuditOutcomeFailure = "failure"
+)
+
+// 監査ログの1ページあたりの件数です。
+const (
+	defaultAuditPageSize = 50
+	maxAuditPageSize     = 200
+)
+
+// ErrInvalidCursor はページングのカーソルが不正な場合に返されます。
+var ErrInvalidCursor = errors.New("カーソルの形式が正しくありません")
+
+// AuditEntry は記録する監査ログ1件分の内容です。ActorUserID / TargetUserID は不明な場合 0 です。
+type AuditEntry struct {
+	Event        string
+	Outcome      string
+	ActorUserID  int64
+	TargetUserID int64
+	Client       ClientInfo
+	Details      map[string]any
+}
+
+// RecordAudit は監査ログを記録します。
+// 記録に失敗しても呼び出し元の処理は止めず、アプリケーションログに残します。
+// 管理者による代理ログイン中の操作には、詳細に代理ログインした管理者のユーザーIDを加えます。
+func RecordAudit(e AuditEntry) {
+	if e.Client.ImpersonatorUserID != 0 {
+		if e.Details == nil {
+			e.Details = map[string]any{}
+		}
+		e.Details["impersonator_user_id"] = e.Client.ImpersonatorUserID
+	}
+	l := &domain.AuditLog{
+		OccurredAt:   time.Now(),
+		Event:        e.Event,
+		Outcome:      e.Outcome,
+		ActorUserID:  sql.NullInt64{Int64: e.ActorUserID, Valid: e.ActorUserID != 0},
+		TargetUserID: sql.NullInt64{Int64: e.TargetUserID, Valid: e.TargetUserID != 0},
+		IPAddress:    e.Client.IPAddress,
+		UserAgent:    truncate(e.Client.UserAgent, 512),
+		RequestID:    e.Client.RequestID,
+	}
+	if len(e.Details) > 0 {
+		details, err := json.Marshal(e.Details)
+		if err != nil {
+			log.Printf("service.RecordAudit: could not encode details for %s: %v", e.Event, err)
+		} else {
+			l.Details = string(details)
+		}
+	}
+	if err := repository.CreateAuditLog(l); err != nil {
+		log.Printf("service.RecordAudit: %s (%s) request=%s: %v", e.Event, e.Outcome, e.Client.RequestID, err)
+	}
+}
+
+// ListAuditLogs は条件に一致する監査ログを新しい順に1ページ分返します (管理者用)。
+// 続きがある場合は次のページのカーソルを返します。
+func ListAuditLogs(filter repository.AuditLogFilter, cursor string, limit int) ([]domain.AuditLog, string, error) {
+	logs, next, err := findAuditLogPage(filter, cursor, limit)
+	if err != nil {
+		return nil, "", fmt.Errorf("service.ListAuditLogs: %w", err)
+	}
+	return logs, next, nil
+}
+
+// ListUserActivity はユーザーが操作した、またはユーザーが対象となった監査ログを新しい順に1ページ分返します。
+func ListUserActivity(userID int64, cursor string, limit int) ([]domain.AuditLog, string, error) {
+	logs, next, err := findAuditLogPage(repository.AuditLogFilter{SubjectUserID: userID}, cursor, limit)
+	if err != nil {
+		return nil, "", fmt.Errorf("service.ListUserActivity: %w", err)
+	}
+	return logs, next, nil
+}
+
+// findAuditLogPage はカーソル (前のページの最後の ID) から1ページ分の監査ログを取得します。
+// 次のページの有無を判定するため、1件多く取得します。
+func findAuditLogPage(filter repository.AuditLogFilter, cursor string, limit int) ([]domain.AuditLog, string, error) {
+	if cursor != "" {
+		id, err := strconv.ParseInt(cursor, 10, 64)
+		if err != nil || id <= 0 {
+			return nil, "", ErrInvalidCursor
+		}
+		filter.BeforeID = id
+	}
+	if limit <= 0 {
+		limit = defaultAuditPageSize
+	}
+	filter.Limit = min(limit, maxAuditPageSize) + 1
+
+	logs, err := repository.FindAuditLogs(filter)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(logs) < filter.Limit {
+		return logs, "", nil
+	}
+	logs = logs[:filter.Limit-1]
+	return logs, strconv.FormatInt(logs[len(logs)-1].ID, 10), nil
+}
@@ -2,53 +2,249 @@
 package service
 
 import (
-	"backend/internal/auth"       // パスワードチェック用
+	"backend/internal/auth" // パスワードチェック用
+	"backend/internal/config"
+	"backend/internal/domain"
+	"backend/internal/identifier"
 	"backend/internal/repository" // ユーザー取得用
 	"errors"
 	"fmt"
+	"log"
+	"strings"
+	"time"
 )
 
-// Login はユーザー名とパスワードを受け取り、認証を試みます。
-// 成功した場合はJWTトークンを、失敗した場合はエラーを返します。
-func Login(username string, password string) (string, error) {
-	user, err := repository.GetUserByUsername(username)
+// ErrInvalidRefreshToken はリフレッシュトークンが存在しない、期限切れ、または失効済みの場合に返されます。
+var ErrInvalidRefreshToken = errors.New("リフレッシュトークンが無効です。再度ログインしてください")
+
+// ErrUserNotFound はユーザーが存在しない、または削除済みの場合に返されます。
+var ErrUserNotFound = errors.New("ユーザーが見つかりません")
+
+// ErrInvalidCredentials はログインIDまたはパスワードが正しくない場合に返されます。
+// ユーザーの有無を推測されないよう、どちらが誤っているかは区別しません。
+var ErrInvalidCredentials = errors.New("ユーザー名またはパスワードが正しくありません")
+
+// TokenPair はログインまたはトークン更新で発行されるトークンの組です。
+type TokenPair struct {
+	AccessToken      string
+	RefreshToken     string
+	RefreshExpiresAt time.Time
+	// PasswordChangeRequired は管理者によってパスワードの変更が求められている場合に true です。
+	// 変更するまでは、パスワードの変更とプロフィールの取得以外の操作はできません。
+	PasswordChangeRequired bool
+}
+
+// ClientInfo はログイン元の端末情報です。セッションの記録に使用します。
+type ClientInfo struct {
+	UserAgent string
+	IPAddress string
+	RequestID string // 監査ログとアプリケーションログを突き合わせるためのリクエストID
+	// ImpersonatorUserID は管理者による代理ログイン中の場合に、操作している管理者のユーザーIDです (それ以外は 0)。
+	ImpersonatorUserID int64
+}
+
+// Login はユーザー名またはメールアドレスとパスワードを受け取り、認証を試みます。
+// 成功した場合はセッションを記録し、JWTトークンとリフレッシュトークンを、失敗した場合はエラーを返します。
+// ログインIDが存在しない場合もパスワードが誤っている場合も、同程度の時間をかけて ErrInvalidCredentials を返します。
+func Login(loginID string, password string, client ClientInfo) (*TokenPair, error) {
+	user, err := findLoginUser(loginID)
 	if err != nil {
-		return "", fmt.Errorf("service.Login: ユーザー情報の取得中にエラーが発生しました: %w", err)
+		return nil, fmt.Errorf("service.Login: ユーザー情報の取得中にエラーが発生しました: %w", err)
 	}
 
-	// ユーザーが存在するかどうかを確認します。
-	if user == nil {
-		return "", errors.New("ユーザー名またはパスワードが正しくありません")
+	// ユーザーが存在しない場合やパスワードが未設定の場合も、応答時間で区別されないようダミーのハッシュと比較します。
+	if user == nil || user.Password == "" {
+		auth.CheckDummyPasswordHash(password)
+		entry := AuditEntry{Event: AuditEventLogin, Outcome: AuditOutcomeFailure, Client: client,
+			Details: map[string]any{"login_id": loginID, "reason": "unknown_user"}}
+		if user != nil {
+			entry.TargetUserID = user.ID
+			entry.Details["reason"] = "no_password"
+		}
+		RecordAudit(entry)
+		return nil, ErrInvalidCredentials
 	}
 
 	// パスワードが正しいかを確認します。
 	passwordIsValid := auth.CheckPasswordHash(password, user.Password)
 	if !passwordIsValid {
-		return "", errors.New("ユーザー名またはパスワードが正しくありません")
+		RecordAudit(AuditEntry{Event: AuditEventLogin, Outcome: AuditOutcomeFailure, TargetUserID: user.ID, Client: client,
+			Details: map[string]any{"login_id": loginID, "reason": "invalid_password"}})
+		return nil, ErrInvalidCredentials
+	}
+
+	// 保存済みのハッシュが古い方式・パラメーターの場合は、平文のパスワードがある今のうちに再ハッシュします。
+	// 失敗してもログイン自体は成功とします。
+	if auth.NeedsRehash(user.Password) {
+		rehashPassword(user.ID, password)
+	}
+
+	// パスワードが正しい場合、セッションを作成してJWTとリフレッシュトークンを生成します。
+	pair, err := startSession(user, client)
+	if err != nil {
+		return nil, fmt.Errorf("service.Login: %w", err)
+	}
+	RecordAudit(AuditEntry{Event: AuditEventLogin, Outcome: AuditOutcomeSuccess, ActorUserID: user.ID, TargetUserID: user.ID, Client: client,
+		Details: map[string]any{"method": "password"}})
+	return pair, nil
+}
+
+// findLoginUser はログインIDがメールアドレスの形 ("@" を含む) であればメールアドレスで、それ以外はユーザー名で
+// 削除されていないユーザーを取得します。どちらの場合もクエリは1回のみで、見つからない場合は nil を返します。
+// ユーザー名には "@" を使用できないため、メールアドレスとユーザー名を取り違えることはありません。
+func findLoginUser(loginID string) (*domain.User, error) {
+	if !strings.Contains(loginID, "@") {
+		return repository.GetUserByUsername(loginID)
+	}
+	email, err := identifier.NormalizeEmail(loginID)
+	if err != nil {
+		return nil, nil
 	}
+	return repository.GetUserByEmail(email)
+}
 
-	// パスワードが正しい場合、JWTを生成します。
-	token, err := auth.GenerateToken(user.ID, user.Username)
+// rehashPassword は現在のハッシュ方式でパスワードを再ハッシュして保存します。
+func rehashPassword(userID int64, password string) {
+	hashed, err := auth.HashPassword(password)
+	if err != nil {
+		log.Printf("service.rehashPassword: user %d: %v", userID, err)
+		return
+	}
+	if _, err := repository.UpdateUserPassword(hashed, userID); err != nil {
+		log.Printf("service.rehashPassword: user %d: %v", userID, err)
+	}
+}
+
+// startSession はセッションを記録し、そのセッションのトークンの組を発行します。
+// 停止中のユーザーの場合は、どの方法でのログインでも ErrAccountSuspended を返します。
+func startSession(user *domain.User, client ClientInfo) (*TokenPair, error) {
+	if user.SuspendedAt.Valid {
+		RecordAudit(AuditEntry{Event: AuditEventLogin, Outcome: AuditOutcomeFailure, TargetUserID: user.ID, Client: client,
+			Details: map[string]any{"reason": "suspended"}})
+		return nil, ErrAccountSuspended
+	}
+	sessionID, err := repository.CreateSession(user.ID, truncate(client.UserAgent, 512), client.IPAddress)
+	if err != nil {
+		return nil, fmt.Errorf("セッションの作成に失敗しました: %w", err)
+	}
+	pair, err := issueTokenPair(user.ID, user.Username, sessionID)
 	if err != nil {
-		return "", fmt.Errorf("service.Login: トークンの生成に失敗しました: %w", err)
+		return nil, err
 	}
-	return token, nil
+	pair.PasswordChangeRequired = user.PasswordChangeRequired
+	return pair, nil
 }
 
-func ChangePassword(userID int64, currentPassword string, newPassword string) error {
+// RefreshTokens はリフレッシュトークンを検証して失効させ、新しいトークンの組を発行します (ローテーション)。
+func RefreshTokens(refreshToken string) (*TokenPair, error) {
+	stored, err := repository.GetRefreshTokenByHash(auth.HashToken(refreshToken))
+	if err != nil {
+		return nil, fmt.Errorf("service.RefreshTokens: %w", err)
+	}
+	if stored == nil || stored.RevokedAt.Valid || time.Now().After(stored.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	// 同じトークンが並行して使われた場合に備え、実際に失効できた場合のみ続行します。
+	revoked, err := repository.RevokeRefreshToken(stored.ID)
+	if err != nil {
+		return nil, fmt.Errorf("service.RefreshTokens: %w", err)
+	}
+	if revoked == 0 {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	session, err := repository.GetSessionByID(stored.SessionID)
+	if err != nil {
+		return nil, fmt.Errorf("service.RefreshTokens: %w", err)
+	}
+	if session == nil || session.RevokedAt.Valid {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	user, err := repository.GetUserByID(stored.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("service.RefreshTokens: %w", err)
+	}
+	if user == nil || user.DeletedAt.Valid || user.SuspendedAt.Valid {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	pair, err := issueTokenPair(user.ID, user.Username, session.ID)
+	if err != nil {
+		return nil, fmt.Errorf("service.RefreshTokens: %w", err)
+	}
+	pair.PasswordChangeRequired = user.PasswordChangeRequired
+	return pair, nil
+}
+
+// Logout はリフレッシュトークンが属するセッションを失効させます。存在しないトークンは無視します。
+func Logout(refreshToken string) error {
+	stored, err := repository.GetRefreshTokenByHash(auth.HashToken(refreshToken))
+	if err != nil {
+		return fmt.Errorf("service.Logout: %w", err)
+	}
+	if stored == nil {
+		return nil
+	}
+	if err := RevokeSession(stored.UserID, stored.SessionID); err != nil && !errors.Is(err, ErrSessionNotFound) {
+		return fmt.Errorf("service.Logout: %w", err)
+	}
+	return nil
+}
+
+// issueTokenPair はセッションに紐付くアクセストークン (JWT) とリフレッシュトークンを発行します。
+func issueTokenPair(userID int64, username string, sessionID int64) (*TokenPair, error) {
+	accessToken, err := auth.GenerateToken(userID, username, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("トークンの生成に失敗しました: %w", err)
+	}
+
+	refreshToken, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	expiresAt := time.Now().Add(config.AppConfig.Session.RefreshTokenTTL)
+	if _, err := repository.CreateRefreshToken(userID, sessionID, auth.HashToken(refreshToken), expiresAt); err != nil {
+		return nil, fmt.Errorf("リフレッシュトークンの保存に失敗しました: %w", err)
+	}
+
+	return &TokenPair{
+		AccessToken:      accessToken,
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: expiresAt,
+	}, nil
+}
+
+// ChangePassword は現在のパスワードを確認し、パスワードポリシーを満たす新しいパスワードに変更します。
+// 新しいパスワードが条件を満たしていない場合は *PasswordPolicyError を返します。
+func ChangePassword(userID int64, currentPassword string, newPassword string, client ClientInfo) error {
 	user, err := repository.GetUserByID(userID)
 	if err != nil {
 		return fmt.Errorf("ユーザー情報の取得に失敗しました: %v", err)
 	}
+	if user == nil || user.DeletedAt.Valid {
+		return ErrUserNotFound
+	}
+
+	audit := func(outcome string, reason string) {
+		entry := AuditEntry{Event: AuditEventPasswordChange, Outcome: outcome, ActorUserID: userID, TargetUserID: userID, Client: client}
+		if reason != "" {
+			entry.Details = map[string]any{"reason": reason}
+		}
+		RecordAudit(entry)
+	}
 
 	check := auth.CheckPasswordHash(currentPassword, user.Password)
 
 	if !check {
+		audit(AuditOutcomeFailure, "invalid_current_password")
 		return errors.New("現在のパスワードが正しくないです。")
 	}
 
-	if len(newPassword) < 8 {
-		return errors.New("新しいパスワードの形式が正しくないです新しいパスワードは8文字以上である必要があります。")
+	if err := ValidatePassword(newPassword, PasswordOwner{UserID: user.ID, Username: user.Username, Email: user.Email}); err != nil {
+		audit(AuditOutcomeFailure, "policy_violation")
+		return err
 	}
 
 	newHashedPassword, err := auth.HashPassword(newPassword)
@@ -60,5 +256,14 @@ func ChangePassword(userID int64, currentPassword string, newPassword string) er
 	if err != nil {
 		return fmt.Errorf("パスワードの更新に失敗しました: %v", err)
 	}
+	if err := RecordPassword(userID, newHashedPassword); err != nil {
+		log.Printf("service.ChangePassword: %v", err)
+	}
+	if user.PasswordChangeRequired {
+		if _, err := repository.SetPasswordChangeRequired(userID, false); err != nil {
+			return fmt.Errorf("パスワードの更新に失敗しました: %v", err)
+		}
+	}
+	audit(AuditOutcomeSuccess, "")
 	return nil
 }
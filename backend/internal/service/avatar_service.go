@@ -0,0 +1,160 @@
+// backend/internal/service/avatar_service.go
+package service
+
+import (
+	"backend/internal/config"
+	"backend/internal/domain"
+	"backend/internal/imaging"
+	"backend/internal/repository"
+	"backend/internal/storage"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AvatarSizes はアバター画像を保存するサイズ (正方形の一辺のピクセル数) です。
+var AvatarSizes = []int{64, 128, 256, 512}
+
+// ErrAvatarTooLarge はアップロードされたファイルが AVATAR_MAX_BYTES を超えている場合に返されます。
+var ErrAvatarTooLarge = errors.New("画像のファイルサイズが大きすぎます")
+
+// avatarUploadTimeout は1回のアップロードでストレージへの保存にかける時間の上限です。
+const avatarUploadTimeout = 30 * time.Second
+
+// UpdateAvatar はアップロードされた画像からサイズごとのアバター画像を作成して保存し、更新後のユーザーを返します。
+// 画像の形式が正しくない場合は imaging パッケージのエラーを返します。以前のアバター画像は削除します。
+func UpdateAvatar(userID int64, data []byte, client ClientInfo) (*domain.User, error) {
+	cfg := config.AppConfig.Avatar
+	if len(data) > cfg.MaxBytes {
+		return nil, ErrAvatarTooLarge
+	}
+	user, err := GetCurrentUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	img, err := imaging.Decode(data, cfg.MaxDimension)
+	if err != nil {
+		return nil, err
+	}
+
+	// 推測されにくいキーにして、署名付き URL 以外から取得されないようにします。
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return nil, fmt.Errorf("service.UpdateAvatar: %w", err)
+	}
+	// 透過のない画像は JPEG、ある画像は PNG で保存します。
+	opaque := false
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		opaque = o.Opaque()
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), avatarUploadTimeout)
+	defer cancel()
+
+	var avatarKey string
+	var stored []string
+	for _, size := range AvatarSizes {
+		thumb := imaging.SquareThumbnail(img, size)
+		encoded, contentType, ext, err := imaging.Encode(thumb, opaque)
+		if err != nil {
+			return nil, fmt.Errorf("service.UpdateAvatar: %w", err)
+		}
+		if avatarKey == "" {
+			avatarKey = fmt.Sprintf("avatars/%d/%s%s", userID, hex.EncodeToString(random), ext)
+		}
+		key := avatarObjectKey(avatarKey, size)
+		if err := storage.Put(ctx, key, encoded, contentType); err != nil {
+			deleteAvatarObjects(stored)
+			return nil, fmt.Errorf("service.UpdateAvatar: %w", err)
+		}
+		stored = append(stored, key)
+	}
+
+	rows, err := repository.UpdateUserAvatar(userID, avatarKey)
+	if err != nil || rows == 0 {
+		deleteAvatarObjects(stored)
+		if err != nil {
+			return nil, fmt.Errorf("service.UpdateAvatar: %w", err)
+		}
+		return nil, ErrUserNotFound
+	}
+	if user.AvatarKey != "" {
+		deleteAvatar(user.AvatarKey)
+	}
+	user.AvatarKey = avatarKey
+
+	RecordAudit(AuditEntry{Event: AuditEventUserUpdate, Outcome: AuditOutcomeSuccess, ActorUserID: userID, TargetUserID: userID, Client: client,
+		Details: map[string]any{"fields": []string{"avatar"}}})
+	return user, nil
+}
+
+// DeleteAvatar はユーザーのアバター画像を削除し、更新後のユーザーを返します。
+func DeleteAvatar(userID int64, client ClientInfo) (*domain.User, error) {
+	user, err := GetCurrentUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.AvatarKey == "" {
+		return user, nil
+	}
+	if _, err := repository.UpdateUserAvatar(userID, ""); err != nil {
+		return nil, fmt.Errorf("service.DeleteAvatar: %w", err)
+	}
+	deleteAvatar(user.AvatarKey)
+	user.AvatarKey = ""
+
+	RecordAudit(AuditEntry{Event: AuditEventUserUpdate, Outcome: AuditOutcomeSuccess, ActorUserID: userID, TargetUserID: userID, Client: client,
+		Details: map[string]any{"fields": []string{"avatar"}}})
+	return user, nil
+}
+
+// AvatarURLs はユーザーのアバター画像のサイズごとの署名付き URL を返します。
+// アバターが設定されていない場合や URL を作成できない場合は nil を返します。
+func AvatarURLs(u *domain.User) map[string]string {
+	if u.AvatarKey == "" {
+		return nil
+	}
+	urls := make(map[string]string, len(AvatarSizes))
+	for _, size := range AvatarSizes {
+		signed, err := storage.SignedURL(avatarObjectKey(u.AvatarKey, size))
+		if err != nil {
+			log.Printf("service.AvatarURLs: user %d: %v", u.ID, err)
+			return nil
+		}
+		urls[strconv.Itoa(size)] = signed
+	}
+	return urls
+}
+
+// avatarObjectKey はサイズごとの画像のキーを返します (例: avatars/12/abc.jpg → avatars/12/abc_64.jpg)。
+func avatarObjectKey(avatarKey string, size int) string {
+	ext := path.Ext(avatarKey)
+	return strings.TrimSuffix(avatarKey, ext) + "_" + strconv.Itoa(size) + ext
+}
+
+// deleteAvatar はアバター画像をすべてのサイズについて削除します。
+func deleteAvatar(avatarKey string) {
+	keys := make([]string, 0, len(AvatarSizes))
+	for _, size := range AvatarSizes {
+		keys = append(keys, avatarObjectKey(avatarKey, size))
+	}
+	deleteAvatarObjects(keys)
+}
+
+// deleteAvatarObjects は不要になった画像を削除します。失敗してもログに残すだけにします。
+func deleteAvatarObjects(keys []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), avatarUploadTimeout)
+	defer cancel()
+	for _, key := range keys {
+		if err := storage.Delete(ctx, key); err != nil {
+			log.Printf("service.deleteAvatarObjects: %v", err)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+// backend/internal/service/avatar_service_test.go
+package service
+
+import (
+	"backend/internal/config"
+	"errors"
+	"testing"
+)
+
+// ファイルサイズの上限はユーザーの取得や画像の展開より前に確認します。
+func TestUpdateAvatarRejectsOversizedFile(t *testing.T) {
+	saved := config.AppConfig
+	t.Cleanup(func() { config.AppConfig = saved })
+	config.AppConfig.Avatar.MaxBytes = 1024
+
+	if _, err := UpdateAvatar(1, make([]byte, 1025), ClientInfo{}); !errors.Is(err, ErrAvatarTooLarge) {
+		t.Fatalf("UpdateAvatar() error = %v, want ErrAvatarTooLarge", err)
+	}
+}
@@ -0,0 +1,238 @@
+// backend/internal/service/email_change_service.go
+package service
+
+import (
+	"backend/internal/auth"
+	"backend/internal/config"
+	"backend/internal/domain"
+	"backend/internal/identifier"
+	"backend/internal/mail"
+	"backend/internal/repository"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidCurrentPassword は確認のために入力された現在のパスワードが正しくない場合に返されます。
+	ErrInvalidCurrentPassword = errors.New("現在のパスワードが正しくありません")
+	// ErrSameEmail は新しいメールアドレスが現在のものと同じ場合に返されます。
+	ErrSameEmail = errors.New("新しいメールアドレスが現在のものと同じです")
+	// ErrInvalidEmailChange は確認・取り消しリンクが存在しない、期限切れ、または使用済みの場合に返されます。
+	ErrInvalidEmailChange = errors.New("リンクが無効か期限切れです。もう一度メールアドレスの変更を申請してください")
+)
+
+// EmailChangeRevert はメールアドレス変更の取り消しの結果です。
+type EmailChangeRevert struct {
+	// Restored は確認済みの変更を元のアドレスに戻した場合に true です。確認前の申請を取り消しただけの場合は false です。
+	Restored bool
+	// RevokedSessions は失効させたセッションの数です。
+	RevokedSessions int64
+	// RevokedAPITokens は失効させた個人用アクセストークンの数です。
+	RevokedAPITokens int64
+	// RevokedOAuthTokens は失効させた、外部アプリケーションに許可したリフレッシュトークンの数です。
+	RevokedOAuthTokens int64
+}
+
+// RequestEmailChange は現在のパスワードを確認し、新しいアドレスに確認リンクを、現在のアドレスに取り消しリンク付きの
+// 通知を送信します。メールアドレスは確認リンクが使われた時点で変更され、それまでは何も変わりません。
+// 確認待ちの以前の申請は無効になります。
+func RequestEmailChange(userID int64, newEmail string, currentPassword string, client ClientInfo) error {
+	user, err := repository.GetUserByID(userID)
+	if err != nil {
+		return fmt.Errorf("service.RequestEmailChange: %w", err)
+	}
+	if user == nil || user.DeletedAt.Valid {
+		return ErrUserNotFound
+	}
+
+	audit := func(outcome string, reason string) {
+		entry := AuditEntry{Event: AuditEventEmailChangeRequest, Outcome: outcome, ActorUserID: userID, TargetUserID: userID, Client: client}
+		if reason != "" {
+			entry.Details = map[string]any{"reason": reason}
+		}
+		RecordAudit(entry)
+	}
+
+	// 外部プロバイダーのみで登録したユーザーはパスワードが空のため、ここで必ず失敗します。
+	if !auth.CheckPasswordHash(currentPassword, user.Password) {
+		audit(AuditOutcomeFailure, "invalid_current_password")
+		return ErrInvalidCurrentPassword
+	}
+	if newEmail, err = identifier.NormalizeEmail(newEmail); err != nil {
+		return err
+	}
+	if strings.EqualFold(newEmail, user.Email) {
+		return ErrSameEmail
+	}
+
+	inUse, err := repository.EmailInUse(newEmail, userID)
+	if err != nil {
+		return fmt.Errorf("service.RequestEmailChange: %w", err)
+	}
+	if inUse {
+		audit(AuditOutcomeFailure, "email_taken")
+		return ErrEmailAlreadyRegistered
+	}
+
+	confirmToken, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return fmt.Errorf("service.RequestEmailChange: %w", err)
+	}
+	revertToken, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return fmt.Errorf("service.RequestEmailChange: %w", err)
+	}
+
+	cfg := config.AppConfig.EmailChange
+	now := time.Now()
+	if _, err := repository.CancelPendingEmailChanges(userID); err != nil {
+		return fmt.Errorf("service.RequestEmailChange: %w", err)
+	}
+	if _, err := repository.CreateEmailChange(&domain.EmailChange{
+		UserID:           userID,
+		OldEmail:         user.Email,
+		NewEmail:         newEmail,
+		ConfirmTokenHash: auth.HashToken(confirmToken),
+		RevertTokenHash:  auth.HashToken(revertToken),
+		IPAddress:        client.IPAddress,
+		ExpiresAt:        now.Add(cfg.TTL),
+		RevertExpiresAt:  now.Add(cfg.RevertTTL),
+	}); err != nil {
+		return fmt.Errorf("service.RequestEmailChange: %w", err)
+	}
+
+	confirmLink := cfg.ConfirmURL + "?" + url.Values{"token": {confirmToken}}.Encode()
+	revertLink := cfg.RevertURL + "?" + url.Values{"token": {revertToken}}.Encode()
+	messages := []mail.Message{
+		{
+			To:      newEmail,
+			Subject: "メールアドレスの変更の確認",
+			Body: fmt.Sprintf("%s さん\n\nアカウントのメールアドレスをこのアドレスに変更する申請を受け付けました。"+
+				"以下のリンクを開いて変更を確定してください。リンクの有効期限は %d 時間で、1回のみ使用できます。\n\n%s\n\n"+
+				"このメールに心当たりがない場合は、破棄してください。メールアドレスは変更されません。\n",
+				user.Username, int(cfg.TTL.Hours()), confirmLink),
+		},
+		{
+			To:      user.Email,
+			Subject: "メールアドレスの変更の申請がありました",
+			Body: fmt.Sprintf("%s さん\n\nアカウントのメールアドレスを %s に変更する申請がありました。"+
+				"変更は新しいアドレスで確認された時点で反映されます。\n\n"+
+				"心当たりがない場合は、以下のリンクから申請を取り消してください。変更が反映された後でも %d 日以内であれば"+
+				"元のアドレスに戻し、すべての端末からログアウトして、アクセストークンと外部アプリケーションへの許可を取り消します。"+
+				"取り消した後はパスワードを変更し、連携している外部アカウントと登録されているパスキーに心当たりのないものがないか確認してください。\n\n%s\n",
+				user.Username, newEmail, int(cfg.RevertTTL.Hours()/24), revertLink),
+		},
+	}
+	for _, msg := range messages {
+		go func(msg mail.Message) {
+			if err := mail.Send(msg); err != nil {
+				log.Printf("service.RequestEmailChange: user %d: %v", userID, err)
+			}
+		}(msg)
+	}
+
+	RecordAudit(AuditEntry{Event: AuditEventEmailChangeRequest, Outcome: AuditOutcomeSuccess, ActorUserID: userID, TargetUserID: userID, Client: client,
+		Details: map[string]any{"new_email": newEmail}})
+	return nil
+}
+
+// ConfirmEmailChange は確認リンクのトークンを消費し、メールアドレスを新しいアドレスに変更します。
+// 旧アドレスに送られた未使用のログインリンクは無効になります。
+func ConfirmEmailChange(token string, client ClientInfo) error {
+	change, err := repository.ConfirmEmailChange(auth.HashToken(token))
+	if err != nil {
+		return fmt.Errorf("service.ConfirmEmailChange: %w", err)
+	}
+	if change == nil || time.Now().After(change.ExpiresAt) {
+		return ErrInvalidEmailChange
+	}
+
+	audit := func(outcome string, reason string) {
+		details := map[string]any{"old_email": change.OldEmail, "new_email": change.NewEmail}
+		if reason != "" {
+			details["reason"] = reason
+		}
+		RecordAudit(AuditEntry{Event: AuditEventEmailChange, Outcome: outcome, ActorUserID: change.UserID, TargetUserID: change.UserID, Client: client,
+			Details: details})
+	}
+
+	// 申請から確認までの間に、同じアドレスが別のユーザーに登録されている可能性があります。
+	inUse, err := repository.EmailInUse(change.NewEmail, change.UserID)
+	if err != nil {
+		return fmt.Errorf("service.ConfirmEmailChange: %w", err)
+	}
+	if inUse {
+		audit(AuditOutcomeFailure, "email_taken")
+		return ErrEmailAlreadyRegistered
+	}
+
+	// 申請後にアドレスが変わっていた場合やユーザーが削除された場合は更新されません。
+	updated, err := repository.ChangeUserEmail(change.UserID, change.OldEmail, change.NewEmail)
+	if err != nil {
+		return fmt.Errorf("service.ConfirmEmailChange: %w", err)
+	}
+	if updated == 0 {
+		audit(AuditOutcomeFailure, "stale_request")
+		return ErrInvalidEmailChange
+	}
+
+	if _, err := repository.InvalidateMagicLinks(change.UserID); err != nil {
+		log.Printf("service.ConfirmEmailChange: %v", err)
+	}
+	audit(AuditOutcomeSuccess, "")
+	return nil
+}
+
+// RevertEmailChange は旧アドレスに送られた取り消しリンクのトークンを消費し、申請を取り消します。
+// 変更が既に確定していた場合は元のアドレスに戻します。アカウントが乗っ取られている可能性があるため、
+// いずれの場合もユーザーのすべてのセッション・個人用アクセストークン・外部アプリケーションのリフレッシュトークンを失効させます。
+func RevertEmailChange(token string, client ClientInfo) (*EmailChangeRevert, error) {
+	change, err := repository.RevertEmailChange(auth.HashToken(token))
+	if err != nil {
+		return nil, fmt.Errorf("service.RevertEmailChange: %w", err)
+	}
+	if change == nil || time.Now().After(change.RevertExpiresAt) {
+		return nil, ErrInvalidEmailChange
+	}
+
+	result := &EmailChangeRevert{}
+	if _, err := repository.CancelPendingEmailChanges(change.UserID); err != nil {
+		return nil, fmt.Errorf("service.RevertEmailChange: %w", err)
+	}
+	if change.ConfirmedAt.Valid {
+		// その後さらに別のアドレスに変更されている場合は戻しません。セッションの失効のみ行います。
+		restored, err := repository.ChangeUserEmail(change.UserID, change.NewEmail, change.OldEmail)
+		if err != nil {
+			return nil, fmt.Errorf("service.RevertEmailChange: %w", err)
+		}
+		result.Restored = restored > 0
+		if result.Restored {
+			if _, err := repository.InvalidateMagicLinks(change.UserID); err != nil {
+				log.Printf("service.RevertEmailChange: %v", err)
+			}
+		}
+	}
+
+	revoked, err := RevokeAllSessions(change.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("service.RevertEmailChange: %w", err)
+	}
+	result.RevokedSessions = revoked
+	// 乗っ取った人が発行したアクセストークンや、外部アプリケーションへの許可もログアウト後に使えないようにします。
+	// 外部アカウントの連携とパスキーは本人のものと区別できないため、通知メールで確認を促します。
+	if result.RevokedAPITokens, err = repository.RevokeAPITokensByUserID(change.UserID); err != nil {
+		return nil, fmt.Errorf("service.RevertEmailChange: %w", err)
+	}
+	if result.RevokedOAuthTokens, err = repository.RevokeOAuthRefreshTokensByUserID(change.UserID); err != nil {
+		return nil, fmt.Errorf("service.RevertEmailChange: %w", err)
+	}
+
+	RecordAudit(AuditEntry{Event: AuditEventEmailChangeRevert, Outcome: AuditOutcomeSuccess, TargetUserID: change.UserID, Client: client,
+		Details: map[string]any{"old_email": change.OldEmail, "new_email": change.NewEmail, "restored": result.Restored, "revoked_sessions": revoked,
+			"revoked_api_tokens": result.RevokedAPITokens, "revoked_oauth_tokens": result.RevokedOAuthTokens}})
+	return result, nil
+}
@@ -0,0 +1,177 @@
+// backend/internal/service/invite_code_service.go
+package service
+
+import (
+	"backend/internal/auth"
+	"backend/internal/config"
+	"backend/internal/domain"
+	"backend/internal/identifier"
+	"backend/internal/repository"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// InviteCodePrefix は招待コードの接頭辞です。
+const InviteCodePrefix = "yui_"
+
+// maxInviteCodeUses は1つの招待コードで登録できるユーザー数の上限です。
+const maxInviteCodeUses = 10000
+
+var (
+	// ErrRegistrationClosed は新規登録を受け付けていない場合に返されます。
+	ErrRegistrationClosed = errors.New("現在、新規登録を受け付けていません")
+	// ErrInviteCodeRequired は招待制で招待コードが指定されなかった場合に返されます。
+	ErrInviteCodeRequired = errors.New("登録には招待コードが必要です")
+	// ErrInvalidInviteCode は招待コードが存在しない、失効・使い切り・期限切れ、
+	// または指定されたメールアドレスと異なるメールアドレスで登録しようとした場合に返されます。
+	ErrInvalidInviteCode = errors.New("招待コードが無効か期限切れです")
+	// ErrInviteCodeNotFound は管理者が操作しようとした招待コードが存在しないか、既に失効済みの場合に返されます。
+	ErrInviteCodeNotFound = errors.New("招待コードが見つかりません")
+	// ErrInvalidInviteCodeOptions は招待コードの発行条件が正しくない場合に返されます。
+	ErrInvalidInviteCodeOptions = errors.New("招待コードの発行条件が正しくありません")
+)
+
+// InviteCodeOptions は招待コードの発行条件です。
+type InviteCodeOptions struct {
+	MaxUses   int        // 登録できるユーザー数。0 の場合は 1 です
+	ExpiresAt *time.Time // nil の場合は REGISTRATION_INVITE_TTL 後です
+	Role      string     // 登録したユーザーに付与するロール。空の場合は一般ユーザーです
+	Email     string     // 指定した場合はこのメールアドレスでのみ登録できます
+	Note      string     // 管理者のみが参照するメモ
+}
+
+// CreateInviteCode は招待コードを発行します (管理者用)。
+// 戻り値の招待コードはハッシュのみ保存されるため、この時点でしか確認できません。
+func CreateInviteCode(adminID int64, opts InviteCodeOptions, client ClientInfo) (*domain.InviteCode, string, error) {
+	if opts.MaxUses == 0 {
+		opts.MaxUses = 1
+	}
+	if opts.MaxUses < 0 || opts.MaxUses > maxInviteCodeUses {
+		return nil, "", fmt.Errorf("%w: 使用回数は 1〜%d の範囲で指定してください", ErrInvalidInviteCodeOptions, maxInviteCodeUses)
+	}
+	if opts.Role == "" {
+		opts.Role = domain.RoleUser
+	}
+	if opts.Role != domain.RoleUser && opts.Role != domain.RoleAdmin {
+		return nil, "", fmt.Errorf("%w: 対応していないロールです: %s", ErrInvalidInviteCodeOptions, opts.Role)
+	}
+	expiresAt := time.Now().Add(config.AppConfig.Registration.InviteTTL)
+	if opts.ExpiresAt != nil {
+		if !opts.ExpiresAt.After(time.Now()) {
+			return nil, "", fmt.Errorf("%w: 有効期限は未来の日時である必要があります", ErrInvalidInviteCodeOptions)
+		}
+		expiresAt = *opts.ExpiresAt
+	}
+	if opts.Email != "" {
+		email, err := identifier.NormalizeEmail(opts.Email)
+		if err != nil {
+			return nil, "", err
+		}
+		opts.Email = email
+	}
+
+	idBytes := make([]byte, 4)
+	if _, err := rand.Read(idBytes); err != nil {
+		return nil, "", fmt.Errorf("service.CreateInviteCode: %w", err)
+	}
+	secret, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return nil, "", err
+	}
+	prefix := InviteCodePrefix + hex.EncodeToString(idBytes)
+	code := prefix + "_" + secret
+
+	c := &domain.InviteCode{
+		Prefix:    prefix,
+		CodeHash:  auth.HashToken(code),
+		CreatedBy: adminID,
+		Note:      truncate(opts.Note, 255),
+		Role:      opts.Role,
+		Email:     opts.Email,
+		MaxUses:   opts.MaxUses,
+		ExpiresAt: expiresAt,
+		CreatedAt: time.Now(),
+	}
+	id, err := repository.CreateInviteCode(c)
+	if err != nil {
+		return nil, "", fmt.Errorf("service.CreateInviteCode: %w", err)
+	}
+	c.ID = id
+
+	RecordAudit(AuditEntry{Event: AuditEventInviteCodeCreate, Outcome: AuditOutcomeSuccess, ActorUserID: adminID, Client: client,
+		Details: map[string]any{"invite_code_id": id, "prefix": prefix, "role": c.Role, "email": c.Email, "max_uses": c.MaxUses, "expires_at": c.ExpiresAt}})
+	return c, code, nil
+}
+
+// ListInviteCodes は招待コードの一覧を新しい順に返します (管理者用)。
+// activeOnly が true の場合は、まだ登録に使用できる招待コードのみを返します。
+func ListInviteCodes(activeOnly bool) ([]domain.InviteCode, error) {
+	codes, err := repository.GetInviteCodes(activeOnly, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("service.ListInviteCodes: %w", err)
+	}
+	return codes, nil
+}
+
+// RevokeInviteCode は招待コードを失効させます (管理者用)。既に登録したユーザーには影響しません。
+func RevokeInviteCode(adminID int64, inviteCodeID int64, client ClientInfo) error {
+	revoked, err := repository.RevokeInviteCode(inviteCodeID)
+	if err != nil {
+		return fmt.Errorf("service.RevokeInviteCode: %w", err)
+	}
+	if revoked == 0 {
+		return ErrInviteCodeNotFound
+	}
+
+	RecordAudit(AuditEntry{Event: AuditEventInviteCodeRevoke, Outcome: AuditOutcomeSuccess, ActorUserID: adminID, Client: client,
+		Details: map[string]any{"invite_code_id": inviteCodeID}})
+	return nil
+}
+
+// RegistrationMode は現在の新規登録の受け付け方 (open / invite_only / closed) を返します。
+func RegistrationMode() string {
+	return config.AppConfig.Registration.Mode
+}
+
+// registrationInviteCode は新規登録で使用する招待コードを確認します。
+// 招待コードが指定されなかった場合は、誰でも登録できる設定であれば nil を返します。
+// 指定された場合は登録の受け付け方にかかわらず、有効な招待コードである必要があります。
+// 使用回数はここでは加算せず、ユーザーの作成と同時に加算します (repository.CreateUserWithInviteCode を参照)。
+func registrationInviteCode(code string, email string) (*domain.InviteCode, error) {
+	mode := RegistrationMode()
+	if mode == config.RegistrationModeClosed {
+		return nil, ErrRegistrationClosed
+	}
+	code = strings.TrimSpace(code)
+	if code == "" {
+		if mode == config.RegistrationModeInviteOnly {
+			return nil, ErrInviteCodeRequired
+		}
+		return nil, nil
+	}
+	if !strings.HasPrefix(code, InviteCodePrefix) {
+		return nil, ErrInvalidInviteCode
+	}
+
+	invite, err := repository.GetInviteCodeByHash(auth.HashToken(code))
+	if err != nil {
+		return nil, fmt.Errorf("service.registrationInviteCode: %w", err)
+	}
+	if invite == nil || !invite.Usable(time.Now()) {
+		return nil, ErrInvalidInviteCode
+	}
+	if invite.Email != "" {
+		email, err := identifier.NormalizeEmail(email)
+		if err != nil {
+			return nil, err
+		}
+		if email != invite.Email {
+			return nil, ErrInvalidInviteCode
+		}
+	}
+	return invite, nil
+}
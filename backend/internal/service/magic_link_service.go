@@ -0,0 +1,95 @@
+// backend/internal/service/magic_link_service.go
+package service
+
+import (
+	"backend/internal/auth"
+	"backend/internal/config"
+	"backend/internal/identifier"
+	"backend/internal/mail"
+	"backend/internal/repository"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+)
+
+// ErrInvalidMagicLink はログインリンクが存在しない、期限切れ、または使用済みの場合に返されます。
+var ErrInvalidMagicLink = errors.New("ログインリンクが無効か期限切れです。もう一度リンクを送信してください")
+
+// RequestMagicLink はメールアドレスに1回限り有効なログインリンクを送信します。
+// 登録の有無を推測されないよう、該当するユーザーがいない場合もエラーを返しません。
+// また、送信は応答時間に影響しないようバックグラウンドで行います。
+func RequestMagicLink(email string, client ClientInfo) error {
+	// パスワードでのログインと同じく、正規化したメールアドレスで検索します。形式が正しくない場合も送信済みとして扱います。
+	email, err := identifier.NormalizeEmail(email)
+	if err != nil {
+		return nil
+	}
+	user, err := repository.GetUserByEmail(email)
+	if err != nil {
+		return fmt.Errorf("service.RequestMagicLink: %w", err)
+	}
+	if user == nil || user.DeletedAt.Valid {
+		return nil
+	}
+
+	token, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return fmt.Errorf("service.RequestMagicLink: %w", err)
+	}
+	ttl := config.AppConfig.MagicLink.TTL
+	if err := repository.CreateMagicLink(user.ID, auth.HashToken(token), client.IPAddress, time.Now().Add(ttl)); err != nil {
+		return fmt.Errorf("service.RequestMagicLink: %w", err)
+	}
+
+	// リンク先はフロントエンドのページです。GET で開いただけではトークンは消費されず、
+	// ページからの POST で初めてログインするため、メールスキャナーによる先読みで無効化されません。
+	link := config.AppConfig.MagicLink.URL + "?" + url.Values{"token": {token}}.Encode()
+	msg := mail.Message{
+		To:      user.Email,
+		Subject: "ログイン用リンク",
+		Body: fmt.Sprintf("%s さん\n\n以下のリンクを開いてログインしてください。リンクの有効期限は %d 分で、1回のみ使用できます。\n\n%s\n\n"+
+			"このメールに心当たりがない場合は、破棄してください。\n", user.Username, int(ttl.Minutes()), link),
+	}
+	go func() {
+		if err := mail.Send(msg); err != nil {
+			log.Printf("service.RequestMagicLink: user %d: %v", user.ID, err)
+		}
+	}()
+	return nil
+}
+
+// ConsumeMagicLink はログインリンクのトークンを消費し、Login と同じくセッションを開始してトークンの組を発行します。
+func ConsumeMagicLink(token string, client ClientInfo) (*TokenPair, error) {
+	link, err := repository.ConsumeMagicLink(auth.HashToken(token))
+	if err != nil {
+		return nil, fmt.Errorf("service.ConsumeMagicLink: %w", err)
+	}
+	if link == nil || time.Now().After(link.ExpiresAt) {
+		return nil, ErrInvalidMagicLink
+	}
+
+	user, err := repository.GetUserByID(link.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("service.ConsumeMagicLink: %w", err)
+	}
+	if user == nil || user.DeletedAt.Valid {
+		return nil, ErrInvalidMagicLink
+	}
+
+	// リンクを受け取れたことで、メールアドレスの確認も済んだことになります。
+	if !user.EmailVerifiedAt.Valid {
+		if err := repository.MarkUserEmailVerified(user.ID); err != nil {
+			log.Printf("service.ConsumeMagicLink: %v", err)
+		}
+	}
+
+	pair, err := startSession(user, client)
+	if err != nil {
+		return nil, fmt.Errorf("service.ConsumeMagicLink: %w", err)
+	}
+	RecordAudit(AuditEntry{Event: AuditEventLogin, Outcome: AuditOutcomeSuccess, ActorUserID: user.ID, TargetUserID: user.ID, Client: client,
+		Details: map[string]any{"method": "magic_link"}})
+	return pair, nil
+}
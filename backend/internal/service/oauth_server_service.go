@@ -0,0 +1,603 @@
+// backend/internal/service/oauth_server_service.go
+package service
+
+import (
+	"backend/internal/auth"
+	"backend/internal/config"
+	"backend/internal/domain"
+	"backend/internal/oauth"
+	"backend/internal/repository"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// 認可サーバーが対応するスコープとグラントタイプです。
+var (
+	SupportedScopes     = []string{"openid", "profile", "email", "offline_access"}
+	SupportedGrantTypes = []string{"authorization_code", "client_credentials", "refresh_token"}
+)
+
+// OAuthError は RFC 6749 のエラーレスポンスに対応するエラーです。
+type OAuthError struct {
+	Code        string // invalid_request / invalid_client / invalid_grant など
+	Description string
+}
+
+func (e *OAuthError) Error() string {
+	return e.Code + ": " + e.Description
+}
+
+func oauthError(code, description string) *OAuthError {
+	return &OAuthError{Code: code, Description: description}
+}
+
+// ErrOAuthClientNotFound はクライアントが存在しない、または他のユーザーのものである場合に返されます。
+var ErrOAuthClientNotFound = errors.New("クライアントが見つかりません")
+
+// RegisterClientInput はクライアント登録の入力です。
+type RegisterClientInput struct {
+	Name         string
+	RedirectURIs []string
+	GrantTypes   []string
+	Scopes       []string
+	Public       bool // true の場合はシークレットを発行しません (PKCE 必須)
+}
+
+// RegisterClient はクライアントアプリケーションを登録し、クライアントとシークレットを返します。
+// シークレットはハッシュのみ保存されるため、この戻り値でしか確認できません。
+func RegisterClient(ownerUserID int64, in RegisterClientInput) (*domain.OAuthClient, string, error) {
+	if len(in.GrantTypes) == 0 {
+		in.GrantTypes = []string{"authorization_code", "refresh_token"}
+	}
+	if len(in.Scopes) == 0 {
+		in.Scopes = []string{"openid", "profile", "email"}
+		if slices.Contains(in.GrantTypes, "refresh_token") {
+			in.Scopes = append(in.Scopes, "offline_access")
+		}
+	}
+
+	for _, g := range in.GrantTypes {
+		if !slices.Contains(SupportedGrantTypes, g) {
+			return nil, "", fmt.Errorf("対応していないグラントタイプです: %s", g)
+		}
+	}
+	if in.Public && slices.Contains(in.GrantTypes, "client_credentials") {
+		return nil, "", errors.New("公開クライアントでは client_credentials を使用できません")
+	}
+	for _, s := range in.Scopes {
+		if !slices.Contains(SupportedScopes, s) {
+			return nil, "", fmt.Errorf("対応していないスコープです: %s", s)
+		}
+	}
+	if slices.Contains(in.GrantTypes, "authorization_code") && len(in.RedirectURIs) == 0 {
+		return nil, "", errors.New("authorization_code を使用する場合はリダイレクト URI が必要です")
+	}
+	for _, uri := range in.RedirectURIs {
+		u, err := url.Parse(uri)
+		if err != nil || u.Scheme == "" || u.Fragment != "" {
+			return nil, "", fmt.Errorf("リダイレクト URI が不正です: %s", uri)
+		}
+		if u.Scheme == "http" && !isLoopbackHost(u.Hostname()) {
+			return nil, "", fmt.Errorf("http のリダイレクト URI は localhost のみ使用できます: %s", uri)
+		}
+	}
+
+	clientID, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return nil, "", err
+	}
+	client := &domain.OAuthClient{
+		ClientID:     clientID[:24],
+		Name:         in.Name,
+		RedirectURIs: in.RedirectURIs,
+		GrantTypes:   in.GrantTypes,
+		Scopes:       in.Scopes,
+		OwnerUserID:  ownerUserID,
+		CreatedAt:    time.Now(),
+	}
+
+	var secret string
+	if !in.Public {
+		secret, err = auth.GenerateOpaqueToken()
+		if err != nil {
+			return nil, "", err
+		}
+		client.ClientSecretHash = auth.HashToken(secret)
+	}
+
+	id, err := repository.CreateOAuthClient(client)
+	if err != nil {
+		return nil, "", fmt.Errorf("service.RegisterClient: %w", err)
+	}
+	client.ID = id
+	return client, secret, nil
+}
+
+// ListClients はユーザーが登録したクライアントの一覧を返します。
+func ListClients(ownerUserID int64) ([]domain.OAuthClient, error) {
+	clients, err := repository.GetOAuthClientsByOwner(ownerUserID)
+	if err != nil {
+		return nil, fmt.Errorf("service.ListClients: %w", err)
+	}
+	return clients, nil
+}
+
+// DeleteClient はユーザーが登録したクライアントを削除します。
+func DeleteClient(ownerUserID int64, clientID string) error {
+	deleted, err := repository.DeleteOAuthClient(clientID, ownerUserID)
+	if err != nil {
+		return fmt.Errorf("service.DeleteClient: %w", err)
+	}
+	if deleted == 0 {
+		return ErrOAuthClientNotFound
+	}
+	return nil
+}
+
+// AuthorizationRequest は /oauth/authorize に渡される認可リクエストのパラメーターです。
+type AuthorizationRequest struct {
+	ResponseType        string `form:"response_type" json:"response_type"`
+	ClientID            string `form:"client_id" json:"client_id"`
+	RedirectURI         string `form:"redirect_uri" json:"redirect_uri"`
+	Scope               string `form:"scope" json:"scope"`
+	State               string `form:"state" json:"state"`
+	Nonce               string `form:"nonce" json:"nonce"`
+	CodeChallenge       string `form:"code_challenge" json:"code_challenge"`
+	CodeChallengeMethod string `form:"code_challenge_method" json:"code_challenge_method"`
+}
+
+// AuthorizationContext は同意画面の表示に必要な、検証済みの認可リクエストです。
+type AuthorizationContext struct {
+	Client      *domain.OAuthClient
+	RedirectURI string
+	Scopes      []string
+}
+
+// ValidateAuthorizationRequest は認可リクエストを検証します。
+// クライアントまたはリダイレクト URI が不正な場合、リダイレクトしてはいけないため
+// AuthorizationContext は nil になります。それ以外のエラーではリダイレクト先を含めて返します。
+func ValidateAuthorizationRequest(req AuthorizationRequest) (*AuthorizationContext, error) {
+	client, err := repository.GetOAuthClientByClientID(req.ClientID)
+	if err != nil {
+		return nil, fmt.Errorf("service.ValidateAuthorizationRequest: %w", err)
+	}
+	if client == nil {
+		return nil, oauthError("invalid_client", "クライアントが見つかりません")
+	}
+
+	redirectURI := req.RedirectURI
+	if redirectURI == "" && len(client.RedirectURIs) == 1 {
+		redirectURI = client.RedirectURIs[0]
+	}
+	if !slices.Contains(client.RedirectURIs, redirectURI) {
+		return nil, oauthError("invalid_request", "リダイレクト URI が登録されていません")
+	}
+
+	ac := &AuthorizationContext{Client: client, RedirectURI: redirectURI}
+
+	if req.ResponseType != "code" {
+		return ac, oauthError("unsupported_response_type", "response_type は code のみ対応しています")
+	}
+	if !slices.Contains(client.GrantTypes, "authorization_code") {
+		return ac, oauthError("unauthorized_client", "このクライアントは認可コードフローを使用できません")
+	}
+
+	scopes, err := resolveScopes(client, req.Scope)
+	if err != nil {
+		return ac, err
+	}
+	ac.Scopes = scopes
+
+	if req.CodeChallenge != "" && req.CodeChallengeMethod != "S256" {
+		return ac, oauthError("invalid_request", "code_challenge_method は S256 のみ対応しています")
+	}
+	if client.IsPublic() && req.CodeChallenge == "" {
+		return ac, oauthError("invalid_request", "公開クライアントでは PKCE (code_challenge) が必須です")
+	}
+	return ac, nil
+}
+
+// Authorize はユーザーの同意結果に応じて、認可コードまたはエラーを付けたリダイレクト先 URL を返します。
+// sessionID は同意したログインセッションで、そのログイン日時を ID トークンの auth_time にします。
+// セッションが無効な場合は ErrSessionRevoked を返します。
+func Authorize(userID int64, sessionID int64, req AuthorizationRequest, approved bool) (string, error) {
+	ac, err := ValidateAuthorizationRequest(req)
+	if ac == nil {
+		return "", err
+	}
+	if err != nil {
+		var oerr *OAuthError
+		if errors.As(err, &oerr) {
+			return AuthorizationErrorRedirect(ac, oerr, req.State), nil
+		}
+		return "", err
+	}
+
+	if !approved {
+		return AuthorizationErrorRedirect(ac, oauthError("access_denied", "ユーザーが拒否しました"), req.State), nil
+	}
+
+	// auth_time は同意した日時ではなく、ユーザーがログインした日時です (max_age を確認するクライアントのため)。
+	session, err := repository.GetSessionByID(sessionID)
+	if err != nil {
+		return "", fmt.Errorf("service.Authorize: %w", err)
+	}
+	if session == nil || session.UserID != userID || session.RevokedAt.Valid {
+		return "", ErrSessionRevoked
+	}
+
+	code, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+	err = repository.CreateAuthorizationCode(auth.HashToken(code), &domain.OAuthAuthorizationCode{
+		ClientID:      ac.Client.ClientID,
+		UserID:        userID,
+		RedirectURI:   req.RedirectURI, // 省略された場合はトークンリクエストでも省略されている必要があります
+		Scope:         strings.Join(ac.Scopes, " "),
+		Nonce:         req.Nonce,
+		CodeChallenge: req.CodeChallenge,
+		AuthTime:      sql.NullTime{Time: session.CreatedAt, Valid: true},
+		ExpiresAt:     time.Now().Add(config.AppConfig.AuthServer.AuthCodeTTL),
+	})
+	if err != nil {
+		return "", fmt.Errorf("service.Authorize: %w", err)
+	}
+
+	return authorizationRedirect(ac.RedirectURI, url.Values{"code": {code}, "state": {req.State}}), nil
+}
+
+// AuthorizationErrorRedirect はクライアントにエラーを通知するリダイレクト先 URL を返します。
+func AuthorizationErrorRedirect(ac *AuthorizationContext, oerr *OAuthError, state string) string {
+	return authorizationRedirect(ac.RedirectURI, url.Values{"error": {oerr.Code}, "error_description": {oerr.Description}, "state": {state}})
+}
+
+// TokenRequest は /oauth/token に渡されるパラメーターです。
+type TokenRequest struct {
+	GrantType    string
+	ClientID     string
+	ClientSecret string
+	Code         string
+	RedirectURI  string
+	CodeVerifier string
+	RefreshToken string
+	Scope        string
+}
+
+// TokenResponse は /oauth/token のレスポンスです。
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	IDToken      string `json:"id_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+}
+
+// Token はグラントタイプに応じてトークンを発行します。
+func Token(req TokenRequest) (*TokenResponse, error) {
+	client, err := authenticateClient(req.ClientID, req.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(client.GrantTypes, req.GrantType) {
+		if !slices.Contains(SupportedGrantTypes, req.GrantType) {
+			return nil, oauthError("unsupported_grant_type", "対応していないグラントタイプです")
+		}
+		return nil, oauthError("unauthorized_client", "このクライアントはこのグラントタイプを使用できません")
+	}
+
+	switch req.GrantType {
+	case "authorization_code":
+		return tokenFromAuthorizationCode(client, req)
+	case "client_credentials":
+		return tokenFromClientCredentials(client, req)
+	default:
+		return tokenFromRefreshToken(client, req)
+	}
+}
+
+func tokenFromAuthorizationCode(client *domain.OAuthClient, req TokenRequest) (*TokenResponse, error) {
+	code, err := repository.ConsumeAuthorizationCode(auth.HashToken(req.Code))
+	if err != nil {
+		return nil, fmt.Errorf("service.Token: %w", err)
+	}
+	if code == nil || code.ClientID != client.ClientID || time.Now().After(code.ExpiresAt) {
+		return nil, oauthError("invalid_grant", "認可コードが無効か期限切れです")
+	}
+	if req.RedirectURI != code.RedirectURI {
+		return nil, oauthError("invalid_grant", "redirect_uri が認可リクエストと一致しません")
+	}
+	if code.CodeChallenge != "" {
+		if req.CodeVerifier == "" ||
+			subtle.ConstantTimeCompare([]byte(oauth.CodeChallenge(req.CodeVerifier)), []byte(code.CodeChallenge)) != 1 {
+			return nil, oauthError("invalid_grant", "code_verifier が一致しません")
+		}
+	}
+
+	user, err := activeUser(code.UserID)
+	if err != nil {
+		return nil, err
+	}
+	var authTime time.Time
+	if code.AuthTime.Valid {
+		authTime = code.AuthTime.Time
+	}
+	return issueClientTokens(client, user, code.Scope, code.Nonce, authTime)
+}
+
+func tokenFromClientCredentials(client *domain.OAuthClient, req TokenRequest) (*TokenResponse, error) {
+	// client_credentials ではユーザーに関するスコープは意味を持たないため除外します。
+	var scopes []string
+	requested, err := resolveScopes(client, req.Scope)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range requested {
+		if s != "openid" && s != "offline_access" {
+			scopes = append(scopes, s)
+		}
+	}
+
+	cfg := config.AppConfig.AuthServer
+	accessToken, err := auth.GenerateAccessToken(&auth.AccessTokenClaims{
+		Claims: auth.Claims{
+			RegisteredClaims: auth.NewRegisteredClaims(cfg.Issuer, client.ClientID, client.ClientID, cfg.AccessTokenTTL),
+		},
+		ClientID: client.ClientID,
+		Scope:    strings.Join(scopes, " "),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("service.Token: %w", err)
+	}
+	return &TokenResponse{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(cfg.AccessTokenTTL.Seconds()),
+		Scope:       strings.Join(scopes, " "),
+	}, nil
+}
+
+func tokenFromRefreshToken(client *domain.OAuthClient, req TokenRequest) (*TokenResponse, error) {
+	stored, err := repository.GetOAuthRefreshTokenByHash(auth.HashToken(req.RefreshToken))
+	if err != nil {
+		return nil, fmt.Errorf("service.Token: %w", err)
+	}
+	if stored == nil || stored.ClientID != client.ClientID || stored.RevokedAt.Valid || time.Now().After(stored.ExpiresAt) {
+		return nil, oauthError("invalid_grant", "リフレッシュトークンが無効か期限切れです")
+	}
+	revoked, err := repository.RevokeOAuthRefreshToken(stored.ID)
+	if err != nil {
+		return nil, fmt.Errorf("service.Token: %w", err)
+	}
+	if revoked == 0 {
+		return nil, oauthError("invalid_grant", "リフレッシュトークンが無効か期限切れです")
+	}
+
+	// スコープは元の範囲内に限り縮小できます。
+	scope := stored.Scope
+	if req.Scope != "" {
+		granted := strings.Fields(stored.Scope)
+		for _, s := range strings.Fields(req.Scope) {
+			if !slices.Contains(granted, s) {
+				return nil, oauthError("invalid_scope", "元のスコープを超えることはできません")
+			}
+		}
+		scope = req.Scope
+	}
+
+	user, err := activeUser(stored.UserID)
+	if err != nil {
+		return nil, err
+	}
+	return issueClientTokens(client, user, scope, "", time.Time{})
+}
+
+// issueClientTokens はユーザーの代理としてクライアントにアクセストークン・ID トークン・リフレッシュトークンを発行します。
+func issueClientTokens(client *domain.OAuthClient, user *domain.User, scope string, nonce string, authTime time.Time) (*TokenResponse, error) {
+	cfg := config.AppConfig.AuthServer
+	scopes := strings.Fields(scope)
+	subject := strconv.FormatInt(user.ID, 10)
+
+	accessToken, err := auth.GenerateAccessToken(&auth.AccessTokenClaims{
+		Claims: auth.Claims{
+			UserID:           user.ID,
+			Username:         user.Username,
+			RegisteredClaims: auth.NewRegisteredClaims(cfg.Issuer, subject, client.ClientID, cfg.AccessTokenTTL),
+		},
+		ClientID: client.ClientID,
+		Scope:    scope,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("service.Token: %w", err)
+	}
+
+	res := &TokenResponse{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(cfg.AccessTokenTTL.Seconds()),
+		Scope:       scope,
+	}
+
+	if slices.Contains(scopes, "openid") {
+		idClaims := &auth.IDTokenClaims{
+			Claims: auth.Claims{
+				UserID:           user.ID,
+				Username:         user.Username,
+				RegisteredClaims: auth.NewRegisteredClaims(cfg.Issuer, subject, client.ClientID, cfg.AccessTokenTTL),
+			},
+			Nonce: nonce,
+		}
+		if !authTime.IsZero() {
+			idClaims.AuthTime = jwt.NewNumericDate(authTime)
+		}
+		if slices.Contains(scopes, "profile") {
+			idClaims.PreferredUsername = user.Username
+		}
+		if slices.Contains(scopes, "email") {
+			idClaims.Email = user.Email
+		}
+		if res.IDToken, err = auth.GenerateIDToken(idClaims); err != nil {
+			return nil, fmt.Errorf("service.Token: %w", err)
+		}
+	}
+
+	if issuesRefreshToken(client, scopes) {
+		refreshToken, err := auth.GenerateOpaqueToken()
+		if err != nil {
+			return nil, err
+		}
+		expiresAt := time.Now().Add(cfg.RefreshTokenTTL)
+		if err := repository.CreateOAuthRefreshToken(auth.HashToken(refreshToken), client.ClientID, user.ID, scope, expiresAt); err != nil {
+			return nil, fmt.Errorf("service.Token: %w", err)
+		}
+		res.RefreshToken = refreshToken
+	}
+	return res, nil
+}
+
+// issuesRefreshToken はリフレッシュトークンを発行するかを返します。
+// OpenID Connect のリクエストでは、ユーザーが offline_access に同意した場合に限り発行します。
+// これにより、ユーザーは短期間のアクセスのみを許可できます。
+func issuesRefreshToken(client *domain.OAuthClient, scopes []string) bool {
+	if !slices.Contains(client.GrantTypes, "refresh_token") {
+		return false
+	}
+	return slices.Contains(scopes, "offline_access") || !slices.Contains(scopes, "openid")
+}
+
+// UserInfo はアクセストークンのスコープに応じたユーザー情報を返します。
+func UserInfo(accessToken string) (map[string]any, error) {
+	claims, err := auth.ValidateAccessToken(accessToken, config.AppConfig.AuthServer.Issuer)
+	if err != nil || claims.UserID == 0 {
+		return nil, oauthError("invalid_token", "アクセストークンが無効です")
+	}
+	scopes := strings.Fields(claims.Scope)
+	if !slices.Contains(scopes, "openid") {
+		return nil, oauthError("insufficient_scope", "openid スコープが必要です")
+	}
+
+	user, err := activeUser(claims.UserID)
+	if err != nil {
+		return nil, oauthError("invalid_token", "ユーザーが存在しません")
+	}
+
+	info := map[string]any{"sub": claims.Subject}
+	if slices.Contains(scopes, "profile") {
+		info["preferred_username"] = user.Username
+		info["updated_at"] = user.UpdatedAt.Unix()
+	}
+	if slices.Contains(scopes, "email") {
+		info["email"] = user.Email
+	}
+	return info, nil
+}
+
+// DiscoveryDocument は /.well-known/openid-configuration の内容を返します。
+func DiscoveryDocument() map[string]any {
+	cfg := config.AppConfig.AuthServer
+	issuer := strings.TrimRight(cfg.Issuer, "/")
+	authorizationEndpoint := cfg.AuthorizationEndpoint
+	if authorizationEndpoint == "" {
+		authorizationEndpoint = issuer + "/oauth/authorize"
+	}
+
+	return map[string]any{
+		"issuer":                                issuer,
+		"authorization_endpoint":                authorizationEndpoint,
+		"token_endpoint":                        issuer + "/oauth/token",
+		"userinfo_endpoint":                     issuer + "/oauth/userinfo",
+		"jwks_uri":                              issuer + "/oauth/jwks",
+		"response_types_supported":              []string{"code"},
+		"grant_types_supported":                 SupportedGrantTypes,
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"scopes_supported":                      SupportedScopes,
+		"token_endpoint_auth_methods_supported": []string{"client_secret_basic", "client_secret_post", "none"},
+		"code_challenge_methods_supported":      []string{"S256"},
+		"claims_supported":                      []string{"sub", "iss", "aud", "exp", "iat", "auth_time", "nonce", "preferred_username", "email"},
+	}
+}
+
+// authenticateClient はクライアントIDとシークレットでクライアントを認証します。
+// 公開クライアントはシークレットを送ってはいけません。
+func authenticateClient(clientID, clientSecret string) (*domain.OAuthClient, error) {
+	if clientID == "" {
+		return nil, oauthError("invalid_client", "クライアント認証に失敗しました")
+	}
+	client, err := repository.GetOAuthClientByClientID(clientID)
+	if err != nil {
+		return nil, fmt.Errorf("service.authenticateClient: %w", err)
+	}
+	if client == nil {
+		return nil, oauthError("invalid_client", "クライアント認証に失敗しました")
+	}
+
+	if client.IsPublic() {
+		if clientSecret != "" {
+			return nil, oauthError("invalid_client", "クライアント認証に失敗しました")
+		}
+		return client, nil
+	}
+	if subtle.ConstantTimeCompare([]byte(auth.HashToken(clientSecret)), []byte(client.ClientSecretHash)) != 1 {
+		return nil, oauthError("invalid_client", "クライアント認証に失敗しました")
+	}
+	return client, nil
+}
+
+// resolveScopes は要求されたスコープを検証します。省略時はクライアントに許可されたすべてのスコープになります。
+func resolveScopes(client *domain.OAuthClient, scope string) ([]string, error) {
+	requested := strings.Fields(scope)
+	if len(requested) == 0 {
+		return client.Scopes, nil
+	}
+	for _, s := range requested {
+		if !slices.Contains(client.Scopes, s) {
+			return nil, oauthError("invalid_scope", "許可されていないスコープです: "+s)
+		}
+	}
+	return requested, nil
+}
+
+// activeUser は削除されていないユーザーを取得します。
+func activeUser(userID int64) (*domain.User, error) {
+	user, err := repository.GetUserByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("service.activeUser: %w", err)
+	}
+	if user == nil || user.DeletedAt.Valid {
+		return nil, oauthError("invalid_grant", "ユーザーが存在しません")
+	}
+	if user.SuspendedAt.Valid {
+		return nil, oauthError("invalid_grant", "ユーザーが停止されています")
+	}
+	return user, nil
+}
+
+// authorizationRedirect はリダイレクト URI にクエリパラメーターを付けた URL を返します。
+func authorizationRedirect(redirectURI string, params url.Values) string {
+	if params.Get("state") == "" {
+		params.Del("state")
+	}
+	u, _ := url.Parse(redirectURI)
+	q := u.Query()
+	for k, v := range params {
+		q[k] = v
+	}
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// isLoopbackHost はホスト名がループバックを指すかどうかを返します。
+func isLoopbackHost(host string) bool {
+	return host == "localhost" || host == "127.0.0.1" || host == "::1"
+}
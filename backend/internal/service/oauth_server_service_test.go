@@ -0,0 +1,29 @@
+// backend/internal/service/oauth_server_service_test.go
+package service
+
+import (
+	"backend/internal/domain"
+	"testing"
+)
+
+func TestIssuesRefreshToken(t *testing.T) {
+	withRefresh := &domain.OAuthClient{GrantTypes: []string{"authorization_code", "refresh_token"}}
+	withoutRefresh := &domain.OAuthClient{GrantTypes: []string{"authorization_code"}}
+
+	tests := []struct {
+		name   string
+		client *domain.OAuthClient
+		scopes []string
+		want   bool
+	}{
+		{"openid with offline_access", withRefresh, []string{"openid", "profile", "offline_access"}, true},
+		{"openid without offline_access", withRefresh, []string{"openid", "profile"}, false},
+		{"plain OAuth", withRefresh, []string{"profile", "email"}, true},
+		{"client without refresh_token grant", withoutRefresh, []string{"openid", "offline_access"}, false},
+	}
+	for _, tt := range tests {
+		if got := issuesRefreshToken(tt.client, tt.scopes); got != tt.want {
+			t.Errorf("%s: issuesRefreshToken() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,288 @@
+// backend/internal/service/oauth_service.go
+package service
+
+import (
+	"backend/internal/auth"
+	"backend/internal/config"
+	"backend/internal/domain"
+	"backend/internal/identifier"
+	"backend/internal/oauth"
+	"backend/internal/repository"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// OAuthStateTTL は認可リクエストを開始してからコールバックまでの有効期間です。
+const OAuthStateTTL = 10 * time.Minute
+
+var (
+	// ErrInvalidOAuthState は state が存在しない、期限切れ、または別の用途のものである場合に返されます。
+	ErrInvalidOAuthState = errors.New("認証リクエストが無効か期限切れです。もう一度お試しください")
+	// ErrIdentityAlreadyLinked は外部アカウントがすでに別のユーザーに紐付いている場合に返されます。
+	ErrIdentityAlreadyLinked = errors.New("この外部アカウントはすでに別のユーザーに連携されています")
+	// ErrEmailAlreadyRegistered は外部アカウントのメールアドレスが既存のユーザーで使われている場合に返されます。
+	ErrEmailAlreadyRegistered = errors.New("このメールアドレスは既に登録されています。ログイン後にアカウント連携を行ってください")
+	// ErrEmailNotVerified は外部アカウントに確認済みのメールアドレスがない場合に返されます。
+	ErrEmailNotVerified = errors.New("外部アカウントに確認済みのメールアドレスがありません")
+	// ErrIdentityNotFound は連携が存在しない、または他のユーザーのものである場合に返されます。
+	ErrIdentityNotFound = errors.New("連携が見つかりません")
+	// ErrLastLoginMethod はパスワードのないユーザーが最後の連携を解除しようとした場合に返されます。
+	ErrLastLoginMethod = errors.New("ログイン手段がなくなるため、この連携は解除できません。先にパスワードを設定してください")
+	// ErrOAuthProviderFailed は外部プロバイダーで認可コードをアカウントの情報に交換できなかった場合に返されます。
+	ErrOAuthProviderFailed = errors.New("外部プロバイダーでの認証に失敗しました")
+)
+
+// StartOAuth は外部プロバイダーの認可 URL と state を生成します。
+// linkUserID が 0 以外の場合は、ログインではなくそのユーザーへのアカウント連携として扱います。
+// 呼び出し側は返された state を認可リクエストを開始したブラウザに紐付け、コールバックで照合する必要があります。
+func StartOAuth(ctx context.Context, providerName string, linkUserID int64) (authURL string, state string, err error) {
+	provider, err := oauth.Get(providerName)
+	if err != nil {
+		return "", "", err
+	}
+
+	state, err = auth.GenerateOpaqueToken()
+	if err != nil {
+		return "", "", err
+	}
+	verifier, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return "", "", err
+	}
+
+	authURL, err = provider.AuthCodeURL(ctx, state, oauth.CodeChallenge(verifier), nonce)
+	if err != nil {
+		return "", "", fmt.Errorf("%w: %w", ErrOAuthProviderFailed, err)
+	}
+
+	link := sql.NullInt64{Int64: linkUserID, Valid: linkUserID != 0}
+	if err := repository.CreateOAuthState(auth.HashToken(state), providerName, verifier, nonce, link, time.Now().Add(OAuthStateTTL)); err != nil {
+		return "", "", fmt.Errorf("service.StartOAuth: %w", err)
+	}
+	return authURL, state, nil
+}
+
+// CompleteOAuthLogin は認可コードを検証し、紐付くユーザーでログインします。
+// 紐付くユーザーがいない場合は、確認済みのメールアドレスでユーザーを新規作成します (JIT プロビジョニング)。
+func CompleteOAuthLogin(ctx context.Context, providerName string, code string, state string, client ClientInfo) (*TokenPair, error) {
+	identity, err := exchangeOAuthCode(ctx, providerName, code, state, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	linked, err := repository.GetUserIdentity(identity.Provider, identity.Subject)
+	if err != nil {
+		return nil, fmt.Errorf("service.CompleteOAuthLogin: %w", err)
+	}
+
+	var user *domain.User
+	if linked != nil {
+		user, err = repository.GetUserByID(linked.UserID)
+		if err != nil {
+			return nil, fmt.Errorf("service.CompleteOAuthLogin: %w", err)
+		}
+		if user == nil || user.DeletedAt.Valid {
+			return nil, errors.New("連携されたユーザーが存在しません")
+		}
+	} else {
+		user, err = provisionExternalUser(identity)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	pair, err := startSession(user, client)
+	if err != nil {
+		return nil, fmt.Errorf("service.CompleteOAuthLogin: %w", err)
+	}
+	RecordAudit(AuditEntry{Event: AuditEventLogin, Outcome: AuditOutcomeSuccess, ActorUserID: user.ID, TargetUserID: user.ID, Client: client,
+		Details: map[string]any{"method": "oauth", "provider": identity.Provider}})
+	return pair, nil
+}
+
+// LinkIdentity は認可コードを検証し、外部アカウントをログイン中のユーザーに紐付けます。
+func LinkIdentity(ctx context.Context, userID int64, providerName string, code string, state string) (*domain.UserIdentity, error) {
+	identity, err := exchangeOAuthCode(ctx, providerName, code, state, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	linked, err := repository.GetUserIdentity(identity.Provider, identity.Subject)
+	if err != nil {
+		return nil, fmt.Errorf("service.LinkIdentity: %w", err)
+	}
+	if linked != nil {
+		if linked.UserID != userID {
+			return nil, ErrIdentityAlreadyLinked
+		}
+		return linked, nil
+	}
+
+	id, err := repository.CreateUserIdentity(userID, identity.Provider, identity.Subject, identity.Email)
+	if err != nil {
+		return nil, fmt.Errorf("service.LinkIdentity: %w", err)
+	}
+	return &domain.UserIdentity{
+		ID:        id,
+		UserID:    userID,
+		Provider:  identity.Provider,
+		Subject:   identity.Subject,
+		Email:     identity.Email,
+		CreatedAt: time.Now(),
+	}, nil
+}
+
+// ListIdentities はユーザーに連携されている外部アカウントの一覧を返します。
+func ListIdentities(userID int64) ([]domain.UserIdentity, error) {
+	identities, err := repository.GetUserIdentitiesByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("service.ListIdentities: %w", err)
+	}
+	return identities, nil
+}
+
+// UnlinkIdentity は外部アカウントの連携を解除します。
+// パスワードが設定されていないユーザーは、最後の1つを解除できません。
+func UnlinkIdentity(userID int64, providerName string) error {
+	user, err := repository.GetUserByID(userID)
+	if err != nil {
+		return fmt.Errorf("service.UnlinkIdentity: %w", err)
+	}
+	if user == nil {
+		return ErrIdentityNotFound
+	}
+
+	if user.Password == "" {
+		identities, err := repository.GetUserIdentitiesByUserID(userID)
+		if err != nil {
+			return fmt.Errorf("service.UnlinkIdentity: %w", err)
+		}
+		if len(identities) <= 1 {
+			return ErrLastLoginMethod
+		}
+	}
+
+	deleted, err := repository.DeleteUserIdentity(userID, providerName)
+	if err != nil {
+		return fmt.Errorf("service.UnlinkIdentity: %w", err)
+	}
+	if deleted == 0 {
+		return ErrIdentityNotFound
+	}
+	return nil
+}
+
+// exchangeOAuthCode は state を消費して検証し、認可コードを外部アカウントの情報に交換します。
+func exchangeOAuthCode(ctx context.Context, providerName string, code string, state string, linkUserID int64) (*oauth.Identity, error) {
+	provider, err := oauth.Get(providerName)
+	if err != nil {
+		return nil, err
+	}
+
+	stored, err := repository.ConsumeOAuthState(auth.HashToken(state))
+	if err != nil {
+		return nil, fmt.Errorf("service.exchangeOAuthCode: %w", err)
+	}
+	// state はプロバイダーと用途 (ログイン / 連携先のユーザー) の両方が一致する必要があります。
+	if stored == nil || stored.Provider != providerName || time.Now().After(stored.ExpiresAt) ||
+		stored.LinkUserID.Int64 != linkUserID {
+		return nil, ErrInvalidOAuthState
+	}
+
+	identity, err := provider.Exchange(ctx, code, stored.CodeVerifier, stored.Nonce)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrOAuthProviderFailed, err)
+	}
+	return identity, nil
+}
+
+// provisionExternalUser は外部アカウントの情報からユーザーを作成し、連携を登録します。
+func provisionExternalUser(identity *oauth.Identity) (*domain.User, error) {
+	// 外部プロバイダーでの初回ログインでは招待コードを受け取れないため、誰でも登録できる設定の場合のみ作成します。
+	if RegistrationMode() != config.RegistrationModeOpen {
+		return nil, ErrRegistrationClosed
+	}
+	if identity.Email == "" || !identity.EmailVerified {
+		return nil, ErrEmailNotVerified
+	}
+	email, err := identifier.NormalizeEmail(identity.Email)
+	if err != nil {
+		return nil, ErrEmailNotVerified
+	}
+
+	// 既存ユーザーへの自動連携はアカウント乗っ取りにつながるため行いません。
+	inUse, err := repository.EmailInUse(email, 0)
+	if err != nil {
+		return nil, fmt.Errorf("service.provisionExternalUser: %w", err)
+	}
+	if inUse {
+		return nil, ErrEmailAlreadyRegistered
+	}
+
+	username, err := availableUsername(identity)
+	if err != nil {
+		return nil, err
+	}
+
+	userID, err := repository.CreateExternalUser(username, email, identity.Provider, identity.Subject)
+	if err != nil {
+		return nil, fmt.Errorf("service.provisionExternalUser: %w", err)
+	}
+
+	user, err := repository.GetUserByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("service.provisionExternalUser: %w", err)
+	}
+	return user, nil
+}
+
+var usernameDisallowed = regexp.MustCompile(`[^a-z0-9_]+`)
+
+// availableUsername は外部アカウントの情報から未使用のユーザー名を決定します。
+func availableUsername(identity *oauth.Identity) (string, error) {
+	base := externalUsernameBase(identity)
+	candidate := base
+	for i := 0; i < 5; i++ {
+		err := checkUsernameAvailable(candidate, 0)
+		if err == nil {
+			return candidate, nil
+		}
+		if !errors.Is(err, ErrUsernameTaken) && !errors.Is(err, ErrUsernameReserved) {
+			return "", fmt.Errorf("service.availableUsername: %w", err)
+		}
+		n, err := rand.Int(rand.Reader, big.NewInt(10000))
+		if err != nil {
+			return "", err
+		}
+		candidate = fmt.Sprintf("%s_%04d", base, n.Int64())
+	}
+	return "", errors.New("ユーザー名を決定できませんでした。もう一度お試しください")
+}
+
+// externalUsernameBase は外部アカウントのユーザー名 (なければメールアドレスのローカル部) から、ユーザー名の候補を作ります。
+func externalUsernameBase(identity *oauth.Identity) string {
+	base := identity.Username
+	if base == "" {
+		base, _, _ = strings.Cut(identity.Email, "@")
+	}
+	base = usernameDisallowed.ReplaceAllString(strings.ToLower(base), "_")
+	base = strings.Trim(base, "_")
+	if len(base) < 3 {
+		base = "user"
+	}
+	if len(base) > 24 {
+		base = base[:24]
+	}
+	return base
+}
@@ -0,0 +1,68 @@
+// backend/internal/service/oauth_service_test.go
+package service
+
+import (
+	"backend/internal/config"
+	"backend/internal/identifier"
+	"backend/internal/oauth"
+	"errors"
+	"testing"
+)
+
+// データベースに触れる前に拒否される場合のみを確認します。
+func TestProvisionExternalUserRejects(t *testing.T) {
+	saved := config.AppConfig
+	t.Cleanup(func() { config.AppConfig = saved })
+
+	verified := &oauth.Identity{Provider: "test", Subject: "subject-1", Email: "alice@example.com", EmailVerified: true}
+	tests := []struct {
+		name     string
+		mode     string
+		identity *oauth.Identity
+		want     error
+	}{
+		{"invite only", config.RegistrationModeInviteOnly, verified, ErrRegistrationClosed},
+		{"closed", config.RegistrationModeClosed, verified, ErrRegistrationClosed},
+		{"unverified email", config.RegistrationModeOpen,
+			&oauth.Identity{Provider: "test", Subject: "subject-1", Email: "alice@example.com"}, ErrEmailNotVerified},
+		{"missing email", config.RegistrationModeOpen,
+			&oauth.Identity{Provider: "test", Subject: "subject-1", EmailVerified: true}, ErrEmailNotVerified},
+		{"invalid email", config.RegistrationModeOpen,
+			&oauth.Identity{Provider: "test", Subject: "subject-1", Email: "not an email", EmailVerified: true}, ErrEmailNotVerified},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.AppConfig.Registration.Mode = tt.mode
+			if _, err := provisionExternalUser(tt.identity); !errors.Is(err, tt.want) {
+				t.Fatalf("provisionExternalUser() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestExternalUsernameBase(t *testing.T) {
+	tests := []struct {
+		username string
+		email    string
+		want     string
+	}{
+		{"Alice", "someone@example.com", "alice"},
+		{"", "bob.smith+news@example.com", "bob_smith_news"},
+		{"__carol--dev__", "", "carol_dev"},
+		{"", "ab@example.com", "user"},
+		{"山田", "yamada@example.com", "user"},
+		{"a-very-long-provider-username-1234567890", "", "a_very_long_provider_use"},
+	}
+	for _, tt := range tests {
+		got := externalUsernameBase(&oauth.Identity{Username: tt.username, Email: tt.email})
+		if got != tt.want {
+			t.Errorf("externalUsernameBase(%q, %q) = %q, want %q", tt.username, tt.email, got, tt.want)
+		}
+		// 連番を付けた候補も含め、通常の登録と同じ規則を満たす必要があります。
+		for _, candidate := range []string{got, got + "_0042"} {
+			if _, err := identifier.NormalizeUsername(candidate); err != nil {
+				t.Errorf("NormalizeUsername(%q): %v", candidate, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,263 @@
+// backend/internal/service/passkey_service.go
+package service
+
+import (
+	"backend/internal/auth"
+	"backend/internal/config"
+	"backend/internal/domain"
+	"backend/internal/repository"
+	"backend/internal/webauthn"
+	"bytes"
+	"database/sql"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// チャレンジの用途です。登録用のチャレンジをログインに使い回せないようにします。
+const (
+	ceremonyRegistration = "registration"
+	ceremonyLogin        = "login"
+)
+
+var (
+	// ErrInvalidPasskeyChallenge はチャレンジが存在しない、期限切れ、または別の用途のものである場合に返されます。
+	ErrInvalidPasskeyChallenge = errors.New("パスキーの確認の有効期限が切れました。もう一度お試しください")
+	// ErrPasskeyVerification は認証器の応答の検証に失敗した場合に返されます。
+	ErrPasskeyVerification = errors.New("パスキーを確認できませんでした")
+	// ErrPasskeyAlreadyRegistered は同じ資格情報が既に登録されている場合に返されます。
+	ErrPasskeyAlreadyRegistered = errors.New("このパスキーは既に登録されています")
+	// ErrPasskeyNotFound はパスキーが存在しない、または他のユーザーのものである場合に返されます。
+	ErrPasskeyNotFound = errors.New("パスキーが見つかりません")
+)
+
+// relyingParty は設定から WebAuthn の RP 情報を作成します。
+func relyingParty() *webauthn.RelyingParty {
+	cfg := config.AppConfig.WebAuthn
+	return &webauthn.RelyingParty{
+		ID:               cfg.RPID,
+		Name:             cfg.RPName,
+		Origins:          cfg.Origins,
+		UserVerification: cfg.UserVerification,
+		Timeout:          cfg.Timeout,
+	}
+}
+
+// userHandle はユーザーIDから WebAuthn のユーザーハンドルを作成します。
+// ユーザー名やメールアドレスなどの個人情報を認証器に保存しないよう、内部IDのみを使います。
+func userHandle(userID int64) []byte {
+	return binary.BigEndian.AppendUint64(nil, uint64(userID))
+}
+
+// BeginPasskeyRegistration はログイン中のユーザーのパスキー登録を開始し、
+// navigator.credentials.create() に渡すオプションを返します。
+func BeginPasskeyRegistration(userID int64) (*webauthn.CreationOptions, error) {
+	user, err := repository.GetUserByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("service.BeginPasskeyRegistration: %w", err)
+	}
+	if user == nil || user.DeletedAt.Valid {
+		return nil, ErrUserNotFound
+	}
+
+	existing, err := repository.GetWebAuthnCredentialsByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("service.BeginPasskeyRegistration: %w", err)
+	}
+	exclude := make([]webauthn.CredentialDescriptor, 0, len(existing))
+	for _, c := range existing {
+		exclude = append(exclude, webauthn.CredentialDescriptor{Type: "public-key", ID: c.CredentialID, Transports: c.Transports})
+	}
+
+	challenge, err := newPasskeyChallenge(sql.NullInt64{Int64: userID, Valid: true}, ceremonyRegistration)
+	if err != nil {
+		return nil, fmt.Errorf("service.BeginPasskeyRegistration: %w", err)
+	}
+
+	entity := webauthn.UserEntity{ID: userHandle(userID), Name: user.Username, DisplayName: user.Username}
+	return relyingParty().CreationOptions(challenge, entity, exclude), nil
+}
+
+// FinishPasskeyRegistration は認証器の応答を検証し、パスキーを保存します。
+func FinishPasskeyRegistration(userID int64, name string, res *webauthn.RegistrationResponse) (*domain.WebAuthnCredential, error) {
+	cd, err := webauthn.ParseClientData(res.Response.ClientDataJSON)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPasskeyVerification, err)
+	}
+	if err := consumePasskeyChallenge(cd.Challenge, ceremonyRegistration, userID); err != nil {
+		return nil, err
+	}
+
+	cred, err := relyingParty().VerifyRegistration(cd, res.Response.AttestationObject)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPasskeyVerification, err)
+	}
+
+	existing, err := repository.GetWebAuthnCredentialByCredentialID(cred.ID)
+	if err != nil {
+		return nil, fmt.Errorf("service.FinishPasskeyRegistration: %w", err)
+	}
+	if existing != nil {
+		return nil, ErrPasskeyAlreadyRegistered
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = "パスキー"
+	}
+	c := &domain.WebAuthnCredential{
+		UserID:         userID,
+		CredentialID:   cred.ID,
+		PublicKey:      cred.PublicKey,
+		Algorithm:      cred.Algorithm,
+		SignCount:      cred.SignCount,
+		AAGUID:         cred.AAGUID,
+		Transports:     res.Response.Transports,
+		BackupEligible: cred.BackupEligible,
+		BackedUp:       cred.BackedUp,
+		Name:           truncate(name, 100),
+		CreatedAt:      time.Now(),
+	}
+	id, err := repository.CreateWebAuthnCredential(c)
+	if err != nil {
+		return nil, fmt.Errorf("service.FinishPasskeyRegistration: %w", err)
+	}
+	c.ID = id
+	return c, nil
+}
+
+// BeginPasskeyLogin はパスキーでのログインを開始し、navigator.credentials.get() に渡すオプションを返します。
+func BeginPasskeyLogin() (*webauthn.RequestOptions, error) {
+	challenge, err := newPasskeyChallenge(sql.NullInt64{}, ceremonyLogin)
+	if err != nil {
+		return nil, fmt.Errorf("service.BeginPasskeyLogin: %w", err)
+	}
+	return relyingParty().RequestOptions(challenge), nil
+}
+
+// FinishPasskeyLogin は認証器の署名を検証し、Login と同じくセッションを開始してトークンの組を発行します。
+func FinishPasskeyLogin(res *webauthn.AssertionResponse, client ClientInfo) (*TokenPair, error) {
+	cd, err := webauthn.ParseClientData(res.Response.ClientDataJSON)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPasskeyVerification, err)
+	}
+	if err := consumePasskeyChallenge(cd.Challenge, ceremonyLogin, 0); err != nil {
+		return nil, err
+	}
+
+	cred, err := repository.GetWebAuthnCredentialByCredentialID(res.RawID)
+	if err != nil {
+		return nil, fmt.Errorf("service.FinishPasskeyLogin: %w", err)
+	}
+	if cred == nil {
+		return nil, ErrPasskeyVerification
+	}
+	if len(res.Response.UserHandle) > 0 && !bytes.Equal(res.Response.UserHandle, userHandle(cred.UserID)) {
+		return nil, ErrPasskeyVerification
+	}
+
+	ad, err := relyingParty().VerifyAssertion(cd, res.Response.ClientDataJSON, res.Response.AuthenticatorData, res.Response.Signature, cred.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPasskeyVerification, err)
+	}
+
+	// 署名カウンターが増えていない場合は認証器が複製された可能性があるため拒否します。
+	// カウンターを持たない認証器 (常に 0) は対象外です。
+	if (ad.SignCount != 0 || cred.SignCount != 0) && ad.SignCount <= cred.SignCount {
+		log.Printf("service.FinishPasskeyLogin: credential %d: sign count did not increase (%d -> %d)", cred.ID, cred.SignCount, ad.SignCount)
+		return nil, ErrPasskeyVerification
+	}
+	updated, err := repository.UpdateWebAuthnCredentialUsage(cred.ID, cred.SignCount, ad.SignCount, ad.BackedUp())
+	if err != nil {
+		return nil, fmt.Errorf("service.FinishPasskeyLogin: %w", err)
+	}
+	if updated == 0 && ad.SignCount != 0 {
+		return nil, ErrPasskeyVerification
+	}
+
+	user, err := repository.GetUserByID(cred.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("service.FinishPasskeyLogin: %w", err)
+	}
+	if user == nil || user.DeletedAt.Valid {
+		return nil, ErrPasskeyVerification
+	}
+
+	pair, err := startSession(user, client)
+	if err != nil {
+		return nil, fmt.Errorf("service.FinishPasskeyLogin: %w", err)
+	}
+	RecordAudit(AuditEntry{Event: AuditEventLogin, Outcome: AuditOutcomeSuccess, ActorUserID: user.ID, TargetUserID: user.ID, Client: client,
+		Details: map[string]any{"method": "passkey"}})
+	return pair, nil
+}
+
+// ListPasskeys はユーザーが登録したパスキーの一覧を返します。
+func ListPasskeys(userID int64) ([]domain.WebAuthnCredential, error) {
+	credentials, err := repository.GetWebAuthnCredentialsByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("service.ListPasskeys: %w", err)
+	}
+	return credentials, nil
+}
+
+// RenamePasskey はパスキーの表示名を変更します。
+func RenamePasskey(userID int64, id int64, name string) (*domain.WebAuthnCredential, error) {
+	cred, err := repository.GetWebAuthnCredentialByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("service.RenamePasskey: %w", err)
+	}
+	if cred == nil || cred.UserID != userID {
+		return nil, ErrPasskeyNotFound
+	}
+
+	cred.Name = truncate(strings.TrimSpace(name), 100)
+	if err := repository.RenameWebAuthnCredential(id, userID, cred.Name); err != nil {
+		return nil, fmt.Errorf("service.RenamePasskey: %w", err)
+	}
+	return cred, nil
+}
+
+// DeletePasskey はパスキーを削除します。
+func DeletePasskey(userID int64, id int64) error {
+	deleted, err := repository.DeleteWebAuthnCredential(id, userID)
+	if err != nil {
+		return fmt.Errorf("service.DeletePasskey: %w", err)
+	}
+	if deleted == 0 {
+		return ErrPasskeyNotFound
+	}
+	return nil
+}
+
+// newPasskeyChallenge はチャレンジを生成してハッシュを保存し、base64url 文字列を返します。
+func newPasskeyChallenge(userID sql.NullInt64, ceremony string) (string, error) {
+	challenge, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+	expiresAt := time.Now().Add(config.AppConfig.WebAuthn.Timeout)
+	if err := repository.CreateWebAuthnChallenge(auth.HashToken(challenge), userID, ceremony, expiresAt); err != nil {
+		return "", err
+	}
+	return challenge, nil
+}
+
+// consumePasskeyChallenge は clientDataJSON のチャレンジを消費し、用途とユーザーを確認します。
+// ログインの場合は userID に 0 を渡します。
+func consumePasskeyChallenge(challenge string, ceremony string, userID int64) error {
+	ch, err := repository.ConsumeWebAuthnChallenge(auth.HashToken(challenge))
+	if err != nil {
+		return fmt.Errorf("service.consumePasskeyChallenge: %w", err)
+	}
+	if ch == nil || ch.Ceremony != ceremony || time.Now().After(ch.ExpiresAt) {
+		return ErrInvalidPasskeyChallenge
+	}
+	if userID != 0 && (!ch.UserID.Valid || ch.UserID.Int64 != userID) {
+		return ErrInvalidPasskeyChallenge
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+// backend/internal/service/password_policy_service.go
+package service
+
+import (
+	"backend/internal/auth"
+	"backend/internal/config"
+	"backend/internal/repository"
+	"fmt"
+	"log"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// PasswordViolation はパスワードが満たしていない条件1件分です。
+// Code はクライアントが表示を切り替えるための識別子です。
+type PasswordViolation struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// PasswordPolicyError はパスワードがポリシーを満たしていない場合に返されます。
+// 満たしていない条件をすべて Violations に含みます。
+type PasswordPolicyError struct {
+	Violations []PasswordViolation
+}
+
+func (e *PasswordPolicyError) Error() string {
+	messages := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		messages = append(messages, v.Message)
+	}
+	return "パスワードが条件を満たしていません: " + strings.Join(messages, " ")
+}
+
+// PasswordOwner はパスワードの確認に使うユーザーの情報です。新規登録の場合 UserID は 0 です。
+type PasswordOwner struct {
+	UserID   int64
+	Username string
+	Email    string
+}
+
+// personalInfoMinLength はパスワードに含めることを禁止するユーザー名などの最小の長さです。
+// 短すぎる値では無関係なパスワードまで拒否してしまうためです。
+const personalInfoMinLength = 3
+
+// ValidatePassword はパスワードが設定されたポリシーを満たしているかを確認します。
+// 満たしていない場合は *PasswordPolicyError を返します。
+func ValidatePassword(password string, owner PasswordOwner) error {
+	policy := config.AppConfig.PasswordPolicy
+	var violations []PasswordViolation
+	add := func(code, format string, args ...any) {
+		violations = append(violations, PasswordViolation{Code: code, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if utf8.RuneCountInString(password) < policy.MinLength {
+		add("too_short", "%d文字以上で入力してください。", policy.MinLength)
+	}
+	if len(password) > policy.MaxBytes {
+		add("too_long", "%dバイト以下で入力してください。", policy.MaxBytes)
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+	if policy.RequireUppercase && !hasUpper {
+		add("missing_uppercase", "英大文字を含めてください。")
+	}
+	if policy.RequireLowercase && !hasLower {
+		add("missing_lowercase", "英小文字を含めてください。")
+	}
+	if policy.RequireDigit && !hasDigit {
+		add("missing_digit", "数字を含めてください。")
+	}
+	if policy.RequireSymbol && !hasSymbol {
+		add("missing_symbol", "記号を含めてください。")
+	}
+
+	if policy.DisallowPersonalInfo {
+		lower := strings.ToLower(password)
+		if containsPersonalInfo(lower, owner.Username) {
+			add("contains_username", "ユーザー名を含めないでください。")
+		}
+		local, _, _ := strings.Cut(owner.Email, "@")
+		if containsPersonalInfo(lower, local) {
+			add("contains_email", "メールアドレスを含めないでください。")
+		}
+	}
+
+	if policy.HistorySize > 0 && owner.UserID != 0 {
+		reused, err := isRecentPassword(owner.UserID, password, policy.HistorySize)
+		if err != nil {
+			return fmt.Errorf("service.ValidatePassword: %w", err)
+		}
+		if reused {
+			add("reused", "直近%d回に使用したパスワードは使用できません。", policy.HistorySize)
+		}
+	}
+
+	if policy.BreachedListFile != "" {
+		breached, err := auth.IsBreachedPassword(policy.BreachedListFile, password)
+		if err != nil {
+			// 一覧が読めない場合でも登録やパスワード変更は止めず、ログに残します。
+			log.Printf("service.ValidatePassword: %v", err)
+		} else if breached {
+			add("breached", "このパスワードは過去に漏洩したことが確認されています。別のパスワードを使用してください。")
+		}
+	}
+
+	if len(violations) > 0 {
+		return &PasswordPolicyError{Violations: violations}
+	}
+	return nil
+}
+
+// RecordPassword はユーザーが設定したパスワードのハッシュを再利用禁止の履歴に追加します。
+func RecordPassword(userID int64, passwordHash string) error {
+	keep := config.AppConfig.PasswordPolicy.HistorySize
+	if keep <= 0 || passwordHash == "" {
+		return nil
+	}
+	if err := repository.CreatePasswordHistory(userID, passwordHash, keep); err != nil {
+		return fmt.Errorf("service.RecordPassword: %w", err)
+	}
+	return nil
+}
+
+// isRecentPassword はパスワードが現在のもの、または直近 n 件の履歴と一致するかどうかを返します。
+// 履歴の導入前に登録されたユーザーのため、現在のパスワードも必ず確認します。
+func isRecentPassword(userID int64, password string, n int) (bool, error) {
+	hashes, err := repository.GetRecentPasswordHashes(userID, n)
+	if err != nil {
+		return false, err
+	}
+	user, err := repository.GetUserByID(userID)
+	if err != nil {
+		return false, err
+	}
+	if user != nil && user.Password != "" {
+		hashes = append(hashes, user.Password)
+	}
+	for _, h := range hashes {
+		if auth.CheckPasswordHash(password, h) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// containsPersonalInfo は小文字にしたパスワードが値を含むかどうかを返します。
+func containsPersonalInfo(lowerPassword string, value string) bool {
+	value = strings.ToLower(strings.TrimSpace(value))
+	return utf8.RuneCountInString(value) >= personalInfoMinLength && strings.Contains(lowerPassword, value)
+}
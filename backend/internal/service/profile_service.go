@@ -0,0 +1,160 @@
+// backend/internal/service/profile_service.go
+package service
+
+import (
+	"backend/internal/domain"
+	"backend/internal/repository"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/language"
+)
+
+// プロフィールの各項目の最大文字数です。
+const (
+	maxDisplayNameLength = 64
+	maxBioLength         = 500
+)
+
+// ProfileUpdate はプロフィールの変更内容です。nil の項目は変更しません。
+// 空文字列を指定した項目は未設定に戻します。
+type ProfileUpdate struct {
+	DisplayName *string
+	Bio         *string
+	Locale      *string
+	Timezone    *string
+}
+
+// ProfileViolation はプロフィールの項目1件分の入力エラーです。
+type ProfileViolation struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ProfileValidationError はプロフィールの入力が正しくない場合に返されます。
+type ProfileValidationError struct {
+	Violations []ProfileViolation
+}
+
+func (e *ProfileValidationError) Error() string {
+	messages := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		messages = append(messages, v.Field+": "+v.Message)
+	}
+	return "プロフィールの入力が正しくありません: " + strings.Join(messages, " ")
+}
+
+// GetCurrentUser はログイン中のユーザーを取得します。削除済みの場合は ErrUserNotFound を返します。
+func GetCurrentUser(userID int64) (*domain.User, error) {
+	user, err := repository.GetUserByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("service.GetCurrentUser: %w", err)
+	}
+	if user == nil || user.DeletedAt.Valid {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
+
+// UpdateProfile はユーザーのプロフィールのうち指定された項目を更新し、更新後のユーザーを返します。
+// 入力が正しくない場合は *ProfileValidationError を返します。
+func UpdateProfile(userID int64, update ProfileUpdate, client ClientInfo) (*domain.User, error) {
+	user, err := GetCurrentUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var violations []ProfileViolation
+	var changed []string
+	apply := func(field string, value *string, current *string, normalize func(string) (string, string)) {
+		if value == nil {
+			return
+		}
+		v, problem := normalize(*value)
+		if problem != "" {
+			violations = append(violations, ProfileViolation{Field: field, Message: problem})
+			return
+		}
+		if v != *current {
+			*current = v
+			changed = append(changed, field)
+		}
+	}
+	apply("display_name", update.DisplayName, &user.DisplayName, normalizeDisplayName)
+	apply("bio", update.Bio, &user.Bio, normalizeBio)
+	apply("locale", update.Locale, &user.Locale, normalizeLocale)
+	apply("timezone", update.Timezone, &user.Timezone, normalizeTimezone)
+	if len(violations) > 0 {
+		return nil, &ProfileValidationError{Violations: violations}
+	}
+	if len(changed) == 0 {
+		return user, nil
+	}
+
+	rows, err := repository.UpdateUserProfile(userID, user.DisplayName, user.Bio, user.Locale, user.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("service.UpdateProfile: %w", err)
+	}
+	if rows == 0 {
+		return nil, ErrUserNotFound
+	}
+	RecordAudit(AuditEntry{Event: AuditEventUserUpdate, Outcome: AuditOutcomeSuccess, ActorUserID: userID, TargetUserID: userID, Client: client,
+		Details: map[string]any{"fields": changed}})
+	return user, nil
+}
+
+// normalizeDisplayName は表示名の前後の空白を取り除き、長さと使用できない文字を確認します。
+func normalizeDisplayName(s string) (string, string) {
+	s = strings.TrimSpace(s)
+	if utf8.RuneCountInString(s) > maxDisplayNameLength {
+		return "", fmt.Sprintf("%d文字以下で入力してください。", maxDisplayNameLength)
+	}
+	if strings.IndexFunc(s, unicode.IsControl) >= 0 {
+		return "", "改行や制御文字は使用できません。"
+	}
+	return s, ""
+}
+
+// normalizeBio は自己紹介の前後の空白を取り除き、長さと使用できない文字を確認します。改行は使用できます。
+func normalizeBio(s string) (string, string) {
+	s = strings.TrimSpace(strings.ReplaceAll(s, "\r\n", "\n"))
+	if utf8.RuneCountInString(s) > maxBioLength {
+		return "", fmt.Sprintf("%d文字以下で入力してください。", maxBioLength)
+	}
+	if strings.IndexFunc(s, func(r rune) bool { return unicode.IsControl(r) && r != '\n' }) >= 0 {
+		return "", "制御文字は使用できません。"
+	}
+	return s, ""
+}
+
+// normalizeLocale は言語タグ (BCP 47) を正規の表記にします (例: "ja_jp" → "ja-JP")。
+func normalizeLocale(s string) (string, string) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return "", ""
+	}
+	tag, err := language.Parse(strings.ReplaceAll(s, "_", "-"))
+	if err != nil || len(tag.String()) > 35 {
+		return "", "言語タグ (例: ja-JP) の形式で入力してください。"
+	}
+	return tag.String(), ""
+}
+
+// normalizeTimezone は IANA のタイムゾーン名 (例: Asia/Tokyo) であることを確認します。
+func normalizeTimezone(s string) (string, string) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return "", ""
+	}
+	if s == "Local" || len(s) > 64 {
+		return "", "タイムゾーン名 (例: Asia/Tokyo) で入力してください。"
+	}
+	loc, err := time.LoadLocation(s)
+	if err != nil {
+		return "", "タイムゾーン名 (例: Asia/Tokyo) で入力してください。"
+	}
+	return loc.String(), ""
+}
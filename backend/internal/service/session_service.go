@@ -0,0 +1,116 @@
+// backend/internal/service/session_service.go
+package service
+
+import (
+	"backend/internal/config"
+	"backend/internal/domain"
+	"backend/internal/repository"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+	"unicode/utf8"
+)
+
+// ErrSessionNotFound はセッションが存在しない、または他のユーザーのものである場合に返されます。
+var ErrSessionNotFound = errors.New("セッションが見つかりません")
+
+// ErrSessionRevoked はトークンのセッションが失効済みの場合に返されます。
+var ErrSessionRevoked = errors.New("セッションは無効化されています。再度ログインしてください")
+
+// ListSessions はユーザーの有効なセッション (ログイン中の端末) の一覧を返します。
+func ListSessions(userID int64) ([]domain.Session, error) {
+	sessions, err := repository.GetActiveSessionsByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("service.ListSessions: %w", err)
+	}
+	return sessions, nil
+}
+
+// RevokeSession はユーザーのセッションと、そのセッションのリフレッシュトークンを失効させます。
+func RevokeSession(userID int64, sessionID int64) error {
+	revoked, err := repository.RevokeSession(sessionID, userID)
+	if err != nil {
+		return fmt.Errorf("service.RevokeSession: %w", err)
+	}
+	if revoked == 0 {
+		return ErrSessionNotFound
+	}
+	if _, err := repository.RevokeRefreshTokensBySession(sessionID); err != nil {
+		return fmt.Errorf("service.RevokeSession: %w", err)
+	}
+	return nil
+}
+
+// RevokeAllSessions はユーザーのすべてのセッションとリフレッシュトークンを失効させ、失効させたセッション数を返します。
+func RevokeAllSessions(userID int64) (int64, error) {
+	revoked, err := repository.RevokeSessionsByUserID(userID)
+	if err != nil {
+		return 0, fmt.Errorf("service.RevokeAllSessions: %w", err)
+	}
+	if _, err := repository.RevokeRefreshTokensByUserID(userID); err != nil {
+		return 0, fmt.Errorf("service.RevokeAllSessions: %w", err)
+	}
+	return revoked, nil
+}
+
+// ValidateSession はトークンのセッションが有効であることを確認し、セッションのユーザーを返します。
+// impersonatorID はトークンの act クレームの管理者のユーザーID (本人のログインの場合は 0) で、セッションの記録と一致する必要があります。
+// ユーザーが停止中の場合は ErrAccountSuspended を返します。
+// 最終アクセス日時は LastSeenInterval 以上経過している場合にのみ更新します。
+func ValidateSession(userID int64, sessionID int64, impersonatorID int64, ipAddress string) (*domain.User, error) {
+	if sessionID == 0 {
+		return nil, ErrSessionRevoked
+	}
+
+	session, err := repository.GetSessionByID(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("service.ValidateSession: %w", err)
+	}
+	if session == nil || session.UserID != userID || session.RevokedAt.Valid {
+		return nil, ErrSessionRevoked
+	}
+	if session.ImpersonatorUserID.Int64 != impersonatorID || (session.ExpiresAt.Valid && time.Now().After(session.ExpiresAt.Time)) {
+		return nil, ErrSessionRevoked
+	}
+
+	user, err := repository.GetUserByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("service.ValidateSession: %w", err)
+	}
+	if user == nil || user.DeletedAt.Valid {
+		return nil, ErrSessionRevoked
+	}
+	if user.SuspendedAt.Valid {
+		return nil, ErrAccountSuspended
+	}
+	// 代理ログインは、管理者が権限を失った時点で無効にします。
+	if impersonatorID != 0 {
+		isAdmin, err := IsAdmin(impersonatorID)
+		if err != nil {
+			return nil, fmt.Errorf("service.ValidateSession: %w", err)
+		}
+		if !isAdmin {
+			return nil, ErrSessionRevoked
+		}
+	}
+
+	if time.Since(session.LastSeenAt) >= config.AppConfig.Session.LastSeenInterval {
+		// 最終アクセス日時の更新に失敗しても認証自体は成功とします。
+		if err := repository.TouchSession(session.ID, ipAddress); err != nil {
+			log.Printf("service.ValidateSession: %v", err)
+		}
+	}
+	return user, nil
+}
+
+// truncate は文字列を最大 n バイトに切り詰めます (マルチバイト文字の途中では切りません)。
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
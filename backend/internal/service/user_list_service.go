@@ -0,0 +1,126 @@
+// backend/internal/service/user_list_service.go
+package service
+
+import (
+	"backend/internal/domain"
+	"backend/internal/repository"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// ユーザー一覧の1ページあたりの件数です。
+const (
+	defaultUserPageSize = 50
+	maxUserPageSize     = 200
+)
+
+// UserListQuery は管理者用のユーザー一覧の取得条件です。
+type UserListQuery struct {
+	Filter       repository.UserFilter
+	Sort         string // repository.UserSort* のいずれか (既定は created_at)
+	Desc         bool
+	Cursor       string
+	Limit        int
+	IncludeTotal bool // 条件に一致する総数も数えます (件数が多い場合は遅くなります)
+}
+
+// UserPage はユーザー一覧の1ページ分です。NextCursor が空の場合は最後のページです。
+// Total は IncludeTotal を指定した場合のみ設定されます。
+type UserPage struct {
+	Users      []domain.User
+	NextCursor string
+	Total      *int64
+}
+
+// userCursor はユーザー一覧のカーソルの中身です。
+// 並び順ごとに位置の意味が変わるため、並び順も含めて異なる条件で使われたカーソルを拒否します。
+type userCursor struct {
+	Sort  string `json:"s"`
+	Desc  bool   `json:"d,omitempty"`
+	Value string `json:"v,omitempty"`
+	ID    int64  `json:"id"`
+}
+
+// ListUsers は条件に一致するユーザーを1ページ分返します (管理者用)。
+// 件数が多くても一定の速度で取得できるよう、OFFSET ではなく前のページの最後の行を起点に取得します。
+func ListUsers(q UserListQuery) (*UserPage, error) {
+	if q.Sort == "" {
+		q.Sort = repository.UserSortCreatedAt
+	}
+	page := repository.UserPageRequest{Sort: q.Sort, Desc: q.Desc}
+	if q.Cursor != "" {
+		if err := decodeUserCursor(q.Cursor, &page); err != nil {
+			return nil, err
+		}
+	}
+	if q.Limit <= 0 {
+		q.Limit = defaultUserPageSize
+	}
+	// 次のページの有無を判定するため、1件多く取得します。
+	page.Limit = min(q.Limit, maxUserPageSize) + 1
+
+	users, err := repository.FindUsers(q.Filter, page)
+	if err != nil {
+		return nil, fmt.Errorf("service.ListUsers: %w", err)
+	}
+	result := &UserPage{Users: users}
+	if len(users) == page.Limit {
+		result.Users = users[:page.Limit-1]
+		result.NextCursor = encodeUserCursor(page, result.Users[len(result.Users)-1])
+	}
+
+	if q.IncludeTotal {
+		total, err := repository.CountUsers(q.Filter)
+		if err != nil {
+			return nil, fmt.Errorf("service.ListUsers: %w", err)
+		}
+		result.Total = &total
+	}
+	return result, nil
+}
+
+// encodeUserCursor はページの最後のユーザーの位置をカーソルにします。
+func encodeUserCursor(page repository.UserPageRequest, last domain.User) string {
+	c := userCursor{Sort: page.Sort, Desc: page.Desc, ID: last.ID}
+	switch page.Sort {
+	case repository.UserSortCreatedAt:
+		c.Value = last.CreatedAt.UTC().Format(time.RFC3339Nano)
+	case repository.UserSortUsername:
+		c.Value = last.Username
+	case repository.UserSortEmail:
+		c.Value = last.Email
+	}
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeUserCursor はカーソルを読み取り、page に起点を設定します。
+func decodeUserCursor(cursor string, page *repository.UserPageRequest) error {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return ErrInvalidCursor
+	}
+	var c userCursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID <= 0 {
+		return ErrInvalidCursor
+	}
+	if c.Sort != page.Sort || c.Desc != page.Desc {
+		return ErrInvalidCursor
+	}
+
+	switch c.Sort {
+	case repository.UserSortCreatedAt:
+		t, err := time.Parse(time.RFC3339Nano, c.Value)
+		if err != nil {
+			return ErrInvalidCursor
+		}
+		page.AfterValue = t
+	case repository.UserSortUsername, repository.UserSortEmail:
+		page.AfterValue = c.Value
+	}
+	page.After = true
+	page.AfterID = c.ID
+	return nil
+}
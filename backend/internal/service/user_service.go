@@ -0,0 +1,195 @@
+// backend/internal/service/user_service.go
+package service
+
+import (
+	"backend/internal/auth"
+	"backend/internal/domain"
+	"backend/internal/identifier"
+	"backend/internal/mail"
+	"backend/internal/repository"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// CreateUser はユーザー名とメールアドレスを正規化し、パスワードポリシーを確認してからユーザーを登録します。
+// ユーザー名が使用できない形式の場合は ErrInvalidUsername を、予約されている、または使用・保持されている場合は
+// ErrUsernameReserved / ErrUsernameTaken を、メールアドレスが登録済みの場合は ErrEmailAlreadyRegistered を、
+// パスワードが条件を満たしていない場合は *PasswordPolicyError を返します。
+// 管理者による登録など、登録済みのメールアドレスを知らせてよい場合に使用します。
+func CreateUser(username string, password string, email string) (int64, error) {
+	return createUser(username, password, email, nil)
+}
+
+// createUser は CreateUser の処理を行います。invite が指定された場合は、招待コードの使用回数の加算と
+// 同じトランザクションでユーザーを作成し、招待コードのロールを付与します。
+// 確認後に招待コードが使用できなくなっていた場合は ErrInvalidInviteCode を返します。
+func createUser(username string, password string, email string, invite *domain.InviteCode) (int64, error) {
+	username, email, err := validateNewUser(username, password, email, false)
+	if err != nil {
+		return 0, err
+	}
+
+	var userID int64
+	if invite == nil {
+		userID, err = repository.CreateUser(username, password, email)
+	} else {
+		userID, err = repository.CreateUserWithInviteCode(invite.ID, username, password, email, time.Now())
+	}
+	if err != nil {
+		return 0, fmt.Errorf("service.CreateUser: %w", err)
+	}
+	if userID == 0 {
+		return 0, ErrInvalidInviteCode
+	}
+
+	// 履歴の記録に失敗しても登録自体は成功とします。
+	if user, err := repository.GetUserByID(userID); err != nil {
+		log.Printf("service.CreateUser: %v", err)
+	} else if user != nil {
+		if err := RecordPassword(userID, user.Password); err != nil {
+			log.Printf("service.CreateUser: %v", err)
+		}
+	}
+	return userID, nil
+}
+
+// validateNewUser は新規登録するユーザー名とメールアドレスを正規化し、CreateUser と同じ確認を行って正規化後の値を返します。
+// invited が true の場合 (パスワードを設定せず招待メールでログインするユーザー) はパスワードポリシーの確認を省略します。
+func validateNewUser(username string, password string, email string, invited bool) (string, string, error) {
+	username, err := normalizeUsername(username)
+	if err != nil {
+		return "", "", err
+	}
+	if email, err = identifier.NormalizeEmail(email); err != nil {
+		return "", "", err
+	}
+	if err := checkUsernameAvailable(username, 0); err != nil {
+		return "", "", err
+	}
+	// メールアドレスの使用状況は、他の入力がすべて正しい場合のみ返します (RegisterUser を参照)。
+	if !invited {
+		if err := ValidatePassword(password, PasswordOwner{Username: username, Email: email}); err != nil {
+			return "", "", err
+		}
+	}
+	inUse, err := repository.EmailInUse(email, 0)
+	if err != nil {
+		return "", "", fmt.Errorf("service.validateNewUser: %w", err)
+	}
+	if inUse {
+		return "", "", ErrEmailAlreadyRegistered
+	}
+	return username, email, nil
+}
+
+// RegisterUser はセルフサービスの新規登録を行います。CreateUser と異なり、メールアドレスが登録済みかどうかを
+// 呼び出し元に返しません。登録済みの場合は、登録に成功した場合と同程度の時間をかけて nil を返し、
+// そのアドレスの所有者に登録の試みがあったことを通知します。登録に成功した場合は登録完了のメールを送信します。
+// ユーザー名は公開される識別子のため、使用済み・予約済みの場合は CreateUser と同じエラーを返します。
+//
+// REGISTRATION_MODE が closed の場合は ErrRegistrationClosed を、invite_only で招待コードがない場合は
+// ErrInviteCodeRequired を返します。招待コードが無効な場合は ErrInvalidInviteCode を返します。
+// 招待コードはユーザーを作成できた場合にのみ使用済みとし、メールアドレスが登録済みの場合は消費しません。
+func RegisterUser(username string, password string, email string, inviteCode string, client ClientInfo) error {
+	var invite *domain.InviteCode
+	audit := func(outcome string, userID int64, reason string) {
+		details := map[string]any{"username": username}
+		if reason != "" {
+			details["reason"] = reason
+		}
+		if invite != nil {
+			details["invite_code_id"] = invite.ID
+		}
+		RecordAudit(AuditEntry{Event: AuditEventUserCreate, Outcome: outcome, TargetUserID: userID, Client: client, Details: details})
+	}
+
+	invite, err := registrationInviteCode(inviteCode, email)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrRegistrationClosed):
+			audit(AuditOutcomeFailure, 0, "registration_closed")
+		case errors.Is(err, ErrInviteCodeRequired):
+			audit(AuditOutcomeFailure, 0, "invite_required")
+		case errors.Is(err, ErrInvalidInviteCode):
+			audit(AuditOutcomeFailure, 0, "invalid_invite")
+		default:
+			audit(AuditOutcomeFailure, 0, "")
+		}
+		return err
+	}
+
+	userID, err := createUser(username, password, email, invite)
+	if errors.Is(err, ErrEmailAlreadyRegistered) {
+		// 登録に成功した場合はここでハッシュ化が行われるため、応答時間を揃えるために同じ処理を行います。
+		if _, err := auth.HashPassword(password); err != nil {
+			log.Printf("service.RegisterUser: %v", err)
+		}
+		notifyRegistrationAttempt(email)
+		audit(AuditOutcomeFailure, 0, "email_taken")
+		return nil
+	}
+	if errors.Is(err, ErrInvalidInviteCode) {
+		audit(AuditOutcomeFailure, 0, "invalid_invite")
+		return err
+	}
+	if err != nil {
+		audit(AuditOutcomeFailure, 0, "")
+		return err
+	}
+
+	user, err := repository.GetUserByID(userID)
+	if err != nil {
+		log.Printf("service.RegisterUser: %v", err)
+	} else if user != nil {
+		sendMailAsync("service.RegisterUser", mail.Message{
+			To:      user.Email,
+			Subject: "ご登録ありがとうございます",
+			Body:    fmt.Sprintf("%s さん\n\nアカウントの登録が完了しました。ユーザー名またはこのメールアドレスとパスワードでログインできます。\n", user.Username),
+		})
+	}
+	audit(AuditOutcomeSuccess, userID, "")
+	return nil
+}
+
+// notifyRegistrationAttempt は登録済みのメールアドレスで新規登録が試みられたことを、そのアドレスの所有者に通知します。
+func notifyRegistrationAttempt(email string) {
+	normalized, err := identifier.NormalizeEmail(email)
+	if err != nil {
+		return
+	}
+	owner, err := repository.GetUserByEmail(normalized)
+	if err != nil {
+		log.Printf("service.notifyRegistrationAttempt: %v", err)
+		return
+	}
+	if owner == nil {
+		return
+	}
+	sendMailAsync("service.notifyRegistrationAttempt", mail.Message{
+		To:      owner.Email,
+		Subject: "このメールアドレスで新規登録が試みられました",
+		Body: fmt.Sprintf("%s さん\n\nこのメールアドレスで新しいアカウントの登録が試みられましたが、既にアカウントがあるため登録されませんでした。"+
+			"ご自身の操作であれば、ユーザー名 %s でログインしてください。パスワードを忘れた場合はログインリンクをご利用ください。\n\n"+
+			"心当たりがない場合は、このメールを破棄してください。\n", owner.Username, owner.Username),
+	})
+}
+
+// sendMailAsync はメールを非同期に送信します。送信の失敗はログに記録するのみです。
+func sendMailAsync(caller string, msg mail.Message) {
+	go func() {
+		if err := mail.Send(msg); err != nil {
+			log.Printf("%s: %v", caller, err)
+		}
+	}()
+}
+
+// IsAdmin はユーザーが管理者かどうかを返します。削除済み・停止中のユーザーは管理者として扱いません。
+func IsAdmin(userID int64) (bool, error) {
+	user, err := repository.GetUserByID(userID)
+	if err != nil {
+		return false, fmt.Errorf("service.IsAdmin: %w", err)
+	}
+	return user != nil && !user.DeletedAt.Valid && !user.SuspendedAt.Valid && user.Role == domain.RoleAdmin, nil
+}
@@ -0,0 +1,506 @@
+// backend/internal/service/user_transfer_service.go
+package service
+
+import (
+	"backend/internal/auth"
+	"backend/internal/config"
+	"backend/internal/domain"
+	"backend/internal/identifier"
+	"backend/internal/mail"
+	"backend/internal/repository"
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ユーザーの一括登録・エクスポートのファイル形式です。
+const (
+	UserFileFormatCSV    = "csv"
+	UserFileFormatNDJSON = "ndjson" // 1行に1つの JSON オブジェクト
+)
+
+// 一括登録の各行の結果です。
+const (
+	UserImportStatusValid   = "valid"   // ドライランで登録できることを確認した行
+	UserImportStatusCreated = "created" // 登録した行
+	UserImportStatusError   = "error"   // 登録できなかった行
+)
+
+// maxImportRows は1回の一括登録で処理する最大の行数です。
+const maxImportRows = 10000
+
+// userExportBatchSize はエクスポートで1回に取得するユーザー数です。
+const userExportBatchSize = 500
+
+var (
+	// ErrUnsupportedUserFileFormat はファイル形式が csv / ndjson のいずれでもない場合に返されます。
+	ErrUnsupportedUserFileFormat = errors.New("ファイル形式は csv または ndjson を指定してください")
+	// ErrInvalidUserImportFile は CSV のヘッダーが正しくない、または行数が上限を超えている場合に返されます。
+	ErrInvalidUserImportFile = errors.New("インポートするファイルの形式が正しくありません")
+	// ErrInvitationsUnavailable はログインリンク (MAGIC_LINK_URL) が設定されておらず、招待メールを送信できない場合に返されます。
+	ErrInvitationsUnavailable = errors.New("ログインリンクが設定されていないため、招待メールを送信できません")
+	// ErrUnknownUserExportField はエクスポートする項目に存在しない項目が含まれている場合に返されます。
+	ErrUnknownUserExportField = errors.New("エクスポートできない項目が指定されています")
+)
+
+// userImportColumns は一括登録のファイルで使用できる項目です。username と email は必須です。
+// password を省略した行は、UserImportOptions.SendInvitations が true の場合のみパスワードなしで登録し、招待メールを送信します。
+var userImportColumns = []string{"username", "email", "password"}
+
+// UserImportOptions は一括登録の条件です。
+type UserImportOptions struct {
+	Format string
+	// DryRun が true の場合は確認のみ行い、ユーザーを登録しません。
+	DryRun bool
+	// SendInvitations が true の場合、パスワードのない行のユーザーに招待メール (ログインリンク) を送信します。
+	SendInvitations bool
+	// WaitForInvitations が true の場合は招待メールの送信の完了を待ち、送信の失敗を行ごとの結果に含めます。
+	// false の場合は送信を待たずに次の行に進み、送信の失敗はログにのみ残します (HTTP からの登録で応答が遅れないようにします)。
+	WaitForInvitations bool
+	// MaxRows は受け付ける最大の行数です。0 の場合は maxImportRows です。
+	MaxRows int
+}
+
+// UserImportRowResult は一括登録の1行分の結果です。
+type UserImportRowResult struct {
+	Line     int      `json:"line"` // ファイルの行番号 (1 始まり、CSV のヘッダーを含む)
+	Username string   `json:"username"`
+	Email    string   `json:"email"`
+	Status   string   `json:"status"` // UserImportStatus* のいずれか
+	UserID   int64    `json:"user_id,omitempty"`
+	Invited  bool     `json:"invited,omitempty"`
+	Errors   []string `json:"errors,omitempty"`
+}
+
+// UserImportReport は一括登録の結果です。
+type UserImportReport struct {
+	DryRun  bool                  `json:"dry_run"`
+	Total   int                   `json:"total"`
+	Valid   int                   `json:"valid"`   // 登録できる (ドライラン) または登録した行数
+	Invited int                   `json:"invited"` // そのうち招待メールを送信する (した) 行数
+	Failed  int                   `json:"failed"`
+	Rows    []UserImportRowResult `json:"rows"`
+}
+
+// userImportRow はファイルから読み取った1行分の入力です。
+type userImportRow struct {
+	Line     int
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
+	err      error  // 行の読み取り自体に失敗した場合のエラー
+}
+
+// ImportUsers は CSV または NDJSON のファイルからユーザーを一括登録します。
+// 行ごとに CreateUser と同じ確認を行い、ファイル内での重複も確認します。確認に失敗した行は登録せずに
+// 理由を結果に含め、残りの行の処理を続けます。登録済みのメールアドレスも理由として返します。
+// adminID は操作した管理者のユーザーID です (CLI から実行した場合は 0)。
+func ImportUsers(r io.Reader, opts UserImportOptions, adminID int64, client ClientInfo) (*UserImportReport, error) {
+	if opts.SendInvitations && config.AppConfig.MagicLink.URL == "" {
+		return nil, ErrInvitationsUnavailable
+	}
+	maxRows := opts.MaxRows
+	if maxRows <= 0 || maxRows > maxImportRows {
+		maxRows = maxImportRows
+	}
+	rows, err := readUserImportRows(r, opts.Format, maxRows)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &UserImportReport{DryRun: opts.DryRun, Total: len(rows), Rows: make([]UserImportRowResult, 0, len(rows))}
+	seenUsernames := make(map[string]int)
+	seenEmails := make(map[string]int)
+	for _, row := range rows {
+		result := UserImportRowResult{Line: row.Line, Username: row.Username, Email: row.Email}
+		if err := importUserRow(row, opts, adminID, client, seenUsernames, seenEmails, &result); err != nil {
+			// データベースのエラーなど、行の内容によらないエラーの場合はそこで中断します。
+			return report, fmt.Errorf("service.ImportUsers: line %d: %w", row.Line, err)
+		}
+		switch result.Status {
+		case UserImportStatusError:
+			report.Failed++
+		default:
+			report.Valid++
+			if result.Invited {
+				report.Invited++
+			}
+		}
+		report.Rows = append(report.Rows, result)
+	}
+	return report, nil
+}
+
+// importUserRow は1行分の入力を確認し、ドライランでなければ登録して result に結果を設定します。
+// 行の内容に問題がある場合は result に理由を設定して nil を返し、それ以外のエラーの場合のみエラーを返します。
+func importUserRow(row userImportRow, opts UserImportOptions, adminID int64, client ClientInfo,
+	seenUsernames map[string]int, seenEmails map[string]int, result *UserImportRowResult) error {
+	fail := func(messages ...string) error {
+		result.Status = UserImportStatusError
+		result.Errors = append(result.Errors, messages...)
+		return nil
+	}
+
+	if row.err != nil {
+		return fail(row.err.Error())
+	}
+	if row.Username == "" || row.Email == "" {
+		return fail("username と email は必須です")
+	}
+	invited := row.Password == ""
+	if invited && !opts.SendInvitations {
+		return fail("password を省略する場合は招待メールの送信を指定してください")
+	}
+
+	username, email, err := validateNewUser(row.Username, row.Password, row.Email, invited)
+	if err != nil {
+		if isNewUserInputError(err) {
+			return fail(err.Error())
+		}
+		return err
+	}
+	result.Username, result.Email, result.Invited = username, email, invited
+
+	// 同じファイル内の重複は、データベースの確認では見つからないため別に確認します。
+	var duplicates []string
+	skeleton := identifier.UsernameSkeleton(username)
+	if line, ok := seenUsernames[skeleton]; ok {
+		duplicates = append(duplicates, fmt.Sprintf("ユーザー名が %d 行目と重複しています", line))
+	} else {
+		seenUsernames[skeleton] = row.Line
+	}
+	if line, ok := seenEmails[strings.ToLower(email)]; ok {
+		duplicates = append(duplicates, fmt.Sprintf("メールアドレスが %d 行目と重複しています", line))
+	} else {
+		seenEmails[strings.ToLower(email)] = row.Line
+	}
+	if len(duplicates) > 0 {
+		return fail(duplicates...)
+	}
+
+	if opts.DryRun {
+		result.Status = UserImportStatusValid
+		return nil
+	}
+
+	var userID int64
+	if invited {
+		userID, err = repository.CreateInvitedUser(username, email)
+	} else {
+		// 確認の後に並行して同じユーザー名などが登録された場合は、その行のエラーとして扱います。
+		userID, err = CreateUser(username, row.Password, email)
+		if err != nil && isNewUserInputError(err) {
+			return fail(err.Error())
+		}
+	}
+	if err != nil {
+		return err
+	}
+	result.Status, result.UserID = UserImportStatusCreated, userID
+
+	if invited {
+		if err := sendInvitation(userID, username, email, opts.WaitForInvitations, client); err != nil {
+			log.Printf("service.ImportUsers: user %d: %v", userID, err)
+			result.Errors = append(result.Errors, "招待メールの送信に失敗しました")
+		}
+	}
+	RecordAudit(AuditEntry{Event: AuditEventUserCreate, Outcome: AuditOutcomeSuccess, ActorUserID: adminID, TargetUserID: userID, Client: client,
+		Details: map[string]any{"username": username, "source": "import", "invited": invited}})
+	return nil
+}
+
+// isNewUserInputError は validateNewUser のエラーが入力内容によるものかどうかを返します。
+func isNewUserInputError(err error) bool {
+	var policyErr *PasswordPolicyError
+	return errors.As(err, &policyErr) || errors.Is(err, ErrInvalidUsername) || errors.Is(err, ErrUsernameTaken) ||
+		errors.Is(err, ErrUsernameReserved) || errors.Is(err, identifier.ErrInvalidEmail) || errors.Is(err, ErrEmailAlreadyRegistered)
+}
+
+// sendInvitation は招待したユーザーに、MAGIC_LINK_INVITATION_TTL の間有効なログインリンクを送信します。
+// wait が true の場合は送信の完了を待ち、送信の失敗も返します (CLI から実行した場合に送信前に終了しないようにします)。
+// false の場合は送信を待たず、ログインリンクの作成に失敗した場合のみエラーを返します。
+func sendInvitation(userID int64, username string, email string, wait bool, client ClientInfo) error {
+	token, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return err
+	}
+	cfg := config.AppConfig.MagicLink
+	if err := repository.CreateMagicLink(userID, auth.HashToken(token), client.IPAddress, time.Now().Add(cfg.InvitationTTL)); err != nil {
+		return err
+	}
+
+	link := cfg.URL + "?" + url.Values{"token": {token}}.Encode()
+	msg := mail.Message{
+		To:      email,
+		Subject: "アカウントへの招待",
+		Body: fmt.Sprintf("%s さん\n\n管理者があなたのアカウントを作成しました。以下のリンクを開いてログインしてください。"+
+			"リンクの有効期限は %d 時間で、1回のみ使用できます。期限が切れた場合は、ログイン画面からログインリンクを再送信してください。\n\n%s\n",
+			username, int(cfg.InvitationTTL.Hours()), link),
+	}
+	if !wait {
+		sendMailAsync("service.ImportUsers", msg)
+		return nil
+	}
+	return mail.Send(msg)
+}
+
+// readUserImportRows はファイルのすべての行を読み取ります。行ごとの形式の誤りは各行の err に設定します。
+// maxRows を超える行がある場合はエラーを返します。
+func readUserImportRows(r io.Reader, format string, maxRows int) ([]userImportRow, error) {
+	switch format {
+	case UserFileFormatCSV:
+		return readUserImportCSV(r, maxRows)
+	case UserFileFormatNDJSON:
+		return readUserImportNDJSON(r, maxRows)
+	}
+	return nil, ErrUnsupportedUserFileFormat
+}
+
+// readUserImportCSV は1行目をヘッダーとして CSV を読み取ります。項目の順序は問いません。
+func readUserImportCSV(r io.Reader, maxRows int) ([]userImportRow, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: ヘッダー行を読み取れません: %w", ErrInvalidUserImportFile, err)
+	}
+	index := make(map[string]int)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if !slices.Contains(userImportColumns, name) {
+			return nil, fmt.Errorf("%w: 不明な項目 %q があります (使用できる項目: %s)", ErrInvalidUserImportFile, name, strings.Join(userImportColumns, ", "))
+		}
+		index[name] = i
+	}
+	if _, ok := index["username"]; !ok {
+		return nil, fmt.Errorf("%w: username の項目が必要です", ErrInvalidUserImportFile)
+	}
+	if _, ok := index["email"]; !ok {
+		return nil, fmt.Errorf("%w: email の項目が必要です", ErrInvalidUserImportFile)
+	}
+
+	var rows []userImportRow
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if len(rows) >= maxRows {
+			return nil, fmt.Errorf("%w: 1回に登録できるのは %d 行までです", ErrInvalidUserImportFile, maxRows)
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return nil, fmt.Errorf("%w: %w", ErrInvalidUserImportFile, err)
+			}
+			rows = append(rows, userImportRow{Line: parseErr.StartLine, err: err})
+			continue
+		}
+		line, _ := cr.FieldPos(0)
+		row := userImportRow{Line: line}
+		field := func(name string) string {
+			if i, ok := index[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		row.Username, row.Email = field("username"), field("email")
+		if i, ok := index["password"]; ok && i < len(record) {
+			row.Password = record[i] // パスワードの前後の空白は意図したものとして扱います
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// readUserImportNDJSON は1行に1つの JSON オブジェクトを読み取ります。空行は無視します。
+func readUserImportNDJSON(r io.Reader, maxRows int) ([]userImportRow, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var rows []userImportRow
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		if len(rows) >= maxRows {
+			return nil, fmt.Errorf("%w: 1回に登録できるのは %d 行までです", ErrInvalidUserImportFile, maxRows)
+		}
+		row := userImportRow{Line: line}
+		dec := json.NewDecoder(strings.NewReader(text))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&row); err != nil {
+			row.err = fmt.Errorf("JSON として読み取れません: %v", err)
+		}
+		row.Line = line
+		row.Username, row.Email = strings.TrimSpace(row.Username), strings.TrimSpace(row.Email)
+		rows = append(rows, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidUserImportFile, err)
+	}
+	return rows, nil
+}
+
+// userExportFields はエクスポートできる項目と、その値の取り出し方です。値が NULL の場合は nil を返します。
+// パスワードのハッシュなど認証に使う情報は含めません。
+var userExportFields = map[string]func(u *domain.User) any{
+	"id":                       func(u *domain.User) any { return u.ID },
+	"username":                 func(u *domain.User) any { return u.Username },
+	"email":                    func(u *domain.User) any { return u.Email },
+	"email_verified_at":        func(u *domain.User) any { return nullTime(u.EmailVerifiedAt.Time, u.EmailVerifiedAt.Valid) },
+	"role":                     func(u *domain.User) any { return u.Role },
+	"display_name":             func(u *domain.User) any { return u.DisplayName },
+	"bio":                      func(u *domain.User) any { return u.Bio },
+	"locale":                   func(u *domain.User) any { return u.Locale },
+	"timezone":                 func(u *domain.User) any { return u.Timezone },
+	"created_at":               func(u *domain.User) any { return u.CreatedAt },
+	"updated_at":               func(u *domain.User) any { return u.UpdatedAt },
+	"deleted_at":               func(u *domain.User) any { return nullTime(u.DeletedAt.Time, u.DeletedAt.Valid) },
+	"suspended_at":             func(u *domain.User) any { return nullTime(u.SuspendedAt.Time, u.SuspendedAt.Valid) },
+	"password_change_required": func(u *domain.User) any { return u.PasswordChangeRequired },
+}
+
+// DefaultUserExportFields は項目を指定しなかった場合にエクスポートする項目です。
+var DefaultUserExportFields = []string{"id", "username", "email", "email_verified_at", "role", "display_name", "created_at"}
+
+// UserExportOptions はエクスポートの条件です。Fields が空の場合は DefaultUserExportFields を使います。
+type UserExportOptions struct {
+	Format string
+	Fields []string
+	Filter repository.UserFilter
+}
+
+// UserExport は条件を確認済みのエクスポートです。PrepareUserExport で作成し、Stream で書き出します。
+type UserExport struct {
+	opts UserExportOptions
+}
+
+// PrepareUserExport はエクスポートの形式と項目を確認します。
+// 書き出しを始める前に条件の誤りを返せるよう、Stream とは分けています。
+func PrepareUserExport(opts UserExportOptions) (*UserExport, error) {
+	if opts.Format != UserFileFormatCSV && opts.Format != UserFileFormatNDJSON {
+		return nil, ErrUnsupportedUserFileFormat
+	}
+	if len(opts.Fields) == 0 {
+		opts.Fields = DefaultUserExportFields
+	}
+	for _, f := range opts.Fields {
+		if _, ok := userExportFields[f]; !ok {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownUserExportField, f)
+		}
+	}
+	return &UserExport{opts: opts}, nil
+}
+
+// Stream は条件に一致するユーザーを ID の順に w へ書き出し、書き出したユーザー数を返します。
+// すべてのユーザーをメモリに読み込まないよう、一定数ずつ取得しては書き出します。
+// w が Flush を持つ場合 (HTTP のレスポンスなど) は、取得ごとにクライアントへ送信します。
+func (e *UserExport) Stream(w io.Writer) (int, error) {
+	var csvWriter *csv.Writer
+	var jsonEncoder *json.Encoder
+	if e.opts.Format == UserFileFormatCSV {
+		csvWriter = csv.NewWriter(w)
+		if err := csvWriter.Write(e.opts.Fields); err != nil {
+			return 0, fmt.Errorf("service.UserExport.Stream: %w", err)
+		}
+	} else {
+		jsonEncoder = json.NewEncoder(w)
+	}
+	flusher, _ := w.(interface{ Flush() })
+
+	count := 0
+	page := repository.UserPageRequest{Sort: repository.UserSortID, Limit: userExportBatchSize}
+	for {
+		users, err := repository.FindUsers(e.opts.Filter, page)
+		if err != nil {
+			return count, fmt.Errorf("service.UserExport.Stream: %w", err)
+		}
+		for i := range users {
+			if csvWriter != nil {
+				err = csvWriter.Write(e.csvRecord(&users[i]))
+			} else {
+				err = jsonEncoder.Encode(e.jsonObject(&users[i]))
+			}
+			if err != nil {
+				return count, fmt.Errorf("service.UserExport.Stream: %w", err)
+			}
+			count++
+		}
+		if csvWriter != nil {
+			csvWriter.Flush()
+			if err := csvWriter.Error(); err != nil {
+				return count, fmt.Errorf("service.UserExport.Stream: %w", err)
+			}
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		if len(users) < userExportBatchSize {
+			return count, nil
+		}
+		page.After, page.AfterID = true, users[len(users)-1].ID
+	}
+}
+
+// csvRecord はユーザーを CSV の1行にします。日時は RFC 3339 (UTC)、NULL は空文字列にします。
+// 文字列は表計算ソフトで数式として実行されないよう csvSafeString で変換します。
+func (e *UserExport) csvRecord(u *domain.User) []string {
+	record := make([]string, len(e.opts.Fields))
+	for i, f := range e.opts.Fields {
+		switch v := userExportFields[f](u).(type) {
+		case nil:
+		case string:
+			record[i] = csvSafeString(v)
+		case int64:
+			record[i] = strconv.FormatInt(v, 10)
+		case bool:
+			record[i] = strconv.FormatBool(v)
+		case time.Time:
+			record[i] = v.UTC().Format(time.RFC3339)
+		}
+	}
+	return record
+}
+
+// csvSafeString は表示名や自己紹介などユーザーが入力した文字列が数式として解釈されないよう、
+// 数式の開始とみなされる文字で始まる場合は先頭に ' を付けます (CSV インジェクション対策)。
+func csvSafeString(v string) string {
+	if v != "" && strings.ContainsRune("=+-@\t\r", rune(v[0])) {
+		return "'" + v
+	}
+	return v
+}
+
+// jsonObject はユーザーを NDJSON の1行分のオブジェクトにします。項目はキーの順に並びます。
+func (e *UserExport) jsonObject(u *domain.User) map[string]any {
+	obj := make(map[string]any, len(e.opts.Fields))
+	for _, f := range e.opts.Fields {
+		v := userExportFields[f](u)
+		if t, ok := v.(time.Time); ok {
+			v = t.UTC().Format(time.RFC3339)
+		}
+		obj[f] = v
+	}
+	return obj
+}
+
+// nullTime は NULL の日時を nil として返します。
+func nullTime(t time.Time, valid bool) any {
+	if !valid {
+		return nil
+	}
+	return t
+}
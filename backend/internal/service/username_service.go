@@ -0,0 +1,211 @@
+// backend/internal/service/username_service.go
+package service
+
+import (
+	"backend/internal/config"
+	"backend/internal/domain"
+	"backend/internal/identifier"
+	"backend/internal/repository"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrInvalidUsername はユーザー名の形式が正しくない場合に返されます。理由となる identifier のエラーも合わせて返します。
+	ErrInvalidUsername = errors.New("ユーザー名が正しくありません")
+	// ErrSameUsername は新しいユーザー名が現在のものと同じ場合に返されます。
+	ErrSameUsername = errors.New("新しいユーザー名が現在のものと同じです")
+	// ErrUsernameTaken はユーザー名が他のユーザーに使用されている、または変更後の保持期間中の場合に返されます。
+	ErrUsernameTaken = errors.New("このユーザー名は既に使用されています")
+	// ErrUsernameReserved はユーザー名が予約されている場合に返されます。
+	ErrUsernameReserved = errors.New("このユーザー名は使用できません")
+)
+
+// UsernameChangeTooSoonError は前回の変更から USERNAME_CHANGE_INTERVAL が経過していない場合に返されます。
+type UsernameChangeTooSoonError struct {
+	NextChangeAt time.Time
+}
+
+func (e *UsernameChangeTooSoonError) Error() string {
+	return fmt.Sprintf("ユーザー名は %s 以降に変更できます", e.NextChangeAt.UTC().Format(time.RFC3339))
+}
+
+// ChangeUsername はユーザー名を変更し、変更前のユーザー名を USERNAME_HOLD_PERIOD の間保持します。
+// 形式・予約名・使用状況を確認し、前回の変更から USERNAME_CHANGE_INTERVAL が経過していない場合は
+// *UsernameChangeTooSoonError を返します。
+func ChangeUsername(userID int64, newUsername string, client ClientInfo) (*domain.User, error) {
+	user, err := repository.GetUserByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("service.ChangeUsername: %w", err)
+	}
+	if user == nil || user.DeletedAt.Valid {
+		return nil, ErrUserNotFound
+	}
+
+	newUsername, err = normalizeUsername(newUsername)
+	if err != nil {
+		return nil, err
+	}
+	if newUsername == user.Username {
+		return nil, ErrSameUsername
+	}
+
+	audit := func(outcome string, reason string) {
+		details := map[string]any{"fields": []string{"username"}, "old_username": user.Username, "new_username": newUsername}
+		if reason != "" {
+			details["reason"] = reason
+		}
+		RecordAudit(AuditEntry{Event: AuditEventUserUpdate, Outcome: outcome, ActorUserID: userID, TargetUserID: userID, Client: client,
+			Details: details})
+	}
+
+	cfg := config.AppConfig.Username
+	now := time.Now()
+	last, err := repository.GetLatestUsernameChangeByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("service.ChangeUsername: %w", err)
+	}
+	if last != nil && now.Before(last.ChangedAt.Add(cfg.ChangeInterval)) {
+		audit(AuditOutcomeFailure, "rate_limited")
+		return nil, &UsernameChangeTooSoonError{NextChangeAt: last.ChangedAt.Add(cfg.ChangeInterval)}
+	}
+
+	if err := checkUsernameAvailable(newUsername, userID); err != nil {
+		if errors.Is(err, ErrUsernameTaken) || errors.Is(err, ErrUsernameReserved) {
+			audit(AuditOutcomeFailure, "unavailable")
+		}
+		return nil, err
+	}
+
+	changed, err := repository.ChangeUsername(userID, user.Username, newUsername, now.Add(cfg.HoldPeriod))
+	if err != nil {
+		return nil, fmt.Errorf("service.ChangeUsername: %w", err)
+	}
+	if changed == 0 {
+		// 確認の直後に並行して変更・削除された場合です。
+		return nil, ErrUsernameTaken
+	}
+	audit(AuditOutcomeSuccess, "")
+
+	user.Username = newUsername
+	return user, nil
+}
+
+// normalizeUsername は入力されたユーザー名を identifier.NormalizeUsername で正規化します。
+// 使用できないユーザー名の場合は ErrInvalidUsername と理由のエラーを合わせて返します。
+func normalizeUsername(username string) (string, error) {
+	normalized, err := identifier.NormalizeUsername(username)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrInvalidUsername, err)
+	}
+	return normalized, nil
+}
+
+// checkUsernameAvailable はユーザー名が予約されておらず、userID 以外のユーザーに使用・保持されていないことを確認します。
+// 大文字・小文字や全角・半角の違い、紛らわしい文字の違いしかないユーザー名も同じものとして扱います。
+// 新規登録の場合は userID に 0 を指定します。
+func checkUsernameAvailable(username string, userID int64) error {
+	skeleton := identifier.UsernameSkeleton(username)
+	for _, reserved := range config.AppConfig.Username.Reserved {
+		if identifier.UsernameSkeleton(reserved) == skeleton {
+			return ErrUsernameReserved
+		}
+	}
+
+	inUse, err := repository.UsernameInUse(username, userID)
+	if err != nil {
+		return fmt.Errorf("service.checkUsernameAvailable: %w", err)
+	}
+	if inUse {
+		return ErrUsernameTaken
+	}
+
+	// 手放されたユーザー名は保持期間中、元の所有者のみが使用できます。
+	// 使用中のユーザー名と同様に、見た目が紛らわしいユーザー名 (スケルトンが同じもの) も使用できません。
+	held, err := repository.UsernameHeld(username, userID, time.Now())
+	if err != nil {
+		return fmt.Errorf("service.checkUsernameAvailable: %w", err)
+	}
+	if held {
+		return ErrUsernameTaken
+	}
+	return nil
+}
+
+// FindUserByUsername は現在のユーザー名、または変更前のユーザー名でユーザーを取得します。
+// 変更前のユーザー名で見つかった場合は renamed に true を返します。現在そのユーザー名を使用しているユーザーが
+// いる場合はそちらを優先します。該当するユーザーがいない場合は ErrUserNotFound を返します。
+func FindUserByUsername(username string) (user *domain.User, renamed bool, err error) {
+	user, err = repository.GetUserByUsername(username)
+	if err != nil {
+		return nil, false, fmt.Errorf("service.FindUserByUsername: %w", err)
+	}
+	if user != nil {
+		return user, false, nil
+	}
+
+	history, err := repository.GetLatestUsernameHistory(username)
+	if err != nil {
+		return nil, false, fmt.Errorf("service.FindUserByUsername: %w", err)
+	}
+	if history == nil {
+		return nil, false, ErrUserNotFound
+	}
+	user, err = repository.GetUserByID(history.UserID)
+	if err != nil {
+		return nil, false, fmt.Errorf("service.FindUserByUsername: %w", err)
+	}
+	if user == nil || user.DeletedAt.Valid {
+		return nil, false, ErrUserNotFound
+	}
+	return user, true, nil
+}
+
+// UsernameBackfillReport は既存ユーザーのユーザー名の正規形を埋めた結果です。
+type UsernameBackfillReport struct {
+	Updated        int
+	Conflicts      []domain.User // 正規形またはスケルトンが他のユーザーと重なるため埋められなかったユーザー (ID とユーザー名のみ)
+	HistoryUpdated int64
+}
+
+// BackfillCanonicalUsernames は正規形が未設定の既存ユーザーと変更履歴に、ユーザー名の正規形とスケルトンを保存します。
+// 大文字・小文字違いなどで他のユーザーと重なるユーザーは変更せずに報告します。そのユーザーは引き続き
+// ユーザー名の完全一致でログインできるため、管理者がどちらかのユーザー名を変更してから再度実行してください。
+func BackfillCanonicalUsernames() (*UsernameBackfillReport, error) {
+	const batchSize = 500
+
+	report := &UsernameBackfillReport{}
+	var afterID int64
+	for {
+		users, err := repository.GetUsersWithoutCanonicalUsername(afterID, batchSize)
+		if err != nil {
+			return report, fmt.Errorf("service.BackfillCanonicalUsernames: %w", err)
+		}
+		for _, u := range users {
+			afterID = u.ID
+			inUse, err := repository.UsernameInUse(u.Username, u.ID)
+			if err != nil {
+				return report, fmt.Errorf("service.BackfillCanonicalUsernames: %w", err)
+			}
+			if inUse {
+				report.Conflicts = append(report.Conflicts, u)
+				continue
+			}
+			if err := repository.SetCanonicalUsername(u.ID, u.Username); err != nil {
+				return report, fmt.Errorf("service.BackfillCanonicalUsernames: %w", err)
+			}
+			report.Updated++
+		}
+		if len(users) < batchSize {
+			break
+		}
+	}
+
+	updated, err := repository.BackfillUsernameHistoryCanonical()
+	report.HistoryUpdated = updated
+	if err != nil {
+		return report, fmt.Errorf("service.BackfillCanonicalUsernames: %w", err)
+	}
+	return report, nil
+}
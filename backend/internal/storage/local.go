@@ -0,0 +1,97 @@
+// backend/internal/storage/local.go
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidSignature は署名付き URL の署名が正しくない、または期限切れの場合に返されます。
+var ErrInvalidSignature = errors.New("URL の署名が正しくないか、有効期限が切れています")
+
+// LocalStore はサーバーのディレクトリにオブジェクトを保存します。
+// オブジェクトは GET /api/blobs/<key>?expires=...&sig=... で配信され、署名は HMAC-SHA256 です。
+type LocalStore struct {
+	dir       string
+	publicURL string
+	key       []byte
+}
+
+func newLocalStore(dir, publicURL string, key []byte) (*LocalStore, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("保存先のディレクトリを作成できません: %w", err)
+	}
+	return &LocalStore{dir: dir, publicURL: strings.TrimSuffix(publicURL, "/"), key: key}, nil
+}
+
+// Put は一時ファイルに書き込んでから置き換えるため、読み込み中のファイルが途中の状態になることはありません。
+// ファイルの種類は配信時に拡張子から判断するため、contentType は保存しません。
+func (s *LocalStore) Put(_ context.Context, key string, data []byte, _ string) error {
+	path := s.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (s *LocalStore) Delete(_ context.Context, key string) error {
+	if err := os.Remove(s.path(key)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *LocalStore) SignedURL(key string, expires time.Time) (string, error) {
+	exp := strconv.FormatInt(expires.Unix(), 10)
+	q := url.Values{"expires": {exp}, "sig": {s.sign(key, exp)}}
+	return s.publicURL + "/api/blobs/" + (&url.URL{Path: key}).EscapedPath() + "?" + q.Encode(), nil
+}
+
+// Open は署名付き URL を検証し、オブジェクトのファイルのパスを返します。
+func (s *LocalStore) Open(key, expires, sig string) (string, error) {
+	if err := validateKey(key); err != nil {
+		return "", err
+	}
+	exp, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > exp {
+		return "", ErrInvalidSignature
+	}
+	if !hmac.Equal([]byte(sig), []byte(s.sign(key, expires))) {
+		return "", ErrInvalidSignature
+	}
+	return s.path(key), nil
+}
+
+// sign はキーと有効期限に対する署名を返します。
+func (s *LocalStore) sign(key, expires string) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(key + "\n" + expires))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// path はキーに対応するファイルのパスです。キーは validateKey で確認済みです。
+func (s *LocalStore) path(key string) string {
+	return filepath.Join(s.dir, filepath.FromSlash(key))
+}
@@ -0,0 +1,110 @@
+// backend/internal/storage/local_test.go
+package storage
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// parseLocalURL は署名付き URL からキー・有効期限・署名を取り出します。
+func parseLocalURL(t *testing.T, rawURL string) (key, expires, sig string) {
+	t.Helper()
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, ok := strings.CutPrefix(u.Path, "/api/blobs/")
+	if !ok {
+		t.Fatalf("unexpected signed URL path: %s", u.Path)
+	}
+	return key, u.Query().Get("expires"), u.Query().Get("sig")
+}
+
+func TestLocalStoreSignedURL(t *testing.T) {
+	s, err := newLocalStore(t.TempDir(), "https://api.example.com/", []byte("signing-key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := "avatars/12/a b.jpg"
+	if err := s.Put(context.Background(), key, []byte("data"), "image/jpeg"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	signed, err := s.SignedURL(key, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(signed, "https://api.example.com/api/blobs/avatars/12/a%20b.jpg?") {
+		t.Errorf("SignedURL = %s", signed)
+	}
+	gotKey, expires, sig := parseLocalURL(t, signed)
+	path, err := s.Open(gotKey, expires, sig)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != "data" {
+		t.Fatalf("ReadFile(%s) = %q, %v", path, data, err)
+	}
+
+	expired, err := s.SignedURL(key, time.Now().Add(-time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, expiredExpires, expiredSig := parseLocalURL(t, expired)
+	other, err := newLocalStore(t.TempDir(), "", []byte("another-key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherSigned, err := other.SignedURL(key, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, otherExpires, otherSig := parseLocalURL(t, otherSigned)
+
+	tests := []struct {
+		name             string
+		key, expires, sg string
+		want             error
+	}{
+		{"expired", key, expiredExpires, expiredSig, ErrInvalidSignature},
+		{"signed with another key", key, otherExpires, otherSig, ErrInvalidSignature},
+		{"other object", "avatars/12/b.jpg", expires, sig, ErrInvalidSignature},
+		{"extended expiry", key, expires + "0", sig, ErrInvalidSignature},
+		{"malformed expiry", key, "soon", sig, ErrInvalidSignature},
+		{"missing signature", key, expires, "", ErrInvalidSignature},
+		{"path traversal", "../secret", expires, sig, ErrInvalidKey},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := s.Open(tt.key, tt.expires, tt.sg); !errors.Is(err, tt.want) {
+				t.Fatalf("Open() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocalStoreDelete(t *testing.T) {
+	dir := t.TempDir()
+	s, err := newLocalStore(dir, "", []byte("signing-key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if err := s.Put(ctx, "avatars/1/a.png", []byte("data"), "image/png"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete(ctx, "avatars/1/a.png"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := os.Stat(s.path("avatars/1/a.png")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("file still exists after Delete: %v", err)
+	}
+	if err := s.Delete(ctx, "avatars/1/a.png"); err != nil {
+		t.Fatalf("Delete(missing): %v", err)
+	}
+}
@@ -0,0 +1,243 @@
+// backend/internal/storage/s3.go
+package storage
+
+import (
+	"backend/internal/config"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// s3Store は S3 互換のオブジェクトストレージ (AWS S3、MinIO など) にオブジェクトを保存します。
+// リクエストには AWS Signature Version 4 で署名します。
+type s3Store struct {
+	endpoint        *url.URL
+	region          string
+	bucket          string
+	accessKeyID     string
+	secretAccessKey string
+	pathStyle       bool
+	client          *http.Client
+}
+
+// unsignedPayload は署名付き URL で本文のハッシュを署名に含めないことを表します。
+const unsignedPayload = "UNSIGNED-PAYLOAD"
+
+func newS3Store(cfg config.StorageConfig) (*s3Store, error) {
+	endpoint, err := url.Parse(strings.TrimSuffix(cfg.S3Endpoint, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("STORAGE_S3_ENDPOINT を解析できません: %w", err)
+	}
+	return &s3Store{
+		endpoint:        endpoint,
+		region:          cfg.S3Region,
+		bucket:          cfg.S3Bucket,
+		accessKeyID:     cfg.S3AccessKeyID,
+		secretAccessKey: cfg.S3SecretAccessKey,
+		pathStyle:       cfg.S3PathStyle,
+		client:          &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+func (s *s3Store) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(key).String(), bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.ContentLength = int64(len(data))
+	req.Header.Set("Content-Type", contentType)
+	s.signRequest(req, data, time.Now())
+	return s.do(req, http.StatusOK)
+}
+
+func (s *s3Store) Delete(ctx context.Context, key string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, s.objectURL(key).String(), nil)
+	if err != nil {
+		return err
+	}
+	s.signRequest(req, nil, time.Now())
+	// S3 は存在しないオブジェクトの削除にも 204 を返しますが、互換実装には 404 を返すものもあります。
+	return s.do(req, http.StatusNoContent, http.StatusOK, http.StatusNotFound)
+}
+
+// SignedURL は署名付き URL (presigned URL) を返します。expires は署名時刻から最長 7 日後です。
+func (s *s3Store) SignedURL(key string, expires time.Time) (string, error) {
+	// 同じ有効期限の間は同じ URL になるよう、署名時刻を有効期限から決めます。
+	now := time.Now().UTC()
+	signedAt := expires.Add(-urlTTL).UTC()
+	if signedAt.After(now) {
+		signedAt = now
+	}
+	u := s.objectURL(key)
+	q := url.Values{
+		"X-Amz-Algorithm":     {"AWS4-HMAC-SHA256"},
+		"X-Amz-Credential":    {s.accessKeyID + "/" + s.scope(signedAt)},
+		"X-Amz-Date":          {signedAt.Format("20060102T150405Z")},
+		"X-Amz-Expires":       {strconv.FormatInt(int64(expires.Sub(signedAt)/time.Second), 10)},
+		"X-Amz-SignedHeaders": {"host"},
+	}
+	u.RawQuery = canonicalQuery(q)
+	headers := map[string]string{"host": u.Host}
+	signature := s.signature(http.MethodGet, u, headers, unsignedPayload, signedAt)
+	u.RawQuery += "&X-Amz-Signature=" + signature
+	return u.String(), nil
+}
+
+// objectURL はオブジェクトの URL を返します。
+func (s *s3Store) objectURL(key string) *url.URL {
+	u := *s.endpoint
+	basePath := strings.TrimSuffix(s.endpoint.Path, "/")
+	baseRawPath := strings.TrimSuffix(s.endpoint.EscapedPath(), "/")
+	if s.pathStyle {
+		u.Path = basePath + "/" + s.bucket + "/" + key
+		u.RawPath = baseRawPath + "/" + awsEscape(s.bucket) + "/" + awsEscapePath(key)
+	} else {
+		u.Host = s.bucket + "." + u.Host
+		u.Path = basePath + "/" + key
+		u.RawPath = baseRawPath + "/" + awsEscapePath(key)
+	}
+	return &u
+}
+
+// signRequest はリクエストの Authorization ヘッダーを設定します。
+func (s *s3Store) signRequest(req *http.Request, body []byte, now time.Time) {
+	now = now.UTC()
+	sum := sha256.Sum256(body)
+	payloadHash := hex.EncodeToString(sum[:])
+	req.Header.Set("X-Amz-Date", now.Format("20060102T150405Z"))
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{
+		"host":                 req.URL.Host,
+		"x-amz-date":           req.Header.Get("X-Amz-Date"),
+		"x-amz-content-sha256": payloadHash,
+	}
+	if ct := req.Header.Get("Content-Type"); ct != "" {
+		headers["content-type"] = ct
+	}
+	signature := s.signature(req.Method, req.URL, headers, payloadHash, now)
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKeyID, s.scope(now), signedHeaderNames(headers), signature))
+}
+
+// signature は Signature Version 4 の署名を計算します。
+func (s *s3Store) signature(method string, u *url.URL, headers map[string]string, payloadHash string, t time.Time) string {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(headers[name]) + "\n")
+	}
+
+	canonicalRequest := strings.Join([]string{
+		method,
+		u.EscapedPath(),
+		u.RawQuery,
+		canonicalHeaders.String(),
+		strings.Join(names, ";"),
+		payloadHash,
+	}, "\n")
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		t.Format("20060102T150405Z"),
+		s.scope(t),
+		hex.EncodeToString(requestHash[:]),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.secretAccessKey), t.Format("20060102"))
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+// scope は署名の資格情報のスコープ "<日付>/<リージョン>/s3/aws4_request" です。
+func (s *s3Store) scope(t time.Time) string {
+	return t.Format("20060102") + "/" + s.region + "/s3/aws4_request"
+}
+
+// do はリクエストを送信し、想定外のステータスの場合はレスポンスの本文を含むエラーを返します。
+func (s *s3Store) do(req *http.Request, okStatus ...int) error {
+	res, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	for _, status := range okStatus {
+		if res.StatusCode == status {
+			io.Copy(io.Discard, res.Body)
+			return nil
+		}
+	}
+	body, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+	return fmt.Errorf("%s %s: %s: %s", req.Method, req.URL.Path, res.Status, bytes.TrimSpace(body))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// signedHeaderNames は署名に含めたヘッダー名を ";" 区切りで返します。
+func signedHeaderNames(headers map[string]string) string {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ";")
+}
+
+// canonicalQuery はクエリパラメーターを名前順に並べ、AWS の規則でエスケープします。
+func canonicalQuery(q url.Values) string {
+	names := make([]string, 0, len(q))
+	for name := range q {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var parts []string
+	for _, name := range names {
+		for _, v := range q[name] {
+			parts = append(parts, awsEscape(name)+"="+awsEscape(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// awsEscapePath はパスの各セグメントを AWS の規則でエスケープします ("/" はそのまま残します)。
+func awsEscapePath(p string) string {
+	segments := strings.Split(p, "/")
+	for i, seg := range segments {
+		segments[i] = awsEscape(seg)
+	}
+	return strings.Join(segments, "/")
+}
+
+// awsEscape は RFC 3986 の非予約文字 (A-Z a-z 0-9 - _ . ~) 以外をパーセントエンコードします。
+func awsEscape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,113 @@
+// backend/internal/storage/storage.go
+package storage
+
+import (
+	"backend/internal/config"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+)
+
+// ErrInvalidKey はオブジェクトのキーが使用できない形式の場合に返されます。
+var ErrInvalidKey = errors.New("オブジェクトのキーが正しくありません")
+
+// BlobStore はアバター画像などのファイル (オブジェクト) の保存先です。
+// キーは "avatars/12/abc_64.jpg" のような "/" 区切りの相対パスです。
+type BlobStore interface {
+	// Put はオブジェクトを保存します。同じキーのオブジェクトがあれば置き換えます。
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	// Delete はオブジェクトを削除します。存在しない場合もエラーにしません。
+	Delete(ctx context.Context, key string) error
+	// SignedURL は expires まで有効な、オブジェクトを取得するための署名付き URL を返します。
+	SignedURL(key string, expires time.Time) (string, error)
+}
+
+// store は Put などで使用する保存先です。Init で設定されます。
+var store BlobStore
+
+// urlTTL は署名付き URL の有効期間です。
+var urlTTL = time.Hour
+
+// Init は設定から保存先を選択します。local の場合は保存先のディレクトリを作成します。
+// jwtSecret は local の署名付き URL の鍵が設定されていない場合に鍵を導出するために使います。
+func Init(cfg config.StorageConfig, jwtSecret string) error {
+	urlTTL = cfg.URLTTL
+	switch cfg.Driver {
+	case config.StorageDriverS3:
+		s, err := newS3Store(cfg)
+		if err != nil {
+			return fmt.Errorf("storage.Init: %w", err)
+		}
+		store = s
+	default:
+		key := []byte(cfg.URLSigningKey)
+		if len(key) == 0 {
+			mac := hmac.New(sha256.New, []byte(jwtSecret))
+			mac.Write([]byte("yutaka-storage-url"))
+			key = mac.Sum(nil)
+		}
+		s, err := newLocalStore(cfg.LocalDir, cfg.PublicURL, key)
+		if err != nil {
+			return fmt.Errorf("storage.Init: %w", err)
+		}
+		store = s
+	}
+	return nil
+}
+
+// Put は設定された保存先にオブジェクトを保存します。
+func Put(ctx context.Context, key string, data []byte, contentType string) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+	if err := store.Put(ctx, key, data, contentType); err != nil {
+		return fmt.Errorf("storage.Put: %s: %w", key, err)
+	}
+	return nil
+}
+
+// Delete は設定された保存先からオブジェクトを削除します。
+func Delete(ctx context.Context, key string) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+	if err := store.Delete(ctx, key); err != nil {
+		return fmt.Errorf("storage.Delete: %s: %w", key, err)
+	}
+	return nil
+}
+
+// SignedURL はオブジェクトの署名付き URL を返します。
+// ブラウザーのキャッシュが効くよう、有効期限を URLTTL の半分の単位で切り上げ、
+// その間は同じ URL を返します (残りの有効期間は URLTTL の半分以上になります)。
+func SignedURL(key string) (string, error) {
+	if err := validateKey(key); err != nil {
+		return "", err
+	}
+	expires := time.Now().Truncate(urlTTL / 2).Add(urlTTL)
+	u, err := store.SignedURL(key, expires)
+	if err != nil {
+		return "", fmt.Errorf("storage.SignedURL: %s: %w", key, err)
+	}
+	return u, nil
+}
+
+// Local は保存先が local の場合にその保存先を返します。署名付き URL の配信に使います。
+func Local() (*LocalStore, bool) {
+	s, ok := store.(*LocalStore)
+	return s, ok
+}
+
+// validateKey はキーが保存先の外を指さない正規化された相対パスであることを確認します。
+func validateKey(key string) error {
+	if key == "" || len(key) > 512 || strings.HasPrefix(key, "/") || path.Clean(key) != key ||
+		key == ".." || strings.HasPrefix(key, "../") || strings.ContainsAny(key, "\\\x00?#") {
+		return ErrInvalidKey
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+// backend/internal/storage/storage_test.go
+package storage
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateKey(t *testing.T) {
+	valid := []string{
+		"avatars/12/abc_64.jpg",
+		"a",
+		"avatars/12/a b.png",
+	}
+	for _, key := range valid {
+		if err := validateKey(key); err != nil {
+			t.Errorf("validateKey(%q) = %v, want nil", key, err)
+		}
+	}
+
+	invalid := []string{
+		"",
+		"/avatars/1.jpg",
+		"..",
+		"../secret",
+		"avatars/../../secret",
+		"avatars//1.jpg",
+		"avatars/./1.jpg",
+		"avatars/1.jpg/",
+		`avatars\..\secret`,
+		"avatars/1.jpg\x00.png",
+		"avatars/1.jpg?x=1",
+		"avatars/1.jpg#frag",
+		strings.Repeat("a", 513),
+	}
+	for _, key := range invalid {
+		if err := validateKey(key); err != ErrInvalidKey {
+			t.Errorf("validateKey(%q) = %v, want ErrInvalidKey", key, err)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+// backend/internal/webauthn/cbor.go
+package webauthn
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// WebAuthn で使われる CBOR (RFC 8949) のサブセットのデコーダーです。
+// attestationObject と COSE 公開鍵は確定長のみで符号化されるため、不定長には対応しません。
+
+// maxCBORDepth は入れ子の深さの上限です。不正な入力でスタックを使い果たさないようにします。
+const maxCBORDepth = 16
+
+var errCBORTruncated = errors.New("CBOR データが途中で終わっています")
+
+// decodeCBOR は data の先頭にある CBOR の値を1つ読み取り、値と残りのバイト列を返します。
+// 整数は int64、バイト列は []byte、文字列は string、配列は []any、マップは map[any]any になります。
+func decodeCBOR(data []byte) (any, []byte, error) {
+	return decodeCBORItem(data, 0)
+}
+
+func decodeCBORItem(data []byte, depth int) (any, []byte, error) {
+	if depth > maxCBORDepth {
+		return nil, nil, errors.New("CBOR の入れ子が深すぎます")
+	}
+	if len(data) == 0 {
+		return nil, nil, errCBORTruncated
+	}
+
+	major := data[0] >> 5
+	info := data[0] & 0x1f
+	if major == 7 {
+		return decodeCBORSimple(info, data[1:])
+	}
+
+	arg, rest, err := readCBORArgument(info, data[1:])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch major {
+	case 0: // 符号なし整数
+		if arg > math.MaxInt64 {
+			return nil, nil, errors.New("CBOR の整数が大きすぎます")
+		}
+		return int64(arg), rest, nil
+	case 1: // 負の整数
+		if arg > math.MaxInt64 {
+			return nil, nil, errors.New("CBOR の整数が大きすぎます")
+		}
+		return -1 - int64(arg), rest, nil
+	case 2, 3: // バイト列, UTF-8 文字列
+		if arg > uint64(len(rest)) {
+			return nil, nil, errCBORTruncated
+		}
+		b := rest[:arg]
+		if major == 3 {
+			return string(b), rest[arg:], nil
+		}
+		return append([]byte(nil), b...), rest[arg:], nil
+	case 4: // 配列
+		if arg > uint64(len(rest)) {
+			return nil, nil, errCBORTruncated
+		}
+		arr := make([]any, 0, arg)
+		for i := uint64(0); i < arg; i++ {
+			var v any
+			if v, rest, err = decodeCBORItem(rest, depth+1); err != nil {
+				return nil, nil, err
+			}
+			arr = append(arr, v)
+		}
+		return arr, rest, nil
+	case 5: // マップ
+		if arg > uint64(len(rest)) {
+			return nil, nil, errCBORTruncated
+		}
+		m := make(map[any]any, arg)
+		for i := uint64(0); i < arg; i++ {
+			var k, v any
+			if k, rest, err = decodeCBORItem(rest, depth+1); err != nil {
+				return nil, nil, err
+			}
+			switch k.(type) {
+			case int64, string:
+			default:
+				return nil, nil, fmt.Errorf("CBOR のマップのキーの型 %T には対応していません", k)
+			}
+			if v, rest, err = decodeCBORItem(rest, depth+1); err != nil {
+				return nil, nil, err
+			}
+			if _, dup := m[k]; dup {
+				return nil, nil, fmt.Errorf("CBOR のマップのキー %v が重複しています", k)
+			}
+			m[k] = v
+		}
+		return m, rest, nil
+	case 6: // タグ (タグ番号は無視して中身を返します)
+		return decodeCBORItem(rest, depth+1)
+	}
+	return nil, nil, fmt.Errorf("CBOR のメジャータイプ %d には対応していません", major)
+}
+
+// readCBORArgument は初期バイトの追加情報から長さまたは値を読み取ります。
+func readCBORArgument(info byte, data []byte) (uint64, []byte, error) {
+	switch {
+	case info < 24:
+		return uint64(info), data, nil
+	case info == 24:
+		if len(data) < 1 {
+			return 0, nil, errCBORTruncated
+		}
+		return uint64(data[0]), data[1:], nil
+	case info == 25:
+		if len(data) < 2 {
+			return 0, nil, errCBORTruncated
+		}
+		return uint64(binary.BigEndian.Uint16(data)), data[2:], nil
+	case info == 26:
+		if len(data) < 4 {
+			return 0, nil, errCBORTruncated
+		}
+		return uint64(binary.BigEndian.Uint32(data)), data[4:], nil
+	case info == 27:
+		if len(data) < 8 {
+			return 0, nil, errCBORTruncated
+		}
+		return binary.BigEndian.Uint64(data), data[8:], nil
+	}
+	return 0, nil, errors.New("CBOR の不定長の値には対応していません")
+}
+
+// decodeCBORSimple は真偽値・null・浮動小数点数を読み取ります。
+func decodeCBORSimple(info byte, data []byte) (any, []byte, error) {
+	switch info {
+	case 20:
+		return false, data, nil
+	case 21:
+		return true, data, nil
+	case 22, 23:
+		return nil, data, nil
+	case 25, 26, 27:
+		size := map[byte]int{25: 2, 26: 4, 27: 8}[info]
+		if len(data) < size {
+			return nil, nil, errCBORTruncated
+		}
+		switch size {
+		case 4:
+			return float64(math.Float32frombits(binary.BigEndian.Uint32(data))), data[4:], nil
+		case 8:
+			return math.Float64frombits(binary.BigEndian.Uint64(data)), data[8:], nil
+		}
+		// 半精度浮動小数点数は WebAuthn では使われないため値は解釈しません。
+		return float64(0), data[2:], nil
+	}
+	return nil, nil, fmt.Errorf("CBOR の単純値 %d には対応していません", info)
+}
@@ -0,0 +1,128 @@
+// backend/internal/webauthn/cose.go
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// COSE のアルゴリズム識別子です (RFC 9053)。
+const (
+	AlgES256 int64 = -7
+	AlgEdDSA int64 = -8
+	AlgRS256 int64 = -257
+)
+
+// SupportedAlgorithms は登録時に認証器へ提示する署名アルゴリズムです (優先順)。
+var SupportedAlgorithms = []int64{AlgES256, AlgEdDSA, AlgRS256}
+
+// COSE_Key のラベルと値です。
+const (
+	coseKeyType   = 1
+	coseAlgorithm = 3
+	coseCurve     = -1 // EC2 / OKP の曲線
+	coseX         = -2 // EC2 / OKP の x 座標
+	coseY         = -3 // EC2 の y 座標
+	coseRSAN      = -1 // RSA の法
+	coseRSAE      = -2 // RSA の公開指数
+
+	coseKeyTypeOKP = 1
+	coseKeyTypeEC2 = 2
+	coseKeyTypeRSA = 3
+
+	coseCurveP256    = 1
+	coseCurveEd25519 = 6
+)
+
+// PublicKey は COSE_Key 形式で保存された認証器の公開鍵です。
+type PublicKey struct {
+	Algorithm int64
+	key       crypto.PublicKey
+}
+
+// ParsePublicKey は COSE_Key 形式の公開鍵を解析します。
+func ParsePublicKey(cose []byte) (*PublicKey, error) {
+	v, rest, err := decodeCBOR(cose)
+	if err != nil {
+		return nil, fmt.Errorf("公開鍵を解析できません: %w", err)
+	}
+	if len(rest) != 0 {
+		return nil, errors.New("公開鍵の後に余分なデータがあります")
+	}
+	return publicKeyFromCOSE(v)
+}
+
+func publicKeyFromCOSE(v any) (*PublicKey, error) {
+	m, ok := v.(map[any]any)
+	if !ok {
+		return nil, errors.New("公開鍵が COSE_Key 形式ではありません")
+	}
+	kty, _ := m[int64(coseKeyType)].(int64)
+	alg, _ := m[int64(coseAlgorithm)].(int64)
+
+	switch {
+	case kty == coseKeyTypeEC2 && alg == AlgES256:
+		crv, _ := m[int64(coseCurve)].(int64)
+		x, _ := m[int64(coseX)].([]byte)
+		y, _ := m[int64(coseY)].([]byte)
+		if crv != coseCurveP256 || len(x) != 32 || len(y) != 32 {
+			return nil, errors.New("ES256 の公開鍵が不正です")
+		}
+		// 曲線上の点であることを確認するため、非圧縮形式に変換して検証付きで読み込みます。
+		point := append(append([]byte{0x04}, x...), y...)
+		if _, err := ecdh.P256().NewPublicKey(point); err != nil {
+			return nil, fmt.Errorf("ES256 の公開鍵が不正です: %w", err)
+		}
+		pub := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		return &PublicKey{Algorithm: alg, key: pub}, nil
+
+	case kty == coseKeyTypeOKP && alg == AlgEdDSA:
+		crv, _ := m[int64(coseCurve)].(int64)
+		x, _ := m[int64(coseX)].([]byte)
+		if crv != coseCurveEd25519 || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("EdDSA の公開鍵が不正です")
+		}
+		return &PublicKey{Algorithm: alg, key: ed25519.PublicKey(x)}, nil
+
+	case kty == coseKeyTypeRSA && alg == AlgRS256:
+		n, _ := m[int64(coseRSAN)].([]byte)
+		e, _ := m[int64(coseRSAE)].([]byte)
+		if len(n) < 256 || len(e) == 0 || len(e) > 4 {
+			return nil, errors.New("RS256 の公開鍵が不正です")
+		}
+		exp := int(new(big.Int).SetBytes(e).Int64())
+		return &PublicKey{Algorithm: alg, key: &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: exp}}, nil
+	}
+	return nil, fmt.Errorf("対応していない公開鍵です (kty=%d, alg=%d)", kty, alg)
+}
+
+// Verify は data に対する署名を検証します。
+func (k *PublicKey) Verify(data, signature []byte) error {
+	switch pub := k.key.(type) {
+	case *ecdsa.PublicKey:
+		digest := sha256.Sum256(data)
+		if !ecdsa.VerifyASN1(pub, digest[:], signature) {
+			return errors.New("署名が一致しません")
+		}
+	case ed25519.PublicKey:
+		if !ed25519.Verify(pub, data, signature) {
+			return errors.New("署名が一致しません")
+		}
+	case *rsa.PublicKey:
+		digest := sha256.Sum256(data)
+		if err := rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], signature); err != nil {
+			return errors.New("署名が一致しません")
+		}
+	default:
+		return errors.New("対応していない公開鍵です")
+	}
+	return nil
+}
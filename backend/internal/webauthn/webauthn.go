@@ -0,0 +1,273 @@
+// backend/internal/webauthn/webauthn.go
+package webauthn
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// WebAuthn Level 2 の登録 (create) と認証 (get) のうち、パスキーでのログインに必要な部分の検証を実装します。
+// 認証器の真正性 (アテステーション) は信頼の判断に使わないため、attestation は "none" を要求し、
+// 他の形式が返された場合も attStmt は検証しません。
+
+// UserVerification の要求レベルです。
+const (
+	UserVerificationRequired    = "required"
+	UserVerificationPreferred   = "preferred"
+	UserVerificationDiscouraged = "discouraged"
+)
+
+// authenticatorData のフラグです。
+const (
+	flagUserPresent            = 0x01
+	flagUserVerified           = 0x04
+	flagBackupEligible         = 0x08
+	flagBackedUp               = 0x10
+	flagAttestedCredentialData = 0x40
+	flagExtensionData          = 0x80
+)
+
+// RelyingParty は検証に使う RP (このサービス) の情報です。
+type RelyingParty struct {
+	ID               string   // RP ID (例: "example.com")
+	Name             string   // 認証器に表示される名前
+	Origins          []string // 許可するオリジン (例: "https://app.example.com")
+	UserVerification string   // required / preferred / discouraged
+	Timeout          time.Duration
+}
+
+// URLEncodedBytes は JSON で base64url 文字列として表されるバイト列です。
+// PublicKeyCredential.toJSON() の形式に合わせています。
+type URLEncodedBytes []byte
+
+// MarshalJSON はパディングなしの base64url 文字列に変換します。
+func (b URLEncodedBytes) MarshalJSON() ([]byte, error) {
+	return json.Marshal(base64.RawURLEncoding.EncodeToString(b))
+}
+
+// UnmarshalJSON は base64url 文字列 (パディングの有無を問わない) を読み取ります。
+func (b *URLEncodedBytes) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	decoded, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+	if err != nil {
+		return fmt.Errorf("base64url として解釈できません: %w", err)
+	}
+	*b = decoded
+	return nil
+}
+
+// CollectedClientData は clientDataJSON の内容です。
+type CollectedClientData struct {
+	Type        string `json:"type"`
+	Challenge   string `json:"challenge"`
+	Origin      string `json:"origin"`
+	CrossOrigin bool   `json:"crossOrigin"`
+}
+
+// ParseClientData は clientDataJSON を解析します。
+// チャレンジの照合は呼び出し側で行い、その後 VerifyRegistration / VerifyAssertion を呼び出します。
+func ParseClientData(raw []byte) (*CollectedClientData, error) {
+	var cd CollectedClientData
+	if err := json.Unmarshal(raw, &cd); err != nil {
+		return nil, fmt.Errorf("clientDataJSON を解析できません: %w", err)
+	}
+	if cd.Challenge == "" {
+		return nil, errors.New("clientDataJSON にチャレンジがありません")
+	}
+	return &cd, nil
+}
+
+// AuthenticatorData は認証器が署名したデータです。
+type AuthenticatorData struct {
+	RPIDHash  []byte
+	Flags     byte
+	SignCount uint32
+
+	// 以下は登録時 (AT フラグあり) のみ設定されます。
+	AAGUID       []byte
+	CredentialID []byte
+	PublicKey    []byte // COSE_Key 形式
+}
+
+// BackupEligible はパスキーが複数の端末に同期可能かどうかを返します。
+func (d *AuthenticatorData) BackupEligible() bool { return d.Flags&flagBackupEligible != 0 }
+
+// BackedUp はパスキーが現在同期されているかどうかを返します。
+func (d *AuthenticatorData) BackedUp() bool { return d.Flags&flagBackedUp != 0 }
+
+// Credential は登録で得られた認証器の資格情報です。
+type Credential struct {
+	ID             []byte
+	PublicKey      []byte // COSE_Key 形式
+	Algorithm      int64
+	SignCount      uint32
+	AAGUID         []byte
+	BackupEligible bool
+	BackedUp       bool
+}
+
+// VerifyRegistration は navigator.credentials.create() の結果を検証し、登録する資格情報を返します。
+func (rp *RelyingParty) VerifyRegistration(cd *CollectedClientData, attestationObject []byte) (*Credential, error) {
+	if err := rp.verifyClientData(cd, "webauthn.create"); err != nil {
+		return nil, err
+	}
+
+	v, rest, err := decodeCBOR(attestationObject)
+	if err != nil {
+		return nil, fmt.Errorf("attestationObject を解析できません: %w", err)
+	}
+	att, ok := v.(map[any]any)
+	if !ok || len(rest) != 0 {
+		return nil, errors.New("attestationObject の形式が正しくありません")
+	}
+	if _, ok := att["fmt"].(string); !ok {
+		return nil, errors.New("attestationObject に fmt がありません")
+	}
+	rawAuthData, ok := att["authData"].([]byte)
+	if !ok {
+		return nil, errors.New("attestationObject に authData がありません")
+	}
+
+	ad, err := parseAuthenticatorData(rawAuthData)
+	if err != nil {
+		return nil, err
+	}
+	if err := rp.verifyAuthenticatorData(ad); err != nil {
+		return nil, err
+	}
+	if ad.CredentialID == nil {
+		return nil, errors.New("authData に資格情報が含まれていません")
+	}
+
+	pub, err := ParsePublicKey(ad.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(SupportedAlgorithms, pub.Algorithm) {
+		return nil, fmt.Errorf("対応していない署名アルゴリズムです: %d", pub.Algorithm)
+	}
+
+	return &Credential{
+		ID:             ad.CredentialID,
+		PublicKey:      ad.PublicKey,
+		Algorithm:      pub.Algorithm,
+		SignCount:      ad.SignCount,
+		AAGUID:         ad.AAGUID,
+		BackupEligible: ad.BackupEligible(),
+		BackedUp:       ad.BackedUp(),
+	}, nil
+}
+
+// VerifyAssertion は navigator.credentials.get() の結果を、登録済みの公開鍵で検証します。
+// 署名カウンターの確認は呼び出し側で行います。
+func (rp *RelyingParty) VerifyAssertion(cd *CollectedClientData, clientDataJSON, authenticatorData, signature, publicKey []byte) (*AuthenticatorData, error) {
+	if err := rp.verifyClientData(cd, "webauthn.get"); err != nil {
+		return nil, err
+	}
+
+	ad, err := parseAuthenticatorData(authenticatorData)
+	if err != nil {
+		return nil, err
+	}
+	if err := rp.verifyAuthenticatorData(ad); err != nil {
+		return nil, err
+	}
+
+	pub, err := ParsePublicKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	signed := append(append([]byte(nil), authenticatorData...), clientDataHash[:]...)
+	if err := pub.Verify(signed, signature); err != nil {
+		return nil, err
+	}
+	return ad, nil
+}
+
+// verifyClientData はセレモニーの種類とオリジンを確認します。
+func (rp *RelyingParty) verifyClientData(cd *CollectedClientData, ceremony string) error {
+	if cd.Type != ceremony {
+		return fmt.Errorf("clientDataJSON の type が %q ではありません", ceremony)
+	}
+	if !slices.Contains(rp.Origins, cd.Origin) {
+		return fmt.Errorf("許可されていないオリジンです: %s", cd.Origin)
+	}
+	if cd.CrossOrigin {
+		return errors.New("クロスオリジンの iframe からの要求は許可されていません")
+	}
+	return nil
+}
+
+// verifyAuthenticatorData は RP ID とユーザーの存在・検証のフラグを確認します。
+func (rp *RelyingParty) verifyAuthenticatorData(ad *AuthenticatorData) error {
+	rpIDHash := sha256.Sum256([]byte(rp.ID))
+	if !bytes.Equal(ad.RPIDHash, rpIDHash[:]) {
+		return errors.New("RP ID が一致しません")
+	}
+	if ad.Flags&flagUserPresent == 0 {
+		return errors.New("ユーザーの操作が確認できませんでした")
+	}
+	if rp.UserVerification == UserVerificationRequired && ad.Flags&flagUserVerified == 0 {
+		return errors.New("ユーザー検証 (生体認証や PIN) が必要です")
+	}
+	return nil
+}
+
+// parseAuthenticatorData は authenticatorData のバイト列を解析します。
+func parseAuthenticatorData(data []byte) (*AuthenticatorData, error) {
+	if len(data) < 37 {
+		return nil, errors.New("authenticatorData が短すぎます")
+	}
+	ad := &AuthenticatorData{
+		RPIDHash:  data[:32],
+		Flags:     data[32],
+		SignCount: binary.BigEndian.Uint32(data[33:37]),
+	}
+	rest := data[37:]
+
+	if ad.Flags&flagAttestedCredentialData != 0 {
+		if len(rest) < 18 {
+			return nil, errors.New("authenticatorData の資格情報が短すぎます")
+		}
+		ad.AAGUID = rest[:16]
+		idLen := int(binary.BigEndian.Uint16(rest[16:18]))
+		rest = rest[18:]
+		if idLen == 0 || idLen > 1023 || len(rest) < idLen {
+			return nil, errors.New("authenticatorData の資格情報IDが不正です")
+		}
+		ad.CredentialID = rest[:idLen]
+		rest = rest[idLen:]
+
+		// 公開鍵は CBOR で符号化されており、その長さは読み取るまで分かりません。
+		_, after, err := decodeCBOR(rest)
+		if err != nil {
+			return nil, fmt.Errorf("authenticatorData の公開鍵を解析できません: %w", err)
+		}
+		ad.PublicKey = rest[:len(rest)-len(after)]
+		rest = after
+	}
+
+	if ad.Flags&flagExtensionData != 0 {
+		_, after, err := decodeCBOR(rest)
+		if err != nil {
+			return nil, fmt.Errorf("authenticatorData の拡張データを解析できません: %w", err)
+		}
+		rest = after
+	}
+	if len(rest) != 0 {
+		return nil, errors.New("authenticatorData の後に余分なデータがあります")
+	}
+	return ad, nil
+}
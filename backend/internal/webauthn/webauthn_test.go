@@ -0,0 +1,459 @@
+// backend/internal/webauthn/webauthn_test.go
+package webauthn
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+const (
+	testRPID   = "example.com"
+	testOrigin = "https://app.example.com"
+)
+
+func testRelyingParty() *RelyingParty {
+	return &RelyingParty{
+		ID:               testRPID,
+		Name:             "Test",
+		Origins:          []string{testOrigin},
+		UserVerification: UserVerificationRequired,
+	}
+}
+
+// cborPair はテスト用の CBOR エンコーダーでマップの要素を順序どおりに符号化するための組です。
+type cborPair struct {
+	key   any
+	value any
+}
+
+// cborMap は要素の順序を保つ CBOR のマップです。
+type cborMap []cborPair
+
+// encodeCBOR はテストで使う型 (int / int64 / string / []byte / cborMap / []any / bool) のみを符号化します。
+func encodeCBOR(v any) []byte {
+	head := func(major byte, n uint64) []byte {
+		switch {
+		case n < 24:
+			return []byte{major<<5 | byte(n)}
+		case n <= 0xff:
+			return []byte{major<<5 | 24, byte(n)}
+		case n <= 0xffff:
+			return binary.BigEndian.AppendUint16([]byte{major<<5 | 25}, uint16(n))
+		case n <= 0xffffffff:
+			return binary.BigEndian.AppendUint32([]byte{major<<5 | 26}, uint32(n))
+		}
+		return binary.BigEndian.AppendUint64([]byte{major<<5 | 27}, n)
+	}
+
+	switch v := v.(type) {
+	case int:
+		return encodeCBOR(int64(v))
+	case int64:
+		if v < 0 {
+			return head(1, uint64(-1-v))
+		}
+		return head(0, uint64(v))
+	case []byte:
+		return append(head(2, uint64(len(v))), v...)
+	case string:
+		return append(head(3, uint64(len(v))), v...)
+	case []any:
+		out := head(4, uint64(len(v)))
+		for _, item := range v {
+			out = append(out, encodeCBOR(item)...)
+		}
+		return out
+	case cborMap:
+		out := head(5, uint64(len(v)))
+		for _, p := range v {
+			out = append(out, encodeCBOR(p.key)...)
+			out = append(out, encodeCBOR(p.value)...)
+		}
+		return out
+	case bool:
+		if v {
+			return []byte{0xf5}
+		}
+		return []byte{0xf4}
+	}
+	panic(fmt.Sprintf("encodeCBOR: unsupported type %T", v))
+}
+
+// softwareAuthenticator はテスト内で生成した鍵で署名するソフトウェア認証器です。
+type softwareAuthenticator struct {
+	alg          int64
+	ecKey        *ecdsa.PrivateKey
+	edKey        ed25519.PrivateKey
+	credentialID []byte
+	signCount    uint32
+}
+
+func newSoftwareAuthenticator(t *testing.T, alg int64) *softwareAuthenticator {
+	t.Helper()
+	a := &softwareAuthenticator{alg: alg, credentialID: make([]byte, 16)}
+	if _, err := rand.Read(a.credentialID); err != nil {
+		t.Fatal(err)
+	}
+	var err error
+	switch alg {
+	case AlgES256:
+		a.ecKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case AlgEdDSA:
+		_, a.edKey, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		t.Fatalf("unsupported algorithm %d", alg)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+// coseKey は公開鍵を COSE_Key 形式で返します。
+func (a *softwareAuthenticator) coseKey() []byte {
+	if a.alg == AlgES256 {
+		x := a.ecKey.PublicKey.X.FillBytes(make([]byte, 32))
+		y := a.ecKey.PublicKey.Y.FillBytes(make([]byte, 32))
+		return encodeCBOR(cborMap{
+			{coseKeyType, coseKeyTypeEC2},
+			{coseAlgorithm, AlgES256},
+			{coseCurve, coseCurveP256},
+			{coseX, x},
+			{coseY, y},
+		})
+	}
+	return encodeCBOR(cborMap{
+		{coseKeyType, coseKeyTypeOKP},
+		{coseAlgorithm, AlgEdDSA},
+		{coseCurve, coseCurveEd25519},
+		{coseX, []byte(a.edKey.Public().(ed25519.PublicKey))},
+	})
+}
+
+// authenticatorData は rpID のハッシュ・フラグ・署名カウンターと、attested が true の場合は資格情報を含む authData を作ります。
+func (a *softwareAuthenticator) authenticatorData(rpID string, flags byte, attested bool) []byte {
+	a.signCount++
+	rpIDHash := sha256.Sum256([]byte(rpID))
+	data := append(rpIDHash[:], flags)
+	data = binary.BigEndian.AppendUint32(data, a.signCount)
+	if attested {
+		data = append(data, make([]byte, 16)...) // AAGUID
+		data = binary.BigEndian.AppendUint16(data, uint16(len(a.credentialID)))
+		data = append(data, a.credentialID...)
+		data = append(data, a.coseKey()...)
+	}
+	return data
+}
+
+// attestationObject は attestation "none" の attestationObject を作ります。
+func (a *softwareAuthenticator) attestationObject(authData []byte) []byte {
+	return encodeCBOR(cborMap{
+		{"fmt", "none"},
+		{"attStmt", cborMap{}},
+		{"authData", authData},
+	})
+}
+
+// sign は authenticatorData と clientDataJSON のハッシュを連結したデータに署名します。
+func (a *softwareAuthenticator) sign(t *testing.T, authData, clientDataJSON []byte) []byte {
+	t.Helper()
+	hash := sha256.Sum256(clientDataJSON)
+	signed := append(append([]byte(nil), authData...), hash[:]...)
+	if a.alg == AlgEdDSA {
+		return ed25519.Sign(a.edKey, signed)
+	}
+	digest := sha256.Sum256(signed)
+	sig, err := ecdsa.SignASN1(rand.Reader, a.ecKey, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sig
+}
+
+// clientDataJSON はブラウザが作る clientDataJSON を再現します。
+func clientDataJSON(t *testing.T, typ, challenge, origin string) []byte {
+	t.Helper()
+	raw, err := json.Marshal(CollectedClientData{Type: typ, Challenge: challenge, Origin: origin})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func parseClientData(t *testing.T, raw []byte) *CollectedClientData {
+	t.Helper()
+	cd, err := ParseClientData(raw)
+	if err != nil {
+		t.Fatalf("ParseClientData: %v", err)
+	}
+	return cd
+}
+
+func newChallenge(t *testing.T) string {
+	t.Helper()
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		t.Fatal(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// register はソフトウェア認証器で登録し、検証済みの資格情報を返します。
+func register(t *testing.T, rp *RelyingParty, a *softwareAuthenticator) *Credential {
+	t.Helper()
+	cd := parseClientData(t, clientDataJSON(t, "webauthn.create", newChallenge(t), testOrigin))
+	authData := a.authenticatorData(testRPID, flagUserPresent|flagUserVerified|flagAttestedCredentialData, true)
+	cred, err := rp.VerifyRegistration(cd, a.attestationObject(authData))
+	if err != nil {
+		t.Fatalf("VerifyRegistration: %v", err)
+	}
+	return cred
+}
+
+func TestRegistrationAndAssertion(t *testing.T) {
+	for _, alg := range []int64{AlgES256, AlgEdDSA} {
+		t.Run(fmt.Sprint(alg), func(t *testing.T) {
+			rp := testRelyingParty()
+			a := newSoftwareAuthenticator(t, alg)
+
+			cred := register(t, rp, a)
+			if !bytes.Equal(cred.ID, a.credentialID) {
+				t.Errorf("credential ID = %x, want %x", cred.ID, a.credentialID)
+			}
+			if cred.Algorithm != alg {
+				t.Errorf("algorithm = %d, want %d", cred.Algorithm, alg)
+			}
+			if cred.SignCount != 1 {
+				t.Errorf("sign count = %d, want 1", cred.SignCount)
+			}
+
+			raw := clientDataJSON(t, "webauthn.get", newChallenge(t), testOrigin)
+			authData := a.authenticatorData(testRPID, flagUserPresent|flagUserVerified, false)
+			ad, err := rp.VerifyAssertion(parseClientData(t, raw), raw, authData, a.sign(t, authData, raw), cred.PublicKey)
+			if err != nil {
+				t.Fatalf("VerifyAssertion: %v", err)
+			}
+			if ad.SignCount != 2 {
+				t.Errorf("sign count = %d, want 2", ad.SignCount)
+			}
+		})
+	}
+}
+
+func TestVerifyRegistrationRejects(t *testing.T) {
+	tests := []struct {
+		name   string
+		typ    string
+		origin string
+		rpID   string
+		flags  byte
+	}{
+		{name: "wrong type", typ: "webauthn.get"},
+		{name: "wrong origin", origin: "https://evil.example.net"},
+		{name: "wrong rp id hash", rpID: "evil.example.net"},
+		{name: "user not present", flags: flagUserVerified | flagAttestedCredentialData},
+		{name: "user not verified", flags: flagUserPresent | flagAttestedCredentialData},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rp := testRelyingParty()
+			a := newSoftwareAuthenticator(t, AlgES256)
+			typ, origin, rpID, flags := "webauthn.create", testOrigin, testRPID, byte(flagUserPresent|flagUserVerified|flagAttestedCredentialData)
+			if tt.typ != "" {
+				typ = tt.typ
+			}
+			if tt.origin != "" {
+				origin = tt.origin
+			}
+			if tt.rpID != "" {
+				rpID = tt.rpID
+			}
+			if tt.flags != 0 {
+				flags = tt.flags
+			}
+
+			cd := parseClientData(t, clientDataJSON(t, typ, newChallenge(t), origin))
+			authData := a.authenticatorData(rpID, flags, true)
+			if _, err := rp.VerifyRegistration(cd, a.attestationObject(authData)); err == nil {
+				t.Fatal("VerifyRegistration succeeded, want error")
+			}
+		})
+	}
+}
+
+func TestVerifyRegistrationAllowsMissingUVWhenPreferred(t *testing.T) {
+	rp := testRelyingParty()
+	rp.UserVerification = UserVerificationPreferred
+	a := newSoftwareAuthenticator(t, AlgEdDSA)
+
+	cd := parseClientData(t, clientDataJSON(t, "webauthn.create", newChallenge(t), testOrigin))
+	authData := a.authenticatorData(testRPID, flagUserPresent|flagAttestedCredentialData, true)
+	if _, err := rp.VerifyRegistration(cd, a.attestationObject(authData)); err != nil {
+		t.Fatalf("VerifyRegistration: %v", err)
+	}
+}
+
+func TestVerifyAssertionRejects(t *testing.T) {
+	rp := testRelyingParty()
+	a := newSoftwareAuthenticator(t, AlgES256)
+	cred := register(t, rp, a)
+	other := newSoftwareAuthenticator(t, AlgES256)
+
+	tests := []struct {
+		name string
+		// build は検証に渡す clientDataJSON・authenticatorData・署名を返します。
+		build func(t *testing.T) (raw, authData, sig []byte)
+	}{
+		{"wrong type", func(t *testing.T) ([]byte, []byte, []byte) {
+			raw := clientDataJSON(t, "webauthn.create", newChallenge(t), testOrigin)
+			authData := a.authenticatorData(testRPID, flagUserPresent|flagUserVerified, false)
+			return raw, authData, a.sign(t, authData, raw)
+		}},
+		{"wrong origin", func(t *testing.T) ([]byte, []byte, []byte) {
+			raw := clientDataJSON(t, "webauthn.get", newChallenge(t), "https://evil.example.net")
+			authData := a.authenticatorData(testRPID, flagUserPresent|flagUserVerified, false)
+			return raw, authData, a.sign(t, authData, raw)
+		}},
+		{"wrong rp id hash", func(t *testing.T) ([]byte, []byte, []byte) {
+			raw := clientDataJSON(t, "webauthn.get", newChallenge(t), testOrigin)
+			authData := a.authenticatorData("evil.example.net", flagUserPresent|flagUserVerified, false)
+			return raw, authData, a.sign(t, authData, raw)
+		}},
+		{"user not present", func(t *testing.T) ([]byte, []byte, []byte) {
+			raw := clientDataJSON(t, "webauthn.get", newChallenge(t), testOrigin)
+			authData := a.authenticatorData(testRPID, flagUserVerified, false)
+			return raw, authData, a.sign(t, authData, raw)
+		}},
+		{"user not verified", func(t *testing.T) ([]byte, []byte, []byte) {
+			raw := clientDataJSON(t, "webauthn.get", newChallenge(t), testOrigin)
+			authData := a.authenticatorData(testRPID, flagUserPresent, false)
+			return raw, authData, a.sign(t, authData, raw)
+		}},
+		{"signed by another key", func(t *testing.T) ([]byte, []byte, []byte) {
+			raw := clientDataJSON(t, "webauthn.get", newChallenge(t), testOrigin)
+			authData := a.authenticatorData(testRPID, flagUserPresent|flagUserVerified, false)
+			return raw, authData, other.sign(t, authData, raw)
+		}},
+		{"tampered signature", func(t *testing.T) ([]byte, []byte, []byte) {
+			raw := clientDataJSON(t, "webauthn.get", newChallenge(t), testOrigin)
+			authData := a.authenticatorData(testRPID, flagUserPresent|flagUserVerified, false)
+			sig := a.sign(t, authData, raw)
+			sig[len(sig)-1] ^= 0x01
+			return raw, authData, sig
+		}},
+		{"tampered authenticator data", func(t *testing.T) ([]byte, []byte, []byte) {
+			raw := clientDataJSON(t, "webauthn.get", newChallenge(t), testOrigin)
+			authData := a.authenticatorData(testRPID, flagUserPresent|flagUserVerified, false)
+			sig := a.sign(t, authData, raw)
+			authData[36]++ // 署名カウンター
+			return raw, authData, sig
+		}},
+		// チャレンジの照合は呼び出し側で保存済みのチャレンジと行いますが、署名は clientDataJSON 全体に対するものであるため、
+		// 署名後にチャレンジを差し替えた応答 (別の要求への使い回し) は署名の検証で拒否されます。
+		{"challenge replaced after signing", func(t *testing.T) ([]byte, []byte, []byte) {
+			raw := clientDataJSON(t, "webauthn.get", newChallenge(t), testOrigin)
+			authData := a.authenticatorData(testRPID, flagUserPresent|flagUserVerified, false)
+			sig := a.sign(t, authData, raw)
+			return clientDataJSON(t, "webauthn.get", newChallenge(t), testOrigin), authData, sig
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw, authData, sig := tt.build(t)
+			if _, err := rp.VerifyAssertion(parseClientData(t, raw), raw, authData, sig, cred.PublicKey); err == nil {
+				t.Fatal("VerifyAssertion succeeded, want error")
+			}
+		})
+	}
+}
+
+func TestVerifyAssertionRejectsCrossOrigin(t *testing.T) {
+	rp := testRelyingParty()
+	a := newSoftwareAuthenticator(t, AlgEdDSA)
+	cred := register(t, rp, a)
+
+	raw, err := json.Marshal(CollectedClientData{Type: "webauthn.get", Challenge: newChallenge(t), Origin: testOrigin, CrossOrigin: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	authData := a.authenticatorData(testRPID, flagUserPresent|flagUserVerified, false)
+	if _, err := rp.VerifyAssertion(parseClientData(t, raw), raw, authData, a.sign(t, authData, raw), cred.PublicKey); err == nil {
+		t.Fatal("VerifyAssertion succeeded, want error")
+	}
+}
+
+func TestParseClientDataRejectsMissingChallenge(t *testing.T) {
+	for _, raw := range []string{`{"type":"webauthn.get","origin":"https://app.example.com"}`, `not json`} {
+		if _, err := ParseClientData([]byte(raw)); err == nil {
+			t.Errorf("ParseClientData(%q) succeeded, want error", raw)
+		}
+	}
+}
+
+func TestDecodeCBORMalformed(t *testing.T) {
+	a := newSoftwareAuthenticator(t, AlgES256)
+	valid := a.attestationObject(a.authenticatorData(testRPID, flagUserPresent|flagUserVerified|flagAttestedCredentialData, true))
+
+	// 途中で切れたデータは、どこで切れてもパニックせずにエラーになる必要があります。
+	for i := 0; i < len(valid); i++ {
+		if _, _, err := decodeCBOR(valid[:i]); err == nil {
+			t.Fatalf("decodeCBOR(valid[:%d]) succeeded, want error", i)
+		}
+	}
+
+	deep := bytes.Repeat([]byte{0x81}, maxCBORDepth+2) // 1要素の配列の入れ子
+	deep = append(deep, 0x00)
+	if _, _, err := decodeCBOR(deep); err == nil {
+		t.Error("decodeCBOR(deeply nested) succeeded, want error")
+	}
+
+	tests := map[string][]byte{
+		"indefinite length":   {0x9f, 0x00, 0xff},
+		"huge byte string":    {0x5b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+		"huge array":          {0x9b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+		"huge integer":        {0x1b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+		"duplicate map key":   {0xa2, 0x01, 0x00, 0x01, 0x00},
+		"unsupported map key": {0xa1, 0x40, 0x00},
+		"empty":               {},
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, _, err := decodeCBOR(data); err == nil {
+				t.Fatal("decodeCBOR succeeded, want error")
+			}
+		})
+	}
+}
+
+func TestVerifyRegistrationMalformed(t *testing.T) {
+	rp := testRelyingParty()
+	a := newSoftwareAuthenticator(t, AlgEdDSA)
+	cd := parseClientData(t, clientDataJSON(t, "webauthn.create", newChallenge(t), testOrigin))
+	authData := a.authenticatorData(testRPID, flagUserPresent|flagUserVerified|flagAttestedCredentialData, true)
+	valid := a.attestationObject(authData)
+
+	for i := 0; i < len(valid); i++ {
+		if _, err := rp.VerifyRegistration(cd, valid[:i]); err == nil {
+			t.Fatalf("VerifyRegistration(attestationObject[:%d]) succeeded, want error", i)
+		}
+	}
+	// authData の中の公開鍵が途中で切れている場合も、パニックせずにエラーになる必要があります。
+	for i := 37; i < len(authData); i++ {
+		if _, err := rp.VerifyRegistration(cd, a.attestationObject(authData[:i])); err == nil {
+			t.Fatalf("VerifyRegistration(authData[:%d]) succeeded, want error", i)
+		}
+	}
+	trailing := a.attestationObject(append(append([]byte(nil), authData...), 0x00))
+	if _, err := rp.VerifyRegistration(cd, trailing); err == nil {
+		t.Fatal("VerifyRegistration with trailing authData succeeded, want error")
+	}
+}
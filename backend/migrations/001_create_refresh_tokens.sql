@@ -0,0 +1,13 @@
+-- migrations/001_create_refresh_tokens.sql
+-- マイグレーションはファイル名の番号順に適用してください。
+-- リフレッシュトークン (平文は保存せず SHA-256 ハッシュのみを保存します)
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id          BIGINT AUTO_INCREMENT PRIMARY KEY,
+    user_id     BIGINT       NOT NULL,
+    token_hash  CHAR(64)     NOT NULL,
+    expires_at  DATETIME     NOT NULL,
+    created_at  DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    revoked_at  DATETIME     NULL,
+    UNIQUE KEY uq_refresh_tokens_token_hash (token_hash),
+    KEY idx_refresh_tokens_user_id (user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
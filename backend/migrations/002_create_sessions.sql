@@ -0,0 +1,17 @@
+-- migrations/002_create_sessions.sql
+-- ログインごとのセッション (端末) を記録します。JWT の sid クレームがこの id を指します。
+CREATE TABLE IF NOT EXISTS sessions (
+    id            BIGINT AUTO_INCREMENT PRIMARY KEY,
+    user_id       BIGINT        NOT NULL,
+    user_agent    VARCHAR(512)  NOT NULL DEFAULT '',
+    ip_address    VARCHAR(45)   NOT NULL DEFAULT '',
+    created_at    DATETIME      NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_seen_at  DATETIME      NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    revoked_at    DATETIME      NULL,
+    KEY idx_sessions_user_id (user_id, revoked_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- リフレッシュトークンを発行元のセッションに紐付けます。
+ALTER TABLE refresh_tokens
+    ADD COLUMN session_id BIGINT NOT NULL DEFAULT 0 AFTER user_id,
+    ADD KEY idx_refresh_tokens_session_id (session_id);
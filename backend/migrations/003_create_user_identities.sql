@@ -0,0 +1,25 @@
+-- migrations/003_create_user_identities.sql
+-- 外部プロバイダー (Google / GitHub / OIDC) のアカウントとユーザーの紐付け
+CREATE TABLE IF NOT EXISTS user_identities (
+    id          BIGINT AUTO_INCREMENT PRIMARY KEY,
+    user_id     BIGINT        NOT NULL,
+    provider    VARCHAR(64)   NOT NULL,
+    subject     VARCHAR(255)  NOT NULL,
+    email       VARCHAR(255)  NOT NULL DEFAULT '',
+    created_at  DATETIME      NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_user_identities_provider_subject (provider, subject),
+    UNIQUE KEY uq_user_identities_user_provider (user_id, provider)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- 認可リクエストの state と PKCE の code_verifier を一時的に保存します (1回限り有効)。
+CREATE TABLE IF NOT EXISTS oauth_states (
+    id             BIGINT AUTO_INCREMENT PRIMARY KEY,
+    state_hash     CHAR(64)      NOT NULL,
+    provider       VARCHAR(64)   NOT NULL,
+    code_verifier  VARCHAR(128)  NOT NULL,
+    nonce          VARCHAR(128)  NOT NULL,
+    link_user_id   BIGINT        NULL,
+    expires_at     DATETIME      NOT NULL,
+    created_at     DATETIME      NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_oauth_states_state_hash (state_hash)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,44 @@
+-- migrations/004_create_oauth_server.sql
+-- YUTAKA を OAuth2 認可サーバー / OIDC プロバイダーとして使うクライアントアプリケーション
+CREATE TABLE IF NOT EXISTS oauth_clients (
+    id                  BIGINT AUTO_INCREMENT PRIMARY KEY,
+    client_id           VARCHAR(64)    NOT NULL,
+    client_secret_hash  CHAR(64)       NOT NULL DEFAULT '',  -- 空の場合は公開クライアント (PKCE 必須)
+    name                VARCHAR(255)   NOT NULL,
+    redirect_uris       TEXT           NOT NULL,             -- 空白区切り
+    grant_types         VARCHAR(255)   NOT NULL,             -- 空白区切り
+    scopes              VARCHAR(255)   NOT NULL,             -- 空白区切り
+    owner_user_id       BIGINT         NOT NULL,
+    created_at          DATETIME       NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_oauth_clients_client_id (client_id),
+    KEY idx_oauth_clients_owner_user_id (owner_user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- 認可コード (1回限り有効、ハッシュのみ保存)
+CREATE TABLE IF NOT EXISTS oauth_authorization_codes (
+    id              BIGINT AUTO_INCREMENT PRIMARY KEY,
+    code_hash       CHAR(64)       NOT NULL,
+    client_id       VARCHAR(64)    NOT NULL,
+    user_id         BIGINT         NOT NULL,
+    redirect_uri    VARCHAR(2048)  NOT NULL,
+    scope           VARCHAR(255)   NOT NULL,
+    nonce           VARCHAR(255)   NOT NULL DEFAULT '',
+    code_challenge  VARCHAR(128)   NOT NULL DEFAULT '',
+    expires_at      DATETIME       NOT NULL,
+    created_at      DATETIME       NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_oauth_authorization_codes_code_hash (code_hash)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- クライアントに発行したリフレッシュトークン (ハッシュのみ保存、使用ごとにローテーション)
+CREATE TABLE IF NOT EXISTS oauth_refresh_tokens (
+    id          BIGINT AUTO_INCREMENT PRIMARY KEY,
+    token_hash  CHAR(64)       NOT NULL,
+    client_id   VARCHAR(64)    NOT NULL,
+    user_id     BIGINT         NOT NULL,
+    scope       VARCHAR(255)   NOT NULL,
+    expires_at  DATETIME       NOT NULL,
+    created_at  DATETIME       NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    revoked_at  DATETIME       NULL,
+    UNIQUE KEY uq_oauth_refresh_tokens_token_hash (token_hash),
+    KEY idx_oauth_refresh_tokens_client_user (client_id, user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,16 @@
+-- migrations/005_create_api_tokens.sql
+-- 個人用アクセストークン (API キー)。平文は保存せず、表示用の接頭辞と SHA-256 ハッシュのみを保存します。
+CREATE TABLE IF NOT EXISTS api_tokens (
+    id            BIGINT AUTO_INCREMENT PRIMARY KEY,
+    user_id       BIGINT        NOT NULL,
+    name          VARCHAR(100)  NOT NULL,
+    prefix        VARCHAR(16)   NOT NULL,
+    token_hash    CHAR(64)      NOT NULL,
+    scopes        VARCHAR(255)  NOT NULL,   -- 空白区切り
+    expires_at    DATETIME      NULL,       -- NULL の場合は無期限
+    last_used_at  DATETIME      NULL,
+    created_at    DATETIME      NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    revoked_at    DATETIME      NULL,
+    UNIQUE KEY uq_api_tokens_token_hash (token_hash),
+    KEY idx_api_tokens_user_id (user_id, revoked_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,13 @@
+-- migrations/006_create_magic_links.sql
+-- パスワードなしログイン用のメールリンク。トークンは SHA-256 ハッシュのみを保存し、1回限り有効です。
+CREATE TABLE IF NOT EXISTS magic_links (
+    id           BIGINT AUTO_INCREMENT PRIMARY KEY,
+    user_id      BIGINT        NOT NULL,
+    token_hash   CHAR(64)      NOT NULL,
+    ip_address   VARCHAR(45)   NOT NULL DEFAULT '',   -- リンクを要求した端末
+    expires_at   DATETIME      NOT NULL,
+    created_at   DATETIME      NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    consumed_at  DATETIME      NULL,
+    UNIQUE KEY uq_magic_links_token_hash (token_hash),
+    KEY idx_magic_links_user_id (user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
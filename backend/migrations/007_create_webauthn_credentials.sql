@@ -0,0 +1,30 @@
+-- migrations/007_create_webauthn_credentials.sql
+-- パスキー (WebAuthn の資格情報)。公開鍵は認証器から受け取った COSE_Key 形式のまま保存します。
+CREATE TABLE IF NOT EXISTS webauthn_credentials (
+    id               BIGINT AUTO_INCREMENT PRIMARY KEY,
+    user_id          BIGINT          NOT NULL,
+    credential_id    VARBINARY(1023) NOT NULL,
+    public_key       BLOB            NOT NULL,
+    algorithm        INT             NOT NULL,
+    sign_count       INT UNSIGNED    NOT NULL DEFAULT 0,
+    aaguid           VARBINARY(16)   NOT NULL,
+    transports       VARCHAR(255)    NOT NULL DEFAULT '',   -- 空白区切り
+    backup_eligible  BOOLEAN         NOT NULL DEFAULT FALSE,
+    backed_up        BOOLEAN         NOT NULL DEFAULT FALSE,
+    name             VARCHAR(100)    NOT NULL,
+    created_at       DATETIME        NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_used_at     DATETIME        NULL,
+    UNIQUE KEY uq_webauthn_credentials_credential_id (credential_id),
+    KEY idx_webauthn_credentials_user_id (user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- 登録・ログインのチャレンジ。1回限り有効で、ハッシュのみを保存します。
+CREATE TABLE IF NOT EXISTS webauthn_challenges (
+    id              BIGINT AUTO_INCREMENT PRIMARY KEY,
+    challenge_hash  CHAR(64)     NOT NULL,
+    user_id         BIGINT       NULL,       -- ログインのチャレンジでは NULL
+    ceremony        VARCHAR(16)  NOT NULL,   -- registration / login
+    expires_at      DATETIME     NOT NULL,
+    created_at      DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_webauthn_challenges_challenge_hash (challenge_hash)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,9 @@
+-- migrations/008_create_password_history.sql
+-- 直近に使用したパスワードのハッシュ。パスワードの再利用を禁止するために使用します。
+CREATE TABLE IF NOT EXISTS password_history (
+    id             BIGINT AUTO_INCREMENT PRIMARY KEY,
+    user_id        BIGINT        NOT NULL,
+    password_hash  VARCHAR(255)  NOT NULL,
+    created_at     DATETIME      NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    KEY idx_password_history_user_id (user_id, id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
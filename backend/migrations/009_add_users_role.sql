@@ -0,0 +1,5 @@
+-- migrations/009_add_users_role.sql
+-- ユーザーの権限。管理者APIは role = 'admin' のユーザーのみ使用できます。
+-- 最初の管理者は次のように手動で設定してください:
+--   UPDATE users SET role = 'admin' WHERE username = '<管理者のユーザー名>';
+ALTER TABLE users ADD COLUMN role VARCHAR(16) NOT NULL DEFAULT 'user';
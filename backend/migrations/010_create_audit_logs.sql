@@ -0,0 +1,24 @@
+-- migrations/010_create_audit_logs.sql
+-- 認証・アカウント操作の監査ログ。追記専用で、更新・削除はトリガーで拒否します。
+CREATE TABLE IF NOT EXISTS audit_logs (
+    id              BIGINT AUTO_INCREMENT PRIMARY KEY,
+    occurred_at     DATETIME(6)   NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    event           VARCHAR(64)   NOT NULL,   -- 例: auth.login, user.password_change
+    outcome         VARCHAR(16)   NOT NULL,   -- success / failure
+    actor_user_id   BIGINT        NULL,       -- 操作したユーザー (未ログインの場合は NULL)
+    target_user_id  BIGINT        NULL,       -- 操作の対象となったユーザー
+    ip_address      VARCHAR(45)   NOT NULL DEFAULT '',
+    user_agent      VARCHAR(512)  NOT NULL DEFAULT '',
+    request_id      VARCHAR(64)   NOT NULL DEFAULT '',
+    details         TEXT          NULL,       -- JSON
+    KEY idx_audit_logs_event (event, id),
+    KEY idx_audit_logs_actor (actor_user_id, id),
+    KEY idx_audit_logs_target (target_user_id, id),
+    KEY idx_audit_logs_occurred_at (occurred_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TRIGGER audit_logs_no_update BEFORE UPDATE ON audit_logs
+    FOR EACH ROW SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'audit_logs is append-only';
+
+CREATE TRIGGER audit_logs_no_delete BEFORE DELETE ON audit_logs
+    FOR EACH ROW SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'audit_logs is append-only';
@@ -0,0 +1,35 @@
+-- migrations/011_add_audit_log_chain.sql
+-- 監査ログのハッシュチェーンと署名付きチェックポイント。
+-- 各エントリーは直前のエントリーのハッシュ (prev_hash) を含めてハッシュ化され (entry_hash)、
+-- 途中のエントリーの改ざん・削除・並べ替えを "server audit verify" で検出できます。
+-- このマイグレーション以前のエントリーは entry_hash が空のままで、チェーンの対象外です。
+ALTER TABLE audit_logs
+    ADD COLUMN prev_hash  CHAR(64) NOT NULL DEFAULT '' AFTER details,
+    ADD COLUMN entry_hash CHAR(64) NOT NULL DEFAULT '' AFTER prev_hash;
+
+-- チェーンの末尾。追記時にこの行をロックして、エントリーを1件ずつ順番に連結します。
+CREATE TABLE IF NOT EXISTS audit_chain_head (
+    id           TINYINT  PRIMARY KEY,
+    last_log_id  BIGINT   NOT NULL DEFAULT 0,
+    last_hash    CHAR(64) NOT NULL DEFAULT ''
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+INSERT IGNORE INTO audit_chain_head (id) VALUES (1);
+
+-- サーバーの鍵 (Ed25519) で署名したチェーンの末尾。定期的に追加されます。
+-- データベースだけを書き換えられる攻撃者はチェーン全体を再計算できますが、署名は作り直せません。
+CREATE TABLE IF NOT EXISTS audit_checkpoints (
+    id           BIGINT AUTO_INCREMENT PRIMARY KEY,
+    last_log_id  BIGINT       NOT NULL,   -- 署名時点のチェーンの末尾のエントリー
+    last_hash    CHAR(64)     NOT NULL,   -- そのエントリーの entry_hash
+    signed_at    DATETIME(6)  NOT NULL,
+    key_id       VARCHAR(64)  NOT NULL,
+    signature    VARCHAR(128) NOT NULL,   -- base64url
+    KEY idx_audit_checkpoints_last_log_id (last_log_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TRIGGER audit_checkpoints_no_update BEFORE UPDATE ON audit_checkpoints
+    FOR EACH ROW SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'audit_checkpoints is append-only';
+
+CREATE TRIGGER audit_checkpoints_no_delete BEFORE DELETE ON audit_checkpoints
+    FOR EACH ROW SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'audit_checkpoints is append-only';
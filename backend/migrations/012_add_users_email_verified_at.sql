@@ -0,0 +1,7 @@
+-- migrations/012_add_users_email_verified_at.sql
+-- メールアドレスの確認日時と、管理者用のユーザー一覧のためのインデックス。
+-- メールのリンクでログインした場合や、確認済みのメールアドレスを持つ外部アカウントで登録した場合に設定されます。
+ALTER TABLE users
+    ADD COLUMN email_verified_at DATETIME NULL,
+    ADD KEY idx_users_created_at (created_at, id),
+    ADD KEY idx_users_email (email, id);
@@ -0,0 +1,7 @@
+-- migrations/013_add_users_profile.sql
+-- ユーザーのプロフィール。未設定の項目は空文字列です。
+ALTER TABLE users
+    ADD COLUMN display_name VARCHAR(64)  NOT NULL DEFAULT '',
+    ADD COLUMN bio          VARCHAR(500) NOT NULL DEFAULT '',
+    ADD COLUMN locale       VARCHAR(35)  NOT NULL DEFAULT '',   -- BCP 47 (例: ja-JP)
+    ADD COLUMN timezone     VARCHAR(64)  NOT NULL DEFAULT '';   -- IANA (例: Asia/Tokyo)
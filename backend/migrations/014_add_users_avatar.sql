@@ -0,0 +1,4 @@
+-- migrations/014_add_users_avatar.sql
+-- アバター画像。ストレージ上のキー (例: avatars/12/3f9a...c1.jpg) を保存し、
+-- サイズごとの画像は "<キーの拡張子の前>_<サイズ><拡張子>" に保存します。未設定の場合は空文字列です。
+ALTER TABLE users ADD COLUMN avatar_key VARCHAR(255) NOT NULL DEFAULT '';
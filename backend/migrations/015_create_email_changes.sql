@@ -0,0 +1,21 @@
+-- migrations/015_create_email_changes.sql
+-- メールアドレス変更の申請。新しいアドレスへの確認リンクと、旧アドレスへの取り消しリンクの
+-- トークンはどちらも SHA-256 ハッシュのみを保存します。
+CREATE TABLE IF NOT EXISTS email_changes (
+    id                 BIGINT AUTO_INCREMENT PRIMARY KEY,
+    user_id            BIGINT        NOT NULL,
+    old_email          VARCHAR(255)  NOT NULL,
+    new_email          VARCHAR(255)  NOT NULL,
+    confirm_token_hash CHAR(64)      NOT NULL,
+    revert_token_hash  CHAR(64)      NOT NULL,
+    ip_address         VARCHAR(45)   NOT NULL DEFAULT '',   -- 変更を申請した端末
+    expires_at         DATETIME      NOT NULL,              -- 確認リンクの有効期限
+    revert_expires_at  DATETIME      NOT NULL,              -- 取り消しリンクの有効期限
+    created_at         DATETIME      NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    confirmed_at       DATETIME      NULL,
+    reverted_at        DATETIME      NULL,
+    cancelled_at       DATETIME      NULL,                  -- 新しい申請や取り消しにより無効になった日時
+    UNIQUE KEY uq_email_changes_confirm_token_hash (confirm_token_hash),
+    UNIQUE KEY uq_email_changes_revert_token_hash (revert_token_hash),
+    KEY idx_email_changes_user_id (user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
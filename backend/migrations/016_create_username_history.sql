@@ -0,0 +1,12 @@
+-- migrations/016_create_username_history.sql
+-- ユーザー名の変更履歴。変更前のユーザー名は held_until まで元の所有者以外が使用できず、
+-- 古いユーザー名を参照するリンクから現在のユーザーを引くためにも使用します。
+CREATE TABLE IF NOT EXISTS username_history (
+    id          BIGINT AUTO_INCREMENT PRIMARY KEY,
+    user_id     BIGINT        NOT NULL,
+    username    VARCHAR(255)  NOT NULL,   -- 変更前のユーザー名
+    changed_at  DATETIME      NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    held_until  DATETIME      NOT NULL,   -- この日時まで他のユーザーの登録・変更を拒否します
+    KEY idx_username_history_username (username, changed_at),
+    KEY idx_username_history_user_id (user_id, changed_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,13 @@
+-- migrations/017_add_users_username_canonical.sql
+-- ユーザー名の正規形 (NFKC + ケースフォールディング) と、紛らわしい文字を寄せたスケルトン。
+-- 照合順序の大文字・小文字やアクセントの同一視に頼らず、アプリケーションで正規化した値を
+-- utf8mb4_bin で比較します。既存の行は NULL のままで、"server users canonicalize" で埋めます。
+ALTER TABLE users
+    ADD COLUMN username_canonical VARCHAR(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_bin NULL AFTER username,
+    ADD COLUMN username_skeleton  VARCHAR(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_bin NULL AFTER username_canonical,
+    ADD UNIQUE KEY uq_users_username_canonical (username_canonical),
+    ADD UNIQUE KEY uq_users_username_skeleton (username_skeleton);
+
+ALTER TABLE username_history
+    ADD COLUMN username_canonical VARCHAR(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_bin NOT NULL DEFAULT '' AFTER username,
+    ADD KEY idx_username_history_canonical (username_canonical, changed_at);
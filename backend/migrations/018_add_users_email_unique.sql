@@ -0,0 +1,7 @@
+-- migrations/018_add_users_email_unique.sql
+-- メールアドレスでもログインできるよう、メールアドレスを一意にします。
+-- 比較は列の照合順序 (大文字・小文字を区別しない) に従います。削除済みのユーザーも対象です。
+-- 適用前に次のクエリで重複がないことを確認し、ある場合はどちらかのアドレスを変更してください。
+--   SELECT email, COUNT(*) FROM users GROUP BY email HAVING COUNT(*) > 1;
+ALTER TABLE users
+    ADD UNIQUE KEY uq_users_email (email);
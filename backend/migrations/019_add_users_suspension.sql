@@ -0,0 +1,13 @@
+-- migrations/019_add_users_suspension.sql
+-- 管理者によるアカウントの停止と、次回ログイン時のパスワード変更の強制。
+-- 停止中のユーザーはログインできず、発行済みのトークンも使用できません。
+ALTER TABLE users
+    ADD COLUMN suspended_at             DATETIME     NULL,
+    ADD COLUMN suspension_reason        VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN password_change_required TINYINT(1)   NOT NULL DEFAULT 0;
+
+-- 管理者による代理ログイン (なりすまし) のセッション。
+-- impersonator_user_id は代理ログインした管理者、expires_at はセッションの有効期限です (通常のログインでは NULL)。
+ALTER TABLE sessions
+    ADD COLUMN impersonator_user_id BIGINT   NULL,
+    ADD COLUMN expires_at           DATETIME NULL;
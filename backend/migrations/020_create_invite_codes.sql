@@ -0,0 +1,28 @@
+-- migrations/020_create_invite_codes.sql
+-- 管理者が発行する招待コード。平文は保存せず、表示用の接頭辞と SHA-256 ハッシュのみを保存します。
+-- 登録時の use_count の加算とユーザーの作成は同じトランザクションで行い、max_uses を超えて使用されないようにします。
+CREATE TABLE IF NOT EXISTS invite_codes (
+    id          BIGINT AUTO_INCREMENT PRIMARY KEY,
+    prefix      VARCHAR(16)   NOT NULL,
+    code_hash   CHAR(64)      NOT NULL,
+    created_by  BIGINT        NOT NULL,   -- 発行した管理者
+    note        VARCHAR(255)  NOT NULL DEFAULT '',
+    role        VARCHAR(16)   NOT NULL DEFAULT 'user',  -- 登録したユーザーに付与するロール
+    email       VARCHAR(255)  NULL,       -- 指定した場合はこのメールアドレスでのみ登録できます
+    max_uses    INT           NOT NULL DEFAULT 1,
+    use_count   INT           NOT NULL DEFAULT 0,
+    expires_at  DATETIME      NOT NULL,
+    created_at  DATETIME      NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    revoked_at  DATETIME      NULL,
+    UNIQUE KEY uq_invite_codes_code_hash (code_hash),
+    KEY idx_invite_codes_created_at (created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- 招待コードで登録したユーザー。
+CREATE TABLE IF NOT EXISTS invite_code_uses (
+    invite_code_id  BIGINT    NOT NULL,
+    user_id         BIGINT    NOT NULL,
+    used_at         DATETIME  NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (invite_code_id, user_id),
+    KEY idx_invite_code_uses_user_id (user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
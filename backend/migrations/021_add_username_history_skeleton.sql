@@ -0,0 +1,7 @@
+-- migrations/021_add_username_history_skeleton.sql
+-- 手放されたユーザー名の保持を、紛らわしい文字を寄せたスケルトンでも確認するための列。
+-- 保持期間中は、元のユーザー名に見た目が似たユーザー名 (例: "paypal" に対する "paypa1") も他のユーザーが使用できません。
+-- 既存の行は空文字列のままで、"server users canonicalize" で埋めます。
+ALTER TABLE username_history
+    ADD COLUMN username_skeleton VARCHAR(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_bin NOT NULL DEFAULT '' AFTER username_canonical,
+    ADD KEY idx_username_history_skeleton (username_skeleton, held_until);
@@ -0,0 +1,6 @@
+-- migrations/022_add_oauth_authorization_codes_auth_time.sql
+-- 同意したログインセッションでユーザーが認証した日時 (ID トークンの auth_time)。
+-- 同意した日時 (created_at) とは異なり、古いセッションでの同意を新しいログインと誤認させないために使います。
+-- 既存の認可コードは NULL のままで、auth_time を含めずに ID トークンを発行します。
+ALTER TABLE oauth_authorization_codes
+    ADD COLUMN auth_time DATETIME NULL AFTER code_challenge;